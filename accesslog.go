@@ -0,0 +1,92 @@
+package otelfuego
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// accessLogMessage is the message of access log records
+const accessLogMessage = "http request"
+
+// accessLogLevel returns the level of the access log record of a request answered with status
+func accessLogLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// newAccessLogMiddleware returns a middleware writing a record per request to logger, with the fields of
+// request metrics and spans. The filters, route filters and enabled function of cfg apply as they do to
+// spans, and the tenant found WithTenantExtractor, the attributes of the route set with
+// WithRouteAttributes and those set WithAttributes are added.
+func newAccessLogMiddleware(logger *slog.Logger, cfg *config) func(http.Handler) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	cfg = newSelfMetrics(cfg).guardHooks(cfg)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.included(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			wrapped := acquireResponseWriter(w)
+			defer releaseResponseWriter(wrapped)
+			next.ServeHTTP(wrapped, r)
+			logAccess(r.Context(), logger, cfg, r, wrapped, time.Since(start))
+		})
+	}
+}
+
+// logAccess writes the access log record of r, answered through rw in d
+func logAccess(ctx context.Context, logger *slog.Logger, cfg *config, r *http.Request, rw *responseWriter, d time.Duration) {
+	level := accessLogLevel(rw.statusCode)
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 12)
+	attrs = append(attrs, slog.String(string(semconv.HTTPRequestMethodKey), r.Method))
+	if route := routePattern(r); route != "" {
+		attrs = append(attrs, slog.String(string(semconv.HTTPRouteKey), route))
+	}
+	if cfg.OmittedAttributes&omitURLPath == 0 {
+		attrs = append(attrs, slog.String(string(semconv.URLPathKey), r.URL.Path))
+	}
+	attrs = append(attrs,
+		slog.Int(string(semconv.HTTPResponseStatusCodeKey), rw.statusCode),
+		slog.Int(string(semconv.HTTPResponseBodySizeKey), rw.bytesWritten),
+		slog.Float64("http.server.request.duration", d.Seconds()),
+	)
+	if id := RequestIDFromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String(string(requestIDKey), id))
+	}
+	if tenant := tenantOf(ctx, cfg.TenantExtractor, r); tenant != "" {
+		attrs = append(attrs, slog.String(string(tenantIDKey), tenant))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsSampled() {
+		attrs = append(attrs,
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	for _, kv := range cfg.RouteAttributes.lookup(r) {
+		attrs = append(attrs, slog.Any(string(kv.Key), kv.Value.AsInterface()))
+	}
+	for _, kv := range cfg.Attributes {
+		attrs = append(attrs, slog.Any(string(kv.Key), kv.Value.AsInterface()))
+	}
+	logger.LogAttrs(ctx, level, accessLogMessage, attrs...)
+}
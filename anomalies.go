@@ -0,0 +1,31 @@
+package otelfuego
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const requestAnomaliesKey = attribute.Key("request.anomalies")
+
+// Request anomalies recorded in request.anomalies. The set is fixed, which bounds the attribute.
+// Duplicate Content-Length headers, Content-Length alongside Transfer-Encoding and missing or invalid
+// Host headers are not checked: net/http rejects or normalizes them before the handler is called.
+const (
+	anomalyConflictingContentType = "conflicting_content_type"
+)
+
+// requestAnomalies returns the malformed header patterns found on r, or nil if there are none
+func requestAnomalies(r *http.Request) []string {
+	var anomalies []string
+	if types := r.Header["Content-Type"]; len(types) > 1 {
+		for _, t := range types[1:] {
+			if !strings.EqualFold(t, types[0]) {
+				anomalies = append(anomalies, anomalyConflictingContentType)
+				break
+			}
+		}
+	}
+	return anomalies
+}
@@ -0,0 +1,91 @@
+package otelfuego_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithRequestAnomalies(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRequestAnomalies(),
+	)
+	server := httptest.NewServer(middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer server.Close()
+
+	// Requests are written on raw connections, as http.Client would not send malformed headers
+	tests := []struct {
+		name    string
+		request string
+		want    string
+	}{
+		{
+			name:    "well-formed",
+			request: "POST /orders HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 2\r\n\r\n{}",
+			want:    "",
+		},
+		{
+			name:    "conflicting content type",
+			request: "POST /orders HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Type: text/plain\r\nContent-Length: 2\r\n\r\n{}",
+			want:    "conflicting_content_type",
+		},
+		{
+			name:    "repeated content type",
+			request: "POST /orders HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Type: Application/JSON\r\nContent-Length: 2\r\n\r\n{}",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			conn, err := net.Dial("tcp", server.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = conn.Close() }()
+			if _, err := conn.Write([]byte(tt.request)); err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			attrs := attribute.NewSet(spans[0].Attributes...)
+			v, _ := attrs.Value("request.anomalies")
+			if got := strings.Join(v.AsStringSlice(), ","); got != tt.want {
+				t.Errorf("Expected anomalies '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// discardResponseWriter is a ResponseWriter that allocates nothing per request
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header { return w.header }
+
+func (w *discardResponseWriter) Write(data []byte) (int, error) { return len(data), nil }
+
+func (w *discardResponseWriter) WriteHeader(int) {}
+
+func benchmarkMiddleware(b *testing.B, opts ...otelfuego.Option) {
+	handler := otelfuego.Middleware("bench-service", opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("OK"))
+	}))
+
+	req := httptest.NewRequest("GET", "/users/42?expand=orders", nil)
+	w := &discardResponseWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkMiddleware_Baseline(b *testing.B) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("OK"))
+	})
+
+	req := httptest.NewRequest("GET", "/users/42?expand=orders", nil)
+	w := &discardResponseWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkMiddleware_NoopProvider(b *testing.B) {
+	benchmarkMiddleware(b, otelfuego.WithTracerProvider(noop.NewTracerProvider()))
+}
+
+func BenchmarkMiddleware_Unsampled(b *testing.B) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	benchmarkMiddleware(b, otelfuego.WithTracerProvider(tp))
+}
+
+func BenchmarkMiddleware_Sampled(b *testing.B) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	benchmarkMiddleware(b, otelfuego.WithTracerProvider(tp))
+}
+
+// BenchmarkMiddleware_Routed guards the span naming hot path: requests routed by ServeMux, as with
+// fuego route middleware, are named after their route without building the name again
+func BenchmarkMiddleware_Routed(b *testing.B) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("bench-service", otelfuego.WithTracerProvider(tp))
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest("GET", "/users/42?expand=orders", nil)
+	w := &discardResponseWriter{header: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mux.ServeHTTP(w, req)
+	}
+}
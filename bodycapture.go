@@ -0,0 +1,75 @@
+package otelfuego
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	requestBodySnippetKey  = attribute.Key("otelfuego.request.body_snippet")
+	responseBodySnippetKey = attribute.Key("otelfuego.response.body_snippet")
+)
+
+// bodySnippet returns at most limit bytes of body as a valid UTF-8 string
+func bodySnippet(body []byte, limit int) string {
+	return strings.ToValidUTF8(truncateValue(string(body), limit), "�")
+}
+
+// defaultCapturedBodyTypes are the request content types captured by WithFailedRequestBodyCapture
+// when none are given: text formats clients commonly get wrong. Form bodies are left out as they
+// carry login credentials.
+var defaultCapturedBodyTypes = []string{
+	"application/json",
+	"application/xml",
+	"text/plain",
+}
+
+// defaultCapturedBodyStatuses are the response status codes for which captured request bodies are
+// recorded unless set with WithFailedRequestBodyStatuses: those rejecting malformed payloads, as
+// opposed to e.g. failed authentication
+var defaultCapturedBodyStatuses = []int{
+	http.StatusBadRequest,
+	http.StatusRequestEntityTooLarge,
+	http.StatusUnsupportedMediaType,
+	http.StatusUnprocessableEntity,
+}
+
+// requestBodyCapture configures the capture of the bodies of failed requests
+type requestBodyCapture struct {
+	limit        int
+	contentTypes []string
+}
+
+// recordsStatus reports whether the captured body of a request answered with status is recorded
+func (c *requestBodyCapture) recordsStatus(status int, statuses []int) bool {
+	if statuses == nil {
+		statuses = defaultCapturedBodyStatuses
+	}
+	return slices.Contains(statuses, status)
+}
+
+// captures reports whether the body of r has one of the captured content types
+func (c *requestBodyCapture) captures(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && slices.Contains(c.contentTypes, mediaType)
+}
+
+// capturingBody wraps a request body to keep the first bytes read by the handler
+type capturingBody struct {
+	io.ReadCloser
+	captured []byte
+	limit    int
+}
+
+func (b *capturingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if remaining := b.limit - len(b.captured); remaining > 0 {
+		b.captured = append(b.captured, p[:min(n, remaining)]...)
+	}
+	return n, err
+}
@@ -0,0 +1,171 @@
+//go:build !otelfuego_minimal
+
+package otelfuego_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithErrorResponseBodyCapture(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithErrorResponseBodyCapture(32),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		switch r.URL.Path {
+		case "/fail":
+			status = http.StatusBadGateway
+		case "/invalid":
+			status = http.StatusBadRequest
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"error":"upstream inventory service timed out after 30s"}`))
+	}))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/fail", `{"error":"upstream inventory ser`},
+		{"/invalid", ""},
+		{"/ok", ""},
+	}
+	for _, tt := range tests {
+		exporter.Reset()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if !strings.HasSuffix(rec.Body.String(), "30s\"}") {
+			t.Errorf("Expected the full body to be sent for %s, got %q", tt.path, rec.Body.String())
+		}
+
+		attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+		v, ok := attrs.Value("otelfuego.response.body_snippet")
+		if ok != (tt.want != "") || v.AsString() != tt.want {
+			t.Errorf("Expected snippet %q for %s, got %q", tt.want, tt.path, v.AsString())
+		}
+	}
+}
+
+func TestMiddleware_WithFailedRequestBodyCapture(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithFailedRequestBodyCapture(16),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var order struct{ Quantity int }
+		if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"malformed", "application/json; charset=utf-8", `{"quantity": "three", "sku": "A-1"}`, `{"quantity": "th`},
+		{"valid", "application/json", `{"quantity": 3}`, ""},
+		{"not allowlisted", "application/octet-stream", `{"quantity": "three"}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			req := httptest.NewRequest("POST", "/orders", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+			v, ok := attrs.Value("otelfuego.request.body_snippet")
+			if ok != (tt.want != "") || v.AsString() != tt.want {
+				t.Errorf("Expected snippet %q, got %q", tt.want, v.AsString())
+			}
+		})
+	}
+}
+
+func TestMiddleware_WithFailedRequestBodyCapture_Statuses(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	login := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithFailedRequestBodyCapture(64, "application/x-www-form-urlencoded", "application/json"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+	}))
+	conflict := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithFailedRequestBodyCapture(64),
+		otelfuego.WithFailedRequestBodyStatuses(http.StatusConflict),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusConflict)
+	}))
+	formDefault := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithFailedRequestBodyCapture(64),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+
+	tests := []struct {
+		name        string
+		handler     http.Handler
+		contentType string
+		body        string
+		want        string
+	}{
+		{"401 form login", login, "application/x-www-form-urlencoded", "username=alice&password=hunter2", ""},
+		{"401 json login", login, "application/json", `{"password": "hunter2"}`, ""},
+		{"configured 409", conflict, "application/json", `{"sku": "A-1"}`, `{"sku": "A-1"}`},
+		{"form not captured by default", formDefault, "application/x-www-form-urlencoded", "quantity=three", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			req := httptest.NewRequest("POST", "/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			tt.handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+			v, ok := attrs.Value("otelfuego.request.body_snippet")
+			if ok != (tt.want != "") || v.AsString() != tt.want {
+				t.Errorf("Expected snippet %q, got %q", tt.want, v.AsString())
+			}
+		})
+	}
+}
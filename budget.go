@@ -0,0 +1,40 @@
+package otelfuego
+
+import (
+	"sync"
+	"time"
+)
+
+// spanBudget is a token bucket bounding the rate of spans created by a middleware. It holds up to
+// one second worth of tokens, so short bursts above the rate are allowed.
+type spanBudget struct {
+	rate float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newSpanBudget(perSecond int) *spanBudget {
+	return &spanBudget{rate: float64(perSecond), tokens: float64(perSecond)}
+}
+
+// allow takes a token from the bucket, reporting whether one was available
+func (b *spanBudget) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.rate {
+			b.tokens = b.rate
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
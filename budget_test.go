@@ -0,0 +1,54 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware_WithMaxSpansPerSecond(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPropagators(propagation.TraceContext{}),
+		otelfuego.WithMaxSpansPerSecond(2),
+	)
+
+	served := 0
+	var untraced trace.SpanContext
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		untraced = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest("GET", "/api/users", nil)
+		req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if served != 5 {
+		t.Errorf("Expected all 5 requests to be served, got %d", served)
+	}
+	if spans := exporter.GetSpans(); len(spans) != 2 {
+		t.Errorf("Expected 2 spans within the budget, got %d", len(spans))
+	}
+	if untraced.TraceID().String() != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("Expected untraced requests to keep the incoming trace context, got %v", untraced.TraceID())
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !otelfuego_minimal
+
+package otelfuego
+
+// minimalBuild reports whether the package was built with the otelfuego_minimal build tag
+const minimalBuild = false
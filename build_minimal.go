@@ -0,0 +1,13 @@
+//go:build otelfuego_minimal
+
+package otelfuego
+
+// minimalBuild reports whether the package was built with the otelfuego_minimal build tag.
+//
+// Minimal builds only record bare server spans: body capture (WithGraphQL,
+// WithErrorResponseBodyCapture, WithFailedRequestBodyCapture), phase spans (WithPhaseSpans), log
+// events (LogBridge) and metrics, those of the middleware as well as request metrics, are compiled
+// out of the request path, and the corresponding options have no effect. Use it for resource-constrained edge deployments:
+//
+//	go build -tags otelfuego_minimal ./...
+const minimalBuild = true
@@ -0,0 +1,74 @@
+//go:build otelfuego_minimal
+
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMinimalBuild_BareSpans(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithGraphQL("/graphql"),
+		otelfuego.WithPhaseSpans(),
+	)
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errors": [{"message": "ignored"}]}`))
+	}))
+
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "query GetUser { me }"}`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "POST" {
+		t.Errorf("Expected GraphQL mode to be compiled out, got span name '%s'", spans[0].Name)
+	}
+}
+
+func TestMinimalBuild_NoMetrics(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithRequestMetrics(),
+		otelfuego.WithTimeToFirstByteMetric(),
+		otelfuego.WithOverheadMetric(),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	if len(rm.ScopeMetrics) != 0 {
+		t.Errorf("Expected metrics to be compiled out, got %+v", rm.ScopeMetrics)
+	}
+}
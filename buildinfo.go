@@ -0,0 +1,38 @@
+package otelfuego
+
+import (
+	"runtime/debug"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	fuegoModulePath = "github.com/go-fuego/fuego"
+	fuegoVersionKey = attribute.Key("fuego.version")
+)
+
+// fuegoVersion returns the version of the fuego module built into the binary, or an empty string if
+// fuego is not a dependency or the binary carries no build information. Replaced modules report the
+// version of their replacement.
+var fuegoVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return moduleVersion(info, fuegoModulePath)
+})
+
+// moduleVersion returns the version of the dependency path of the binary described by info
+func moduleVersion(info *debug.BuildInfo, path string) string {
+	for _, dep := range info.Deps {
+		if dep.Path != path {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package otelfuego
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const spanNameCollapsedKey = attribute.Key("otelfuego.span_name.collapsed")
+
+// cardinalityGuard bounds the number of distinct values, such as span names or tenants, produced by a
+// middleware. Once the limit is reached, only the values already seen are allowed.
+type cardinalityGuard struct {
+	limit int
+
+	mu     sync.RWMutex
+	values map[string]struct{}
+}
+
+func newCardinalityGuard(limit int) *cardinalityGuard {
+	return &cardinalityGuard{limit: limit, values: make(map[string]struct{})}
+}
+
+// allow reports whether value may be used, remembering it while below the limit
+func (g *cardinalityGuard) allow(value string) bool {
+	g.mu.RLock()
+	_, seen := g.values[value]
+	full := len(g.values) >= g.limit
+	g.mu.RUnlock()
+	if seen || full {
+		return seen
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, seen := g.values[value]; seen {
+		return true
+	}
+	if len(g.values) >= g.limit {
+		return false
+	}
+	g.values[value] = struct{}{}
+	return true
+}
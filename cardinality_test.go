@@ -0,0 +1,60 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithMaxSpanNames(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRawPathSpanNames(),
+		otelfuego.WithMaxSpanNames(2),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3", "/users/1"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(spans))
+	}
+
+	expected := []string{"GET /users/1", "GET /users/2", "HTTP GET", "GET /users/1"}
+	for i, span := range spans {
+		if span.Name != expected[i] {
+			t.Errorf("Span %d: expected name '%s', got '%s'", i, expected[i], span.Name)
+		}
+	}
+
+	attrs := attribute.NewSet(spans[2].Attributes...)
+	if v, _ := attrs.Value("otelfuego.span_name.collapsed"); !v.AsBool() {
+		t.Error("Expected collapsed span to be marked")
+	}
+	if v, _ := attrs.Value("url.path"); v.AsString() != "/users/3" {
+		t.Errorf("Expected url.path '/users/3', got '%s'", v.AsString())
+	}
+	first := attribute.NewSet(spans[0].Attributes...)
+	if _, ok := first.Value("otelfuego.span_name.collapsed"); ok {
+		t.Error("Expected spans within the limit not to be marked")
+	}
+}
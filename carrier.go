@@ -0,0 +1,49 @@
+package otelfuego
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// CarrierFactory returns the carrier the trace context of a request is extracted from
+type CarrierFactory func(*http.Request) propagation.TextMapCarrier
+
+// QueryCarrier is a CarrierFactory reading the trace context from the query parameters of the
+// request, e.g. ?traceparent=..., falling back to its headers for fields absent from the query.
+// Parameter names are matched in lowercase. Query parameters are trusted as headers are:
+// WithStripIncomingContext ignores their trace context and WithStripIncomingState their trace state
+// and baggage.
+//
+// Example:
+//
+//	otelfuego.WithCarrierFactory(otelfuego.QueryCarrier)
+func QueryCarrier(r *http.Request) propagation.TextMapCarrier {
+	return queryCarrier{query: r.URL.Query(), header: propagation.HeaderCarrier(r.Header)}
+}
+
+// queryCarrier is a read-only carrier over query parameters and headers
+type queryCarrier struct {
+	query  url.Values
+	header propagation.HeaderCarrier
+}
+
+func (c queryCarrier) Get(key string) string {
+	if v := c.query.Get(strings.ToLower(key)); v != "" {
+		return v
+	}
+	return c.header.Get(key)
+}
+
+// Set does nothing, the carrier is only used for extraction
+func (c queryCarrier) Set(string, string) {}
+
+func (c queryCarrier) Keys() []string {
+	keys := c.header.Keys()
+	for k := range c.query {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,133 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithCarrierFactory(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	cookieCarrier := func(r *http.Request) propagation.TextMapCarrier {
+		cookie, err := r.Cookie("traceparent")
+		if err != nil {
+			return nil
+		}
+		return propagation.MapCarrier{"traceparent": cookie.Value}
+	}
+
+	tests := []struct {
+		name    string
+		factory otelfuego.CarrierFactory
+		request func() *http.Request
+	}{
+		{"query", otelfuego.QueryCarrier, func() *http.Request {
+			return httptest.NewRequest("GET", "/ws?traceparent="+traceparent, nil)
+		}},
+		{"query falls back to headers", otelfuego.QueryCarrier, func() *http.Request {
+			req := httptest.NewRequest("GET", "/ws", nil)
+			req.Header.Set("traceparent", traceparent)
+			return req
+		}},
+		{"cookie", cookieCarrier, func() *http.Request {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.AddCookie(&http.Cookie{Name: "traceparent", Value: traceparent})
+			return req
+		}},
+		{"nil carrier uses headers", cookieCarrier, func() *http.Request {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("traceparent", traceparent)
+			return req
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			handler := otelfuego.Middleware("test-service",
+				otelfuego.WithTracerProvider(tp),
+				otelfuego.WithPropagators(propagation.TraceContext{}),
+				otelfuego.WithCarrierFactory(tt.factory),
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			handler.ServeHTTP(httptest.NewRecorder(), tt.request())
+
+			span := exporter.GetSpans()[0]
+			if !span.Parent.IsRemote() || span.Parent.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("Expected the trace context to be extracted, got parent %v", span.Parent)
+			}
+		})
+	}
+}
+
+func TestMiddleware_QueryCarrier_Untrusted(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// Query parameters are as untrusted as the headers of the same request
+	var bag baggage.Baggage
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPropagators(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})),
+		otelfuego.WithCarrierFactory(otelfuego.QueryCarrier),
+		otelfuego.WithStripIncomingContext(func(r *http.Request) bool { return r.Header.Get("X-Client") == "public" }),
+		otelfuego.WithStripIncomingState(func(r *http.Request) bool { return r.Header.Get("X-Client") == "partner" }, "session.id"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bag = baggage.FromContext(r.Context())
+	}))
+
+	const query = "/ws?traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" +
+		"&tracestate=vendor%3Dpriority&baggage=session.id%3Ds1%2Cuser.tier%3Dgold"
+	tests := []struct {
+		client     string
+		continued  bool
+		traceState string
+		baggage    []string
+	}{
+		{"public", false, "", nil},
+		{"partner", true, "", []string{"session.id"}},
+		{"internal", true, "vendor=priority", []string{"session.id", "user.tier"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.client, func(t *testing.T) {
+			exporter.Reset()
+			req := httptest.NewRequest("GET", query, nil)
+			req.Header.Set("X-Client", tt.client)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			span := exporter.GetSpans()[0]
+			if span.Parent.IsRemote() != tt.continued {
+				t.Errorf("Expected the trace to be continued: %v, got parent %v", tt.continued, span.Parent)
+			}
+			if got := span.SpanContext.TraceState().String(); got != tt.traceState {
+				t.Errorf("Expected trace state %q, got %q", tt.traceState, got)
+			}
+			if bag.Len() != len(tt.baggage) {
+				t.Errorf("Expected baggage members %v, got %q", tt.baggage, bag.String())
+			}
+			for _, key := range tt.baggage {
+				if bag.Member(key).Value() == "" {
+					t.Errorf("Expected baggage member %s, got %q", key, bag.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package otelfuego
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	clientTraceDurationKey = attribute.Key("otelfuego.duration_ms")
+	clientTraceErrorKey    = attribute.Key("otelfuego.error")
+	connectionReusedKey    = attribute.Key("otelfuego.connection.reused")
+)
+
+// clientTrace records the connection setup of an outgoing request as events of its client span. Hooks
+// can be called concurrently, e.g. when dialing several addresses of a host.
+type clientTrace struct {
+	span trace.Span
+
+	mu     sync.Mutex
+	starts map[string]time.Time
+}
+
+// newClientTrace returns the httptrace hooks recording the phases of a request on span: dns, connect
+// and tls, with their duration, then the first response byte
+func newClientTrace(span trace.Span) *httptrace.ClientTrace {
+	t := &clientTrace{span: span, starts: make(map[string]time.Time, 3)}
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.start("dns") },
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			t.done("dns", info.Err)
+		},
+		ConnectStart: func(network, addr string) { t.start("connect") },
+		ConnectDone: func(network, addr string, err error) {
+			t.done("connect", err)
+		},
+		TLSHandshakeStart: func() { t.start("tls") },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			t.done("tls", err)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			span.AddEvent("http.got_conn", trace.WithAttributes(connectionReusedKey.Bool(info.Reused)))
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			span.AddEvent("http.wrote_request")
+		},
+		GotFirstResponseByte: func() {
+			span.AddEvent("http.first_byte")
+		},
+	}
+}
+
+func (t *clientTrace) start(phase string) {
+	now := time.Now()
+	t.mu.Lock()
+	t.starts[phase] = now
+	t.mu.Unlock()
+	t.span.AddEvent("http."+phase+".start", trace.WithTimestamp(now))
+}
+
+func (t *clientTrace) done(phase string, err error) {
+	now := time.Now()
+	t.mu.Lock()
+	start := t.starts[phase]
+	t.mu.Unlock()
+
+	attrs := make([]attribute.KeyValue, 0, 2)
+	if !start.IsZero() {
+		attrs = append(attrs, clientTraceDurationKey.Float64(milliseconds(now.Sub(start))))
+	}
+	if err != nil {
+		attrs = append(attrs, clientTraceErrorKey.String(err.Error()))
+	}
+	t.span.AddEvent("http."+phase+".done", trace.WithTimestamp(now), trace.WithAttributes(attrs...))
+}
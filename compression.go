@@ -0,0 +1,49 @@
+package otelfuego
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+)
+
+// responseSizeKey records the size of the response body as sent, i.e. after content encoding
+const responseSizeKey = attribute.Key("http.response.size")
+
+// minGzipSize is the size of an empty gzip stream: a 10 byte header and an 8 byte trailer
+const minGzipSize = 18
+
+// encodedBody reports whether a body sent with the Content-Encoding header value encoding is compressed
+func encodedBody(encoding string) bool {
+	return encoding != "" && !strings.EqualFold(encoding, "identity")
+}
+
+// appendTail appends the last bytes of data to tail, which keeps the last len(tail) bytes written
+func appendTail(tail *[4]byte, data []byte) {
+	if len(data) >= len(tail) {
+		copy(tail[:], data[len(data)-len(tail):])
+		return
+	}
+	copy(tail[:], tail[len(data):])
+	copy(tail[len(tail)-len(data):], data)
+}
+
+// appendSizeAttributes appends the uncompressed size of the response body as http.response.body.size
+// and its size as sent as http.response.size. Responses encoded before reaching the middleware, e.g.
+// by a gzip middleware inside it, were counted as sent; the uncompressed size of gzip bodies is read
+// from the trailer of the stream, and unknown for other encodings. Other responses were counted
+// uncompressed, and their size as sent is unknown once a middleware outside compresses them.
+func (rw *responseWriter) appendSizeAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if !rw.encoded {
+		attrs = append(attrs, semconv.HTTPResponseBodySizeKey.Int(rw.bytesWritten))
+		if !encodedBody(rw.Header().Get("Content-Encoding")) {
+			attrs = append(attrs, responseSizeKey.Int(rw.bytesWritten))
+		}
+		return attrs
+	}
+	if rw.gzipped && rw.bytesWritten >= minGzipSize {
+		attrs = append(attrs, semconv.HTTPResponseBodySizeKey.Int64(int64(binary.LittleEndian.Uint32(rw.tail[:]))))
+	}
+	return append(attrs, responseSizeKey.Int(rw.bytesWritten))
+}
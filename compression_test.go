@@ -0,0 +1,81 @@
+package otelfuego_test
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// gzipWriter compresses the body written to a ResponseWriter
+type gzipWriter struct {
+	http.ResponseWriter
+	zw *gzip.Writer
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	w.Header().Set("Content-Encoding", "gzip")
+	return w.zw.Write(data)
+}
+
+// gzipMiddleware compresses the responses of next, like common gzip middleware
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zw := gzip.NewWriter(w)
+		defer func() { _ = zw.Close() }()
+		next.ServeHTTP(&gzipWriter{ResponseWriter: w, zw: zw}, r)
+	})
+}
+
+func TestMiddleware_CompressedResponseSizes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	body := strings.Repeat("compressible ", 100)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})
+	middleware := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))
+
+	tests := []struct {
+		name         string
+		handler      http.Handler
+		wantBodySize int64
+		wantWire     bool
+	}{
+		{"uncompressed", middleware(handler), int64(len(body)), true},
+		{"compressed inside", middleware(gzipMiddleware(handler)), int64(len(body)), true},
+		{"compressed outside", gzipMiddleware(middleware(handler)), int64(len(body)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest("GET", "/report", nil))
+
+			attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+			if v, _ := attrs.Value("http.response.body.size"); v.AsInt64() != tt.wantBodySize {
+				t.Errorf("Expected http.response.body.size %d, got %d", tt.wantBodySize, v.AsInt64())
+			}
+			v, ok := attrs.Value("http.response.size")
+			if ok != tt.wantWire {
+				t.Fatalf("Expected http.response.size recorded %v, got %v", tt.wantWire, ok)
+			}
+			if ok && v.AsInt64() != int64(w.Body.Len()) {
+				t.Errorf("Expected http.response.size %d, got %d", w.Body.Len(), v.AsInt64())
+			}
+		})
+	}
+}
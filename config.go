@@ -1,20 +1,122 @@
 package otelfuego
 
 import (
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"log/slog"
 	"net/http"
+	"net/netip"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 )
 
 // config holds the configuration for the OpenTelemetry middleware
 type config struct {
-	TracerProvider    trace.TracerProvider
-	Propagators       propagation.TextMapPropagator
-	Filter            Filter
-	SpanNameFormatter SpanNameFormatter
+	TracerProvider            trace.TracerProvider
+	AdditionalTracerProviders []trace.TracerProvider
+	MeterProvider             metric.MeterProvider
+	MaxSpansPerSecond         int
+	Enabled                   func() bool
+	Propagators               propagation.TextMapPropagator
+	CarrierFactory            CarrierFactory
+	ClientTrace               bool
+	ErrorResponseBodyCapture  int
+	FailedRequestBodyCapture  *requestBodyCapture
+	FailedRequestBodyStatuses []int
+	ErrorFingerprinter        ErrorFingerprinter
+	ErrorHandler              func(error)
+	Filter                    Filter
+	RouteFilter               RouteFilter
+	RouteFilterMux            *http.ServeMux
+	SpanNameFormatter         SpanNameFormatter
+	SpanNameHeader            *spanNameHeader
+	RouteSamplers             *routeSamplers
+	Nested                    NestedMode
+	SpanKind                  trace.SpanKind
+	Semconv                   SemconvMode
+	ServiceNameFunc           func(*http.Request) string
+	ServiceNameAttribute      bool
+	EndUserExtractor          EndUserExtractor
+	TenantExtractor           TenantExtractor
+	MaxMetricTenants          int
+	LazyAttributes            []func(*http.Request) []attribute.KeyValue
+	TraceStateMutator         func(*http.Request, trace.TraceState) trace.TraceState
+	CorrelationHeaders        []string
+	CorrelationIDDecoder      CorrelationIDDecoder
+	RequestIDHeader           string
+	ContextLogger             *slog.Logger
+	OmittedAttributes         omittedAttributes
+	MaxAttributeValueLength   int
+	MaxSpanNames              int
+	DebugSpans                bool
+	OverheadMetric            bool
+	TimeToFirstByteMetric     bool
+	RequestMetrics            bool
+	MetricAttributes          []MetricAttribute
+	RouteAttributes           routeAttributes
+	Attributes                []attribute.KeyValue
+	DurationBoundaries        []float64
+	OpenAPIOperations         OpenAPIOperations
+	OpenAPIMetadata           bool
+	PhaseSpans                bool
+	MaxLogEvents              int
+	GraphQLPath               string
+	RouteGroup                string
+	PathParams                bool
+	ContentNegotiation        bool
+	TLSClientSubject          bool
+	ResponseThroughput        *responseThroughput
+	LongRequestInterval       time.Duration
+	RedactedPathParams        []string
+
+	StripIncomingContext    func(*http.Request) bool
+	PreserveStrippedContext bool
+	StripIncomingState      func(*http.Request) bool
+	AllowedBaggageKeys      []string
+
+	Experiments            []Experiment
+	CapturedRequestHeaders []capturedHeader
+
+	CountRequestBody bool
+	StrictSemconv    SemconvReporter
+	RecordAnomalies  bool
+	TimingAttributes bool
+	QueueTimeHeaders []string
+	LatencyFlags     *latencyFlags
+
+	SniffBody          bool
+	RejectBodyMismatch bool
+
+	ClientDisconnectStatus string
+	LegacySpanStatus       bool
+
+	SlowestRequests int
+
+	PublishConfig bool
+	ConfigProfile string
+
+	ScopeName       string
+	ScopeVersion    string
+	ScopeAttributes []attribute.KeyValue
+
+	// customCorrelationIDDecoder is set by WithCorrelationIDDecoder, which requires correlation headers
+	customCorrelationIDDecoder bool
+
+	// invalid holds the problems found while applying options, reported by validate
+	invalid []error
 }
 
 // Option is a function that configures the middleware
@@ -32,6 +134,10 @@ func (o optionFunc) apply(c *config) {
 // Filter is a function that determines whether a request should be traced
 type Filter func(*http.Request) bool
 
+// RouteFilter is a function that determines whether a request should be traced given the ServeMux
+// pattern of the route it matched, e.g. "GET /users/{id}", or an empty string if no route matched
+type RouteFilter func(r *http.Request, pattern string) bool
+
 // SpanNameFormatter is a function that formats the span name based on the operation and request
 type SpanNameFormatter func(operation string, r *http.Request) string
 
@@ -39,6 +145,11 @@ type SpanNameFormatter func(operation string, r *http.Request) string
 func newConfig(opts ...Option) *config {
 	c := &config{
 		SpanNameFormatter: defaultSpanNameFormatter,
+		MaxLogEvents:      defaultMaxLogEvents,
+		MaxMetricTenants:  defaultMaxMetricTenants,
+		SlowestRequests:   defaultSlowestRequests,
+		ScopeName:         instrumentationName,
+		ScopeVersion:      instrumentationVersion,
 	}
 
 	for _, opt := range opts {
@@ -48,9 +159,347 @@ func newConfig(opts ...Option) *config {
 	return c
 }
 
-// defaultSpanNameFormatter is the default span name formatter
+// features returns the sorted names of the optional features enabled in the config
+func (c *config) features() []string {
+	var features []string
+	if c.ScopeName != instrumentationName || c.ScopeVersion != instrumentationVersion || len(c.ScopeAttributes) > 0 {
+		features = append(features, "instrumentation_scope")
+	}
+	if c.TracerProvider != nil {
+		features = append(features, "tracer_provider")
+	}
+	if len(c.AdditionalTracerProviders) > 0 {
+		features = append(features, "additional_tracer_providers:"+strconv.Itoa(len(c.AdditionalTracerProviders)))
+	}
+	if c.Propagators != nil {
+		features = append(features, "propagators")
+	}
+	if c.Filter != nil {
+		features = append(features, "filter")
+	}
+	if c.RouteFilter != nil {
+		features = append(features, "route_filter")
+	}
+	if c.Enabled != nil {
+		features = append(features, "enabled_func")
+	}
+	if c.MeterProvider != nil {
+		features = append(features, "meter_provider")
+	}
+	if c.MaxSpansPerSecond > 0 {
+		features = append(features, "max_spans_per_second:"+strconv.Itoa(c.MaxSpansPerSecond))
+	}
+	if c.SpanNameFormatter != nil && !isDefaultSpanNameFormatter(c.SpanNameFormatter) {
+		features = append(features, "span_name_formatter")
+	}
+	if c.SpanKind != trace.SpanKindUnspecified && c.SpanKind != trace.SpanKindServer {
+		features = append(features, "span_kind:"+c.SpanKind.String())
+	}
+	if c.MetricAttributes != nil {
+		features = append(features, "metric_attributes")
+	}
+	if len(c.RouteAttributes) > 0 {
+		features = append(features, "route_attributes")
+	}
+	if c.RequestMetrics {
+		features = append(features, "request_metrics")
+	}
+	if c.TimeToFirstByteMetric {
+		features = append(features, "time_to_first_byte_metric")
+	}
+	if c.OverheadMetric {
+		features = append(features, "overhead_metric")
+	}
+	if len(c.DurationBoundaries) > 0 {
+		features = append(features, "duration_histogram_boundaries")
+	}
+	if c.DebugSpans {
+		features = append(features, "debug_spans")
+	}
+	if c.MaxSpanNames > 0 {
+		features = append(features, "max_span_names:"+strconv.Itoa(c.MaxSpanNames))
+	}
+	if c.MaxAttributeValueLength > 0 {
+		features = append(features, "max_attribute_value_length:"+strconv.Itoa(c.MaxAttributeValueLength))
+	}
+	if c.MaxLogEvents != defaultMaxLogEvents {
+		features = append(features, "max_log_events:"+strconv.Itoa(c.MaxLogEvents))
+	}
+	if c.TenantExtractor != nil {
+		features = append(features, "tenant_extractor")
+	}
+	if c.MaxMetricTenants != defaultMaxMetricTenants {
+		features = append(features, "max_metric_tenants:"+strconv.Itoa(c.MaxMetricTenants))
+	}
+	if len(c.LazyAttributes) > 0 {
+		features = append(features, "lazy_attributes")
+	}
+	if c.TraceStateMutator != nil {
+		features = append(features, "trace_state_mutator")
+	}
+	if len(c.CorrelationHeaders) > 0 {
+		features = append(features, "correlation_links")
+	}
+	if c.customCorrelationIDDecoder {
+		features = append(features, "correlation_id_decoder")
+	}
+	if c.RequestIDHeader != "" {
+		features = append(features, "request_id")
+	}
+	if c.ContextLogger != nil {
+		features = append(features, "context_logger")
+	}
+	if len(c.Attributes) > 0 {
+		features = append(features, "attributes")
+	}
+	if c.OmittedAttributes&omitUserAgent != 0 {
+		features = append(features, "without_user_agent")
+	}
+	if c.OmittedAttributes&omitQuery != 0 {
+		features = append(features, "without_query")
+	}
+	if c.OmittedAttributes&omitURLPath != 0 {
+		features = append(features, "without_url_path")
+	}
+	if c.EndUserExtractor != nil {
+		features = append(features, "enduser_extractor")
+	}
+	if c.ServiceNameFunc != nil {
+		features = append(features, "service_name_func")
+	}
+	if c.ServiceNameAttribute {
+		features = append(features, "service_name_attribute")
+	}
+	if c.Semconv != 0 {
+		features = append(features, "semconv:"+strconv.Itoa(int(c.Semconv)))
+	}
+	if c.Nested != NestedSkip {
+		features = append(features, "nested:"+strconv.Itoa(int(c.Nested)))
+	}
+	if c.RouteSamplers != nil {
+		features = append(features, "route_samplers:"+strconv.Itoa(len(c.RouteSamplers.samplers)))
+	}
+	if c.SpanNameHeader != nil {
+		features = append(features, "span_name_from_header")
+	}
+	if len(c.OpenAPIOperations) > 0 {
+		features = append(features, "openapi_operations:"+strconv.Itoa(len(c.OpenAPIOperations)))
+	}
+	if c.OpenAPIMetadata {
+		features = append(features, "openapi_metadata")
+	}
+	if c.GraphQLPath != "" {
+		features = append(features, "graphql")
+	}
+	if c.RouteGroup != "" {
+		features = append(features, "route_group")
+	}
+	if c.PathParams {
+		features = append(features, "path_params")
+	}
+	if len(c.RedactedPathParams) > 0 {
+		features = append(features, "redacted_path_params")
+	}
+	if c.ContentNegotiation {
+		features = append(features, "content_negotiation")
+	}
+	if c.TLSClientSubject {
+		features = append(features, "tls_client_subject")
+	}
+	if c.ResponseThroughput != nil {
+		features = append(features, "response_throughput")
+	}
+	if c.LongRequestInterval > 0 {
+		features = append(features, "long_request_events")
+	}
+	if c.ErrorFingerprinter != nil {
+		features = append(features, "error_fingerprint")
+	}
+	if c.ErrorHandler != nil {
+		features = append(features, "error_handler")
+	}
+	if c.FailedRequestBodyCapture != nil {
+		features = append(features, "failed_request_body_capture")
+	}
+	if c.FailedRequestBodyStatuses != nil {
+		features = append(features, "failed_request_body_statuses")
+	}
+	if c.ErrorResponseBodyCapture > 0 {
+		features = append(features, "error_response_body_capture")
+	}
+	if c.ClientTrace {
+		features = append(features, "client_trace")
+	}
+	if c.CarrierFactory != nil {
+		features = append(features, "carrier_factory")
+	}
+	if c.StripIncomingState != nil {
+		features = append(features, "strip_incoming_state")
+	}
+	if len(c.AllowedBaggageKeys) > 0 {
+		features = append(features, "allowed_baggage_keys")
+	}
+	if c.StripIncomingContext != nil {
+		features = append(features, "strip_incoming_context")
+	}
+	if c.PreserveStrippedContext {
+		features = append(features, "stripped_context_attributes")
+	}
+	if c.CountRequestBody {
+		features = append(features, "count_request_body")
+	}
+	if c.StrictSemconv != nil {
+		features = append(features, "strict_semconv")
+	}
+	if c.RecordAnomalies {
+		features = append(features, "request_anomalies")
+	}
+	if len(c.QueueTimeHeaders) > 0 {
+		features = append(features, "queue_time")
+	}
+	if c.TimingAttributes {
+		features = append(features, "timing_attributes")
+	}
+	if c.LatencyFlags != nil {
+		features = append(features, "latency_flags")
+	}
+	if c.SniffBody {
+		features = append(features, "body_sniffing")
+	}
+	if c.RejectBodyMismatch {
+		features = append(features, "reject_body_mismatch")
+	}
+	if len(c.Experiments) > 0 {
+		features = append(features, "experiments")
+	}
+	if len(c.CapturedRequestHeaders) > 0 {
+		features = append(features, "captured_request_headers")
+	}
+	if c.LegacySpanStatus {
+		features = append(features, "legacy_span_status")
+	}
+	if c.ClientDisconnectStatus != "" {
+		features = append(features, "client_disconnect_status")
+	}
+	if c.SlowestRequests != defaultSlowestRequests {
+		features = append(features, "slowest_requests:"+strconv.Itoa(c.SlowestRequests))
+	}
+	if c.PhaseSpans {
+		features = append(features, "phase_spans")
+	}
+	sort.Strings(features)
+	return features
+}
+
+// scopeAttributes returns the instrumentation scope attributes describing the fuego version built into
+// the binary and the config, followed by those set with WithInstrumentationScope
+func (c *config) scopeAttributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if version := fuegoVersion(); version != "" {
+		attrs = append(attrs, fuegoVersionKey.String(version))
+	}
+	attrs = append(attrs, c.ScopeAttributes...)
+	if !c.PublishConfig {
+		return attrs
+	}
+
+	features := c.features()
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strings.Join(features, ",")))
+
+	return append(attrs,
+		attribute.String("otelfuego.config.profile", c.ConfigProfile),
+		attribute.String("otelfuego.config.hash", strconv.FormatUint(h.Sum64(), 16)),
+		attribute.StringSlice("otelfuego.config.features", features),
+	)
+}
+
+// isDefaultSpanNameFormatter reports whether f is the built-in span name formatter
+func isDefaultSpanNameFormatter(f SpanNameFormatter) bool {
+	return reflect.ValueOf(f).Pointer() == reflect.ValueOf(defaultSpanNameFormatter).Pointer()
+}
+
+// defaultSpanNameFormatter is the default span name formatter. It names spans after the method and
+// the matched route, e.g. "GET /users/{id}", or only the method while the route is unknown, keeping
+// the raw path out of the span name. The middleware adds the route once the request was routed.
+// It runs for every request, sampled or not, so it neither uses fmt nor builds names it built before.
 func defaultSpanNameFormatter(operation string, r *http.Request) string {
-	return fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+	if route := routePattern(r); route != "" {
+		return routeSpanName(r.Method, route)
+	}
+	return spanNameMethod(r.Method)
+}
+
+// routeSpanNameKey identifies the span names cached by routeSpanName
+type routeSpanNameKey struct {
+	method string
+	route  string
+}
+
+// spanNameCache caches the names built by routeSpanName. Routes are ServeMux patterns registered by the
+// application and methods are the known ones, so the cache is bounded by the routes of the application.
+var spanNameCache sync.Map
+
+// routeSpanName returns the span name of a request routed to route, e.g. "GET /users/{id}", built once
+// per method and route
+func routeSpanName(method, route string) string {
+	key := routeSpanNameKey{method: spanNameMethod(method), route: route}
+	if name, ok := spanNameCache.Load(key); ok {
+		return name.(string)
+	}
+	name, _ := spanNameCache.LoadOrStore(key, key.method+" "+route)
+	return name.(string)
+}
+
+// spanOperation returns the operation passed to span name formatters, e.g. "HTTP GET", without
+// building it for known methods
+func spanOperation(method string) string {
+	switch method {
+	case http.MethodGet:
+		return "HTTP GET"
+	case http.MethodHead:
+		return "HTTP HEAD"
+	case http.MethodPost:
+		return "HTTP POST"
+	case http.MethodPut:
+		return "HTTP PUT"
+	case http.MethodPatch:
+		return "HTTP PATCH"
+	case http.MethodDelete:
+		return "HTTP DELETE"
+	case http.MethodConnect:
+		return "HTTP CONNECT"
+	case http.MethodOptions:
+		return "HTTP OPTIONS"
+	case http.MethodTrace:
+		return "HTTP TRACE"
+	}
+	return "HTTP " + method
+}
+
+// rawPathSpanNameFormatter names spans after the method and the raw request path
+func rawPathSpanNameFormatter(operation string, r *http.Request) string {
+	return r.Method + " " + r.URL.Path
+}
+
+// spanNameMethod returns method if it is a known HTTP method, and "HTTP" otherwise, so arbitrary
+// methods sent by clients do not make it into span names
+func spanNameMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "HTTP"
+}
+
+// WithRawPathSpanNames configures the middleware to name spans after the method and the raw request
+// path, e.g. "GET /users/42", instead of the matched route. Paths are unbounded, so this should only
+// be used when the number of distinct paths is known to be small; see also WithMaxSpanNames.
+func WithRawPathSpanNames() Option {
+	return optionFunc(func(c *config) {
+		c.SpanNameFormatter = rawPathSpanNameFormatter
+	})
 }
 
 // WithTracerProvider configures the middleware to use a specific tracer provider
@@ -60,6 +509,36 @@ func WithTracerProvider(provider trace.TracerProvider) Option {
 	})
 }
 
+// WithAdditionalTracerProviders configures the middleware to also create its spans with the given
+// providers, e.g. a local debugging exporter next to the production pipeline during a backend migration.
+// The provider set with WithTracerProvider, or the global one, stays the primary provider: its span
+// contexts are propagated downstream, while each additional provider records a trace of its own.
+// Spans started by the helpers of this package, such as VerifySignature, and by tracers obtained from
+// the TracerProvider of the request span are fanned out too.
+func WithAdditionalTracerProviders(providers ...trace.TracerProvider) Option {
+	return optionFunc(func(c *config) {
+		c.AdditionalTracerProviders = append(c.AdditionalTracerProviders, providers...)
+	})
+}
+
+// WithMeterProvider configures the middleware to use a specific meter provider for the metrics it
+// reports about itself. The global meter provider is used by default.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return optionFunc(func(c *config) {
+		c.MeterProvider = provider
+	})
+}
+
+// WithMaxSpansPerSecond configures the middleware to create at most n spans per second, with bursts of
+// up to n spans. Requests beyond the budget are passed to the next handler untraced, keeping the incoming
+// trace context, and counted in the otelfuego.spans.dropped metric, so traffic spikes do not overwhelm
+// the tracing backend.
+func WithMaxSpansPerSecond(n int) Option {
+	return optionFunc(func(c *config) {
+		c.MaxSpansPerSecond = n
+	})
+}
+
 // WithPropagators configures the middleware to use specific propagators for context propagation
 func WithPropagators(propagators propagation.TextMapPropagator) Option {
 	return optionFunc(func(c *config) {
@@ -67,6 +546,132 @@ func WithPropagators(propagators propagation.TextMapPropagator) Option {
 	})
 }
 
+// WithErrorResponseBodyCapture configures the middleware to record the first maxBytes bytes of the body
+// of 5xx responses as the otelfuego.response.body_snippet attribute, as a bare "HTTP 500" status rarely
+// says what went wrong. Only the bytes written after the status are captured, and other responses are
+// not copied. Error bodies must not contain secrets or personal data when enabled.
+func WithErrorResponseBodyCapture(maxBytes int) Option {
+	return optionFunc(func(c *config) {
+		c.ErrorResponseBodyCapture = maxBytes
+	})
+}
+
+// WithErrorFingerprint configures the middleware to record the fingerprint computed by fingerprinter,
+// usually DefaultErrorFingerprint, as the error.fingerprint attribute of failed requests, grouping
+// similar failures in the tracing backend.
+//
+// Example:
+//
+//	WithErrorFingerprint(otelfuego.DefaultErrorFingerprint)
+func WithErrorFingerprint(fingerprinter ErrorFingerprinter) Option {
+	return optionFunc(func(c *config) {
+		if fingerprinter == nil {
+			c.invalid = append(c.invalid, errors.New("WithErrorFingerprint: nil fingerprinter"))
+		}
+		c.ErrorFingerprinter = fingerprinter
+	})
+}
+
+// WithErrorHandler configures the middleware to report failures of its own instrumentation to handler
+// instead of the global OpenTelemetry error handler: invalid options, panics recovered from
+// user-provided hooks and requests carrying trace context headers no valid context could be extracted
+// from, which are otherwise only counted. The request is served either way. handler must be safe for
+// concurrent use.
+//
+// Example:
+//
+//	WithErrorHandler(func(err error) { logger.Warn("tracing", "error", err) })
+func WithErrorHandler(handler func(error)) Option {
+	return optionFunc(func(c *config) {
+		if handler == nil {
+			c.invalid = append(c.invalid, errors.New("WithErrorHandler: nil handler"))
+		}
+		c.ErrorHandler = handler
+	})
+}
+
+// handleError reports err to the handler set with WithErrorHandler, or to the global OpenTelemetry
+// error handler. A panicking handler is reported to the global error handler.
+func (c *config) handleError(err error) {
+	if c.ErrorHandler == nil {
+		otel.Handle(err)
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			otel.Handle(fmt.Errorf("otelfuego: error handler panicked: %v", p))
+		}
+	}()
+	c.ErrorHandler(err)
+}
+
+// WithFailedRequestBodyCapture configures the middleware to keep the first maxBytes bytes of request
+// bodies read by the handler, and to record them as the otelfuego.request.body_snippet attribute only
+// when the request is rejected as malformed: with a 400, 413, 415 or 422 status by default, see
+// WithFailedRequestBodyStatuses. Only bodies whose media type is one of contentTypes are captured, by
+// default application/json, application/xml and text/plain. Payloads must not contain secrets or
+// personal data when enabled.
+//
+// Example:
+//
+//	WithFailedRequestBodyCapture(1024, "application/json")
+func WithFailedRequestBodyCapture(maxBytes int, contentTypes ...string) Option {
+	return optionFunc(func(c *config) {
+		if len(contentTypes) == 0 {
+			contentTypes = defaultCapturedBodyTypes
+		}
+		capture := &requestBodyCapture{limit: maxBytes}
+		for _, t := range contentTypes {
+			capture.contentTypes = append(capture.contentTypes, strings.ToLower(t))
+		}
+		c.FailedRequestBodyCapture = capture
+	})
+}
+
+// WithFailedRequestBodyStatuses replaces the response status codes for which the request bodies kept
+// by WithFailedRequestBodyCapture are recorded, which default to 400, 413, 415 and 422. Statuses such
+// as 401 and 403 answer requests carrying credentials and should not be listed.
+//
+// Example:
+//
+//	WithFailedRequestBodyStatuses(http.StatusBadRequest, http.StatusConflict)
+func WithFailedRequestBodyStatuses(codes ...int) Option {
+	return optionFunc(func(c *config) {
+		for _, code := range codes {
+			if code < 400 || code > 599 {
+				c.invalid = append(c.invalid, fmt.Errorf("WithFailedRequestBodyStatuses: %d is not an error status", code))
+				continue
+			}
+			c.FailedRequestBodyStatuses = append(c.FailedRequestBodyStatuses, code)
+		}
+	})
+}
+
+// WithClientTrace configures the transports returned by NewTransport and WrapClient to record the
+// connection setup of outgoing requests as events of their client span: DNS lookup, connect and TLS
+// handshake, each with its start and end and an otelfuego.duration_ms attribute, then the connection
+// being obtained, the request written and the first response byte received. Slow calls can then be
+// attributed to connection setup rather than to the server. It has no effect on the middleware.
+func WithClientTrace() Option {
+	return optionFunc(func(c *config) {
+		c.ClientTrace = true
+	})
+}
+
+// WithCarrierFactory configures the middleware to extract the incoming trace context from the carrier
+// returned by factory rather than from the request headers, e.g. from query parameters of WebSocket
+// handshakes, which browsers cannot add headers to, or from a cookie set by the frontend. The headers
+// are used when factory returns nil. WithStripIncomingContext and WithStripIncomingState apply to the
+// fields of the carrier. See QueryCarrier.
+func WithCarrierFactory(factory CarrierFactory) Option {
+	return optionFunc(func(c *config) {
+		if factory == nil {
+			c.invalid = append(c.invalid, errors.New("WithCarrierFactory: nil factory"))
+		}
+		c.CarrierFactory = factory
+	})
+}
+
 // WithFilter configures the middleware to use a filter function to determine which requests to trace
 // The filter function should return true for requests that should be traced, false otherwise.
 //
@@ -77,49 +682,1051 @@ func WithPropagators(propagators propagation.TextMapPropagator) Option {
 //	})
 func WithFilter(filter Filter) Option {
 	return optionFunc(func(c *config) {
+		if filter == nil {
+			c.invalid = append(c.invalid, errors.New("WithFilter: nil filter"))
+		}
 		c.Filter = filter
 	})
 }
 
+// WithRouteFilter configures the middleware to use a filter function receiving the route pattern matched
+// by the request, so routes can be excluded without matching raw paths. Per-route middleware sees the
+// pattern fuego matched; global middleware runs before routing and resolves the pattern with mux,
+// typically the fuego server's Mux, at the cost of an extra route lookup per request. With a nil mux,
+// global middleware passes an empty pattern.
+//
+// Example:
+//
+//	WithRouteFilter(server.Mux, otelfuego.ExcludeRoutes("GET /healthz", "GET /metrics"))
+func WithRouteFilter(mux *http.ServeMux, filter RouteFilter) Option {
+	return optionFunc(func(c *config) {
+		if filter == nil {
+			c.invalid = append(c.invalid, errors.New("WithRouteFilter: nil filter"))
+		}
+		c.RouteFilter = filter
+		c.RouteFilterMux = mux
+	})
+}
+
+// WithEnabledFunc configures the middleware to check enabled on every request and pass requests straight
+// to the next handler while it returns false, so tracing can be turned off at runtime, e.g. through a
+// feature flag, while keeping the middleware in the chain. enabled must be safe for concurrent use.
+//
+// Example:
+//
+//	var tracingEnabled atomic.Bool
+//	WithEnabledFunc(tracingEnabled.Load)
+func WithEnabledFunc(enabled func() bool) Option {
+	return optionFunc(func(c *config) {
+		if enabled == nil {
+			c.invalid = append(c.invalid, errors.New("WithEnabledFunc: nil function"))
+		}
+		c.Enabled = enabled
+	})
+}
+
 // WithSpanNameFormatter configures the middleware to use a custom span name formatter
 //
 // Example:
 //
 //	WithSpanNameFormatter(func(operation string, req *http.Request) string {
-//	    return fmt.Sprintf("%s %s", req.Method, req.URL.Path)
+//	    return req.Method + " " + req.URL.Path
 //	})
 func WithSpanNameFormatter(formatter SpanNameFormatter) Option {
 	return optionFunc(func(c *config) {
+		if formatter == nil {
+			c.invalid = append(c.invalid, errors.New("WithSpanNameFormatter: nil formatter"))
+			return
+		}
 		c.SpanNameFormatter = formatter
 	})
 }
 
-// Common filter functions for convenience
+// WithPathParamAttributes configures the middleware to record the values of the path parameters of
+// the matched route as http.route.params.<name> attributes, e.g. http.route.params.id="123" for
+// /users/{id}, so the requests of a specific entity can be found. The values of the redacted parameters,
+// such as tokens or email addresses, are recorded as "REDACTED".
+//
+// Example:
+//
+//	WithPathParamAttributes("token", "email")
+func WithPathParamAttributes(redacted ...string) Option {
+	return optionFunc(func(c *config) {
+		c.PathParams = true
+		c.RedactedPathParams = append([]string(nil), redacted...)
+	})
+}
 
-// HealthCheckFilter returns a filter that excludes common health check endpoints
-func HealthCheckFilter() Filter {
-	return func(req *http.Request) bool {
-		path := req.URL.Path
-		return path != "/health" &&
-			path != "/healthz" &&
-			path != "/ping" &&
-			path != "/ready" &&
-			path != "/live" &&
-			path != "/metrics"
-	}
+// WithContentNegotiationAttributes configures the middleware to record the preferred media type of the
+// Accept request header as http.request.accept and the media type of the response as
+// http.response.content_type, both without parameters, so endpoints serving several representations,
+// such as fuego's DataOrTemplate serving JSON and HTML, can be analyzed per representation.
+func WithContentNegotiationAttributes() Option {
+	return optionFunc(func(c *config) {
+		c.ContentNegotiation = true
+	})
 }
 
-// PathPrefixFilter returns a filter that excludes paths with the given prefix
-func PathPrefixFilter(prefix string) Filter {
-	return func(req *http.Request) bool {
-		return !strings.HasPrefix(req.URL.Path, prefix)
-	}
+// WithTLSClientSubject configures the middleware to record the subject of the certificate presented by
+// clients of TLS connections as the tls.client.subject attribute, e.g. for mutual TLS between services.
+// The TLS protocol version and cipher suite are always recorded.
+func WithTLSClientSubject() Option {
+	return optionFunc(func(c *config) {
+		c.TLSClientSubject = true
+	})
 }
 
-// PathSuffixFilter returns a filter that excludes paths with the given suffix
-func PathSuffixFilter(suffix string) Filter {
-	return func(req *http.Request) bool {
-		return !strings.HasSuffix(req.URL.Path, suffix)
+// WithResponseThroughput configures the middleware to record the rate at which the body of responses
+// of at least minBytes bytes was sent, from the time the header was written, as the
+// http.response.throughput_bytes_per_s attribute, so slow large downloads can be told apart from slow
+// handlers. With a positive progressInterval, an "http.response.progress" event recording the bytes
+// sent so far is added at most once per interval while the body is written, which helps with
+// downloads that never complete.
+//
+// Example:
+//
+//	WithResponseThroughput(1<<20, 5*time.Second)
+func WithResponseThroughput(minBytes int, progressInterval time.Duration) Option {
+	return optionFunc(func(c *config) {
+		if minBytes <= 0 {
+			c.invalid = append(c.invalid, fmt.Errorf("WithResponseThroughput: %d bytes must be positive", minBytes))
+		}
+		if progressInterval < 0 {
+			c.invalid = append(c.invalid, fmt.Errorf("WithResponseThroughput: interval %v must not be negative", progressInterval))
+		}
+		c.ResponseThroughput = &responseThroughput{minBytes: minBytes, progressInterval: progressInterval}
+	})
+}
+
+// WithLongRequestEvents configures the middleware to add an "in-progress" event, recording the time
+// elapsed as otelfuego.elapsed_ms, to the spans of requests still running after each interval, so
+// half-finished requests show up in live trace views instead of only after they complete.
+//
+// Example:
+//
+//	WithLongRequestEvents(10 * time.Second)
+func WithLongRequestEvents(interval time.Duration) Option {
+	return optionFunc(func(c *config) {
+		if interval <= 0 {
+			c.invalid = append(c.invalid, fmt.Errorf("WithLongRequestEvents: interval %v must be positive", interval))
+		}
+		c.LongRequestInterval = interval
+	})
+}
+
+// WithRouteSampler configures the middleware to sample the requests matching a ServeMux pattern, such as
+// "/events/" or "POST /checkout", with sampler, so high-volume routes can use a low ratio while critical
+// ones stay at 100%, without a custom SDK sampler. Patterns match like fuego's routes, the most specific
+// one winning; requests matching no pattern are only sampled by the tracer provider.
+//
+// Requests dropped by their route sampler are not traced, but carry an unsampled span context to
+// downstream services. Requests it samples are still subject to the tracer provider's sampler, unless
+// the provider samples with RouteSampler.
+//
+// Example:
+//
+//	WithRouteSampler("/events/", sdktrace.TraceIDRatioBased(0.01))
+func WithRouteSampler(pattern string, sampler sdktrace.Sampler) Option {
+	return optionFunc(func(c *config) {
+		if c.RouteSamplers == nil {
+			c.RouteSamplers = &routeSamplers{mux: http.NewServeMux(), samplers: make(map[string]sdktrace.Sampler)}
+		}
+		if sampler == nil {
+			c.invalid = append(c.invalid, fmt.Errorf("WithRouteSampler: nil sampler for %q", pattern))
+			return
+		}
+		if err := c.RouteSamplers.add(pattern, sampler); err != nil {
+			c.invalid = append(c.invalid, fmt.Errorf("WithRouteSampler: %w", err))
+		}
+	})
+}
+
+// WithServiceNameAttribute configures the middleware to record the service passed to Middleware as the
+// service.name attribute of request spans, as it did by default before. The service name belongs to the
+// resource of the tracer provider, see otelfuegosetup.ResourceOption, and backends may take the span
+// attribute for a conflicting value, so this is only meant for pipelines relying on the attribute.
+func WithServiceNameAttribute() Option {
+	return optionFunc(func(c *config) {
+		c.ServiceNameAttribute = true
+	})
+}
+
+// WithServiceNameFunc configures the middleware to record the service.name attribute returned by resolve
+// for each request, so multi-tenant or host-based routing servers can attribute spans to the right
+// logical service. The service passed to Middleware is recorded when resolve returns an empty string.
+// The attribute is recorded without WithServiceNameAttribute, as one resource cannot tell these
+// services apart.
+//
+// Example:
+//
+//	WithServiceNameFunc(func(req *http.Request) string {
+//	    return servicesByHost[req.Host]
+//	})
+func WithServiceNameFunc(resolve func(*http.Request) string) Option {
+	return optionFunc(func(c *config) {
+		if resolve == nil {
+			c.invalid = append(c.invalid, errors.New("WithServiceNameFunc: nil function"))
+		}
+		c.ServiceNameFunc = resolve
+	})
+}
+
+// WithEndUserExtractor configures the middleware to record the end user identity returned by extract as
+// enduser.id and enduser.role attributes. extract is called before the next handler, typically to read
+// a validated JWT or session placed in the request context by an authentication middleware running
+// earlier in the chain. Identifiers may be personal data: record opaque IDs rather than emails.
+//
+// Example:
+//
+//	WithEndUserExtractor(func(req *http.Request) (string, string) {
+//	    if claims, ok := auth.ClaimsFromContext(req.Context()); ok {
+//	        return claims.Subject, claims.Role
+//	    }
+//	    return "", ""
+//	})
+func WithEndUserExtractor(extract EndUserExtractor) Option {
+	return optionFunc(func(c *config) {
+		if extract == nil {
+			c.invalid = append(c.invalid, errors.New("WithEndUserExtractor: nil extractor"))
+		}
+		c.EndUserExtractor = extract
+	})
+}
+
+// WithTenantExtractor configures the middleware to record the tenant returned by extract as the
+// tenant.id attribute, so that per-tenant SLOs can be computed. The tenant is also recorded on the
+// request metrics and the otelfuego.spans.dropped metric, bounded by WithMaxMetricTenants, added to
+// access log records and to the records LogBridge passes on to its next handler, and available to
+// handlers through TenantFromRequest. Tenants longer than 64 bytes are truncated.
+//
+// Example:
+//
+//	WithTenantExtractor(otelfuego.TenantFromHeader("X-Tenant-ID"))
+func WithTenantExtractor(extract TenantExtractor) Option {
+	return optionFunc(func(c *config) {
+		if extract == nil {
+			c.invalid = append(c.invalid, errors.New("WithTenantExtractor: nil extractor"))
+		}
+		c.TenantExtractor = extract
+	})
+}
+
+// WithMaxMetricTenants configures the maximum number of distinct tenants found WithTenantExtractor that
+// metrics record as tenant.id, protecting metric backends from unbounded cardinality: tenants seen
+// after the limit was reached are recorded as _OTHER. Spans and logs always record the tenant. The
+// default is 100; 0 leaves tenant.id off metrics.
+func WithMaxMetricTenants(n int) Option {
+	return optionFunc(func(c *config) {
+		c.MaxMetricTenants = n
+	})
+}
+
+// WithLazyAttributes configures the middleware to add the attributes returned by compute to the spans of
+// sampled requests. compute is not called for requests that are not recorded, so expensive enrichment
+// such as database lookups or token parsing is skipped for unsampled traffic. It may be given several
+// times; the attributes are added in order.
+//
+// Example:
+//
+//	WithLazyAttributes(func(req *http.Request) []attribute.KeyValue {
+//	    account, err := accounts.Lookup(req.Context(), req.Header.Get("X-Account"))
+//	    if err != nil {
+//	        return nil
+//	    }
+//	    return []attribute.KeyValue{attribute.String("account.plan", account.Plan)}
+//	})
+func WithLazyAttributes(compute func(*http.Request) []attribute.KeyValue) Option {
+	return optionFunc(func(c *config) {
+		if compute == nil {
+			c.invalid = append(c.invalid, errors.New("WithLazyAttributes: nil function"))
+			return
+		}
+		c.LazyAttributes = append(c.LazyAttributes, compute)
+	})
+}
+
+// WithTraceStateMutator configures the middleware to replace the trace state of the request span with
+// the one returned by mutate, called with the incoming trace state before the span starts. Vendor
+// specific entries added by mutate, such as sampling tiers or tenant hints, are seen by the sampler and
+// propagated to downstream services. mutate is called for every traced request, sampled or not.
+//
+// Example:
+//
+//	WithTraceStateMutator(func(req *http.Request, ts trace.TraceState) trace.TraceState {
+//	    if tier := req.Header.Get("X-Plan"); tier != "" {
+//	        if updated, err := ts.Insert("acme", "tier:"+tier); err == nil {
+//	            return updated
+//	        }
+//	    }
+//	    return ts
+//	})
+func WithTraceStateMutator(mutate func(r *http.Request, ts trace.TraceState) trace.TraceState) Option {
+	return optionFunc(func(c *config) {
+		if mutate == nil {
+			c.invalid = append(c.invalid, errors.New("WithTraceStateMutator: nil function"))
+		}
+		c.TraceStateMutator = mutate
+	})
+}
+
+// WithCorrelationHeaderLinks configures the middleware to link the request span to the traces identified
+// by the given correlation ID headers, so requests from systems correlating by their own IDs connect to
+// OpenTelemetry traces. Header values are decoded with ParseCorrelationID unless a decoder is set with
+// WithCorrelationIDDecoder. Links carry the header name as the otelfuego.correlation.header attribute;
+// IDs of the trace the request continues are not linked.
+//
+// Example:
+//
+//	WithCorrelationHeaderLinks("X-Correlation-ID", "X-Request-ID")
+func WithCorrelationHeaderLinks(headers ...string) Option {
+	return optionFunc(func(c *config) {
+		if len(headers) == 0 {
+			c.invalid = append(c.invalid, errors.New("WithCorrelationHeaderLinks: no headers"))
+			return
+		}
+		c.CorrelationHeaders = append(c.CorrelationHeaders, headers...)
+		if c.CorrelationIDDecoder == nil {
+			c.CorrelationIDDecoder = ParseCorrelationID
+		}
+	})
+}
+
+// WithCorrelationIDDecoder replaces ParseCorrelationID as the decoder of the headers given to
+// WithCorrelationHeaderLinks, for correlation IDs in formats of their own, e.g. IDs mapped to traces
+// by a lookup table.
+func WithCorrelationIDDecoder(decode CorrelationIDDecoder) Option {
+	return optionFunc(func(c *config) {
+		if decode == nil {
+			c.invalid = append(c.invalid, errors.New("WithCorrelationIDDecoder: nil decoder"))
+			return
+		}
+		c.CorrelationIDDecoder = decode
+		c.customCorrelationIDDecoder = true
+	})
+}
+
+// WithRequestID configures the middleware to assign each traced request an ID, recorded as the
+// http.request_id attribute, echoed in the given response header and available to handlers through
+// RequestIDFromContext. The ID is taken from the request header of the same name when it holds up to
+// 128 visible ASCII characters, and is the trace ID of the request otherwise, so request IDs and trace
+// IDs are handled by one middleware. Requests untrusted by WithStripIncomingContext always get a new ID.
+// The header defaults to X-Request-ID.
+//
+// Example:
+//
+//	WithRequestID("")                // X-Request-ID
+//	WithRequestID("X-Correlation-ID")
+func WithRequestID(header string) Option {
+	return optionFunc(func(c *config) {
+		if header == "" {
+			header = defaultRequestIDHeader
+		}
+		c.RequestIDHeader = header
+	})
+}
+
+// WithContextLogger configures the middleware to store a logger derived from base in the context of
+// each request, retrieved by handlers with Logger. Its records carry the trace_id and span_id of the
+// request when it is sampled, its http.route when the middleware runs after routing, as fuego route middleware does, and
+// its http.request_id when WithRequestID is used, so handlers no longer add correlation fields
+// themselves. A nil base is slog.Default() at the time the middleware is created.
+//
+// Example:
+//
+//	WithContextLogger(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+func WithContextLogger(base *slog.Logger) Option {
+	return optionFunc(func(c *config) {
+		if base == nil {
+			base = slog.Default()
+		}
+		c.ContextLogger = base
+	})
+}
+
+// WithoutUserAgent configures the middleware not to record the user_agent.original attribute, for
+// deployments treating user agents as personal data or trimming span sizes
+func WithoutUserAgent() Option {
+	return optionFunc(func(c *config) {
+		c.OmittedAttributes |= omitUserAgent
+	})
+}
+
+// WithoutQuery configures the middleware not to record the url.query attribute, for deployments whose
+// query strings may carry personal data or tokens
+func WithoutQuery() Option {
+	return optionFunc(func(c *config) {
+		c.OmittedAttributes |= omitQuery
+	})
+}
+
+// WithoutURLPath configures the middleware not to record the url.path attribute, for deployments whose
+// paths carry identifiers such as e-mail addresses. Span names follow the SpanNameFormatter, which
+// includes the raw path with WithRawPathSpanNames.
+func WithoutURLPath() Option {
+	return optionFunc(func(c *config) {
+		c.OmittedAttributes |= omitURLPath
+	})
+}
+
+// WithSemconvMode configures the generation of HTTP semantic convention attributes the middleware emits:
+// the stable ones (SemconvStable), the ones preceding v1.20.0 (SemconvOld), or both (SemconvDup), so
+// dashboards built on either keep working during a migration. By default, the mode follows the
+// OTEL_SEMCONV_STABILITY_OPT_IN environment variable: both generations for "http/dup", the stable
+// attributes otherwise.
+func WithSemconvMode(mode SemconvMode) Option {
+	return optionFunc(func(c *config) {
+		c.Semconv = mode
+	})
+}
+
+// WithSpanKind configures the kind of the spans created by the middleware, e.g. trace.SpanKindInternal for
+// services behind an internal gateway that already records the server span. The default is
+// trace.SpanKindServer.
+func WithSpanKind(kind trace.SpanKind) Option {
+	return optionFunc(func(c *config) {
+		c.SpanKind = kind
+	})
+}
+
+// NestedMode controls how a middleware handles requests already instrumented by an outer middleware of
+// this package, e.g. when it is registered both globally and on a route group
+type NestedMode int
+
+const (
+	// NestedSkip passes nested requests to the next handler untouched, keeping a single server span
+	NestedSkip NestedMode = iota
+	// NestedInternal creates an internal span, child of the outer server span
+	NestedInternal
+	// NestedServer creates a nested server span, like a middleware used on its own
+	NestedServer
+)
+
+// WithNestedMode configures how the middleware handles requests already instrumented by an outer
+// middleware of this package. The default is NestedSkip. Requests re-dispatched with Forward are not
+// nested requests.
+func WithNestedMode(mode NestedMode) Option {
+	return optionFunc(func(c *config) {
+		c.Nested = mode
+	})
+}
+
+// WithSpanNameFromHeader configures the middleware to name spans after the value of a request header set
+// by a trusted API gateway, such as a normalized operation name in X-Operation-Name, when validator accepts
+// it. The header takes precedence over all other span names, and its names count towards WithMaxSpanNames.
+// It only names spans: the http.route of spans, request metrics and access logs remains the matched route
+// pattern, so metrics keep a bounded set of routes even if the gateway misbehaves. A nil validator accepts
+// printable ASCII names of up to 128 bytes. Clients must not be able to set the header themselves.
+//
+// Example:
+//
+//	WithSpanNameFromHeader("X-Operation-Name", func(name string) bool {
+//	    return knownOperations[name]
+//	})
+func WithSpanNameFromHeader(header string, validator func(string) bool) Option {
+	if validator == nil {
+		validator = validHeaderSpanName
+	}
+	return optionFunc(func(c *config) {
+		if header == "" {
+			c.invalid = append(c.invalid, errors.New("WithSpanNameFromHeader: empty header"))
+			return
+		}
+		c.SpanNameHeader = &spanNameHeader{header: header, validator: validator}
+	})
+}
+
+// WithOpenAPIOperations configures the middleware to name spans after the OpenAPI operationId
+// of the matched route, and to record it as the openapi.operation_id attribute.
+// Routes without an operation keep the name produced by the span name formatter.
+//
+// Example:
+//
+//	spec, _ := json.Marshal(server.OpenAPI.Description())
+//	ops, err := otelfuego.ParseOpenAPI(spec)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	WithOpenAPIOperations(ops)
+func WithOpenAPIOperations(ops OpenAPIOperations) Option {
+	return optionFunc(func(c *config) {
+		c.OpenAPIOperations = ops
+	})
+}
+
+// WithOpenAPIMetadata configures the middleware to record the tags and summary of the OpenAPI operation
+// of the matched route as the openapi.tags and openapi.summary attributes, so traces can be grouped by
+// API domain rather than by path. It requires WithOpenAPIOperations.
+func WithOpenAPIMetadata() Option {
+	return optionFunc(func(c *config) {
+		c.OpenAPIMetadata = true
+	})
+}
+
+// WithPhaseSpans configures the middleware to record child spans for the phases of fuego's typed
+// handlers: "fuego.deserialize" around body decoding and validation performed through Body, and
+// "fuego.serialize" from the return of a Controller to the end of the handler, during which fuego
+// serializes and writes the response.
+func WithPhaseSpans() Option {
+	return optionFunc(func(c *config) {
+		c.PhaseSpans = true
+	})
+}
+
+// WithStripIncomingContext configures the middleware to ignore the incoming trace context of requests
+// for which untrusted returns true: the headers read by the propagators (traceparent, tracestate,
+// baggage, ...) are removed from the request before it reaches the handler, and a new trace is started.
+// Use WithStrippedContextAttributes to keep the removed values as span attributes for forensics.
+//
+// Example:
+//
+//	WithStripIncomingContext(func(req *http.Request) bool {
+//	    return req.Header.Get("X-Internal-Token") == ""
+//	})
+func WithStripIncomingContext(untrusted func(*http.Request) bool) Option {
+	return optionFunc(func(c *config) {
+		if untrusted == nil {
+			c.invalid = append(c.invalid, errors.New("WithStripIncomingContext: nil function"))
+		}
+		c.StripIncomingContext = untrusted
+	})
+}
+
+// WithStrippedContextAttributes configures the middleware to record the propagation headers removed
+// by WithStripIncomingContext as otelfuego.stripped.<header> span attributes, truncated to
+// WithMaxAttributeValueLength
+func WithStrippedContextAttributes() Option {
+	return optionFunc(func(c *config) {
+		c.PreserveStrippedContext = true
+	})
+}
+
+// WithStripIncomingState configures the middleware to continue the incoming trace context of requests
+// for which untrusted returns true, such as those of internet-facing routes, but to remove their
+// tracestate header and their baggage before it reaches the handler and downstream services, keeping
+// only the baggage members whose key is in allowedBaggage. Callers then cannot inject arbitrary key
+// values into the telemetry pipeline. Use WithStripIncomingContext to also start a new trace.
+//
+// Example:
+//
+//	WithStripIncomingState(func(req *http.Request) bool {
+//	    return strings.HasPrefix(req.URL.Path, "/public/")
+//	}, "session.id")
+func WithStripIncomingState(untrusted func(*http.Request) bool, allowedBaggage ...string) Option {
+	return optionFunc(func(c *config) {
+		if untrusted == nil {
+			c.invalid = append(c.invalid, errors.New("WithStripIncomingState: nil function"))
+		}
+		c.StripIncomingState = untrusted
+		c.AllowedBaggageKeys = append([]string(nil), allowedBaggage...)
+	})
+}
+
+// WithGraphQL configures the middleware to treat requests to path as GraphQL-over-HTTP requests.
+// Spans are named after the GraphQL operation (e.g. "query GetUser") with the
+// graphql.operation.type and graphql.operation.name attributes, and the span status is set to
+// Error when the response contains a non-empty "errors" array, even for 200 responses.
+// The operationName parameter selects the operation and is ignored unless it is a valid GraphQL
+// name of up to 128 bytes. At most 64KiB of request and response bodies are inspected.
+//
+// Example:
+//
+//	WithGraphQL("/graphql")
+func WithGraphQL(path string) Option {
+	return optionFunc(func(c *config) {
+		c.GraphQLPath = path
+	})
+}
+
+// WithRequestBodyCounting configures the middleware to count the bytes read from request bodies whose
+// size is not announced in a Content-Length header, such as chunked uploads, and record the count as
+// http.request.body.size. Requests with a Content-Length header always record it.
+func WithRequestBodyCounting() Option {
+	return optionFunc(func(c *config) {
+		c.CountRequestBody = true
+	})
+}
+
+// WithStrictSemconv configures the middleware to verify that every span it ends carries the HTTP server
+// attributes required or recommended by the semantic conventions it implements (v1.27.0), and to report
+// the missing attributes to reporter. It is meant for CI and integration tests, and only works with
+// SDK spans, which expose their attributes.
+//
+// Example:
+//
+//	func TestAPI(t *testing.T) {
+//	    handler := otelfuego.Middleware("my-service",
+//	        otelfuego.WithTracerProvider(tp),
+//	        otelfuego.WithStrictSemconv(t),
+//	    )(api)
+//	    ...
+//	}
+func WithStrictSemconv(reporter SemconvReporter) Option {
+	return optionFunc(func(c *config) {
+		if reporter == nil {
+			c.invalid = append(c.invalid, errors.New("WithStrictSemconv: nil reporter"))
+		}
+		c.StrictSemconv = reporter
+	})
+}
+
+// WithRequestAnomalies configures the middleware to record malformed header patterns that net/http lets
+// through, such as conflicting Content-Type values, in the request.anomalies attribute. Such traffic often
+// precedes security incidents.
+func WithRequestAnomalies() Option {
+	return optionFunc(func(c *config) {
+		c.RecordAnomalies = true
+	})
+}
+
+// WithBodySniffing configures the middleware to compare the first bytes of request bodies with their
+// declared Content-Type and Content-Encoding before routing, recording mismatches such as a JSON request
+// whose body starts with '<' or a gzip request that is not compressed in the request.body.mismatch
+// attribute. Such requests otherwise surface as confusing deserialization errors deep in handlers.
+//
+// When reject is true, mismatching requests are answered with 415 Unsupported Media Type without calling
+// the next handler. Requests that are not traced, e.g. because of WithFilter, are not sniffed.
+func WithBodySniffing(reject bool) Option {
+	return optionFunc(func(c *config) {
+		c.SniffBody = true
+		c.RejectBodyMismatch = reject
+	})
+}
+
+// WithRequestMetrics configures the middleware to record the duration of the requests it traces, sampled
+// or not, as the http.server.request.duration histogram by method, route, status and error type. Its
+// count and error rate complete the RED metrics (rate, errors, duration) of the server. Requests skipped
+// by filters, route samplers or the span budget are not recorded.
+func WithRequestMetrics() Option {
+	return optionFunc(func(c *config) {
+		c.RequestMetrics = true
+	})
+}
+
+// WithRouteAttributes configures the middleware to add static attributes, such as the owning team or
+// criticality tier, to the spans and request metrics of the routes they are set for. Routes are keyed
+// by their path pattern, optionally preceded by a method, which takes precedence, e.g.
+// "/users/{id}" or "DELETE /users/{id}". Methods are upper-cased; patterns with a host, such as
+// "example.com/users", are rejected since routes are matched by path.
+//
+// Example:
+//
+//	WithRouteAttributes(map[string][]attribute.KeyValue{
+//	    "/payments/{id}": {attribute.String("team", "payments"), attribute.String("tier", "critical")},
+//	    "/users/{id}":    {attribute.String("team", "identity")},
+//	})
+func WithRouteAttributes(routes map[string][]attribute.KeyValue) Option {
+	return optionFunc(func(c *config) {
+		if c.RouteAttributes == nil {
+			c.RouteAttributes = make(routeAttributes, len(routes))
+		}
+		for route, attrs := range routes {
+			key, ok := routeAttributesKey(route)
+			if !ok {
+				c.invalid = append(c.invalid, fmt.Errorf("WithRouteAttributes: %q is not a route pattern", route))
+				continue
+			}
+			c.RouteAttributes[key] = append([]attribute.KeyValue(nil), attrs...)
+		}
+	})
+}
+
+// WithAttributes configures the middleware to add constant attributes describing the deployment, such
+// as its region, cluster or deployment color, to every span, request metric and self metric it
+// produces. They are set up once rather than computed per request; attributes known for the whole
+// process are better set on the resource of the providers, which every signal shares.
+//
+// Example:
+//
+//	WithAttributes(attribute.String("cloud.region", "eu-west-1"), attribute.String("deployment.color", "blue"))
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return optionFunc(func(c *config) {
+		for _, kv := range attrs {
+			if !kv.Valid() {
+				c.invalid = append(c.invalid, fmt.Errorf("WithAttributes: invalid attribute %q", kv.Key))
+				continue
+			}
+			c.Attributes = append(c.Attributes, kv)
+		}
+	})
+}
+
+// WithMetricAttributes selects the attributes of the request metrics, which default to the method,
+// route and status code, independently of the span attributes. Metric backends are far more sensitive
+// to cardinality than trace backends, so e.g. the status code can be replaced by its class:
+//
+//	WithMetricAttributes(otelfuego.MetricMethod, otelfuego.MetricRoute, otelfuego.MetricStatusClass)
+func WithMetricAttributes(attrs ...MetricAttribute) Option {
+	return optionFunc(func(c *config) {
+		c.MetricAttributes = append([]MetricAttribute{}, attrs...)
+	})
+}
+
+// WithTimeToFirstByteMetric configures the middleware to record the duration from the start of the request
+// span until the response header was written as the http.server.time_to_first_byte histogram, by method,
+// route and status. The same duration is always recorded on sampled spans as the
+// http.server.time_to_first_byte attribute, in seconds. It matters for streaming and template-heavy
+// endpoints, whose total duration hides slow starts.
+func WithTimeToFirstByteMetric() Option {
+	return optionFunc(func(c *config) {
+		c.TimeToFirstByteMetric = true
+	})
+}
+
+// WithOverheadMetric configures the middleware to record the time it spends itself, building attributes,
+// extracting the trace context and ending the span, which includes queuing it for export, as the
+// otelfuego.overhead histogram, separate from the time spent in the next handler. Only sampled requests
+// are measured. Use WithTimingAttributes for a per-span view.
+func WithOverheadMetric() Option {
+	return optionFunc(func(c *config) {
+		c.OverheadMetric = true
+	})
+}
+
+// WithDurationHistogramBoundaries configures the bucket boundaries, in seconds, of the duration
+// histograms of the middleware, such as http.server.time_to_first_byte and otelfuego.overhead. The SDK
+// defaults suit neither sub-10ms APIs nor multi-second batch endpoints. Boundaries must be increasing.
+// Views registered with the meter provider, e.g. with HistogramView, take precedence.
+func WithDurationHistogramBoundaries(boundaries []float64) Option {
+	return optionFunc(func(c *config) {
+		c.DurationBoundaries = append([]float64(nil), boundaries...)
+	})
+}
+
+// WithQueueTime configures the middleware to record the time a request spent in front of the process,
+// e.g. queued in a reverse proxy or router, as the http.request.queue_duration attribute in seconds. It
+// is computed from the request start timestamp set by the proxy in the first of headers present, by
+// default X-Request-Start and X-Queue-Start, as set by nginx and Heroku. Clients can forge these
+// headers, so only enable it behind a proxy overwriting them; timestamps in the future are ignored.
+func WithQueueTime(headers ...string) Option {
+	return optionFunc(func(c *config) {
+		if len(headers) == 0 {
+			headers = defaultQueueTimeHeaders
+		}
+		c.QueueTimeHeaders = append([]string(nil), headers...)
+	})
+}
+
+// WithTimingAttributes configures the middleware to record the time spent before calling the next handler
+// (otelfuego.timing.before_ms), in the next handler (otelfuego.timing.handler_ms) and after it returned
+// (otelfuego.timing.after_ms), making the overhead of the middleware itself and of inner middleware
+// visible per request.
+func WithTimingAttributes() Option {
+	return optionFunc(func(c *config) {
+		c.TimingAttributes = true
+	})
+}
+
+// WithLatencyFlags configures the middleware to stamp spans with latency.bucket="slow" when the request
+// took at least slow, and latency.bucket="normal" otherwise, plus error=true for failed requests, so
+// tail-sampling collectors can keep slow and failed requests cheaply. routes overrides the threshold for
+// the given route patterns, as recorded in http.route.
+//
+// Example:
+//
+//	WithLatencyFlags(500*time.Millisecond, map[string]time.Duration{
+//	    "/reports/{id}": 5 * time.Second,
+//	})
+func WithLatencyFlags(slow time.Duration, routes map[string]time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.LatencyFlags = &latencyFlags{slow: slow, routes: routes}
+	})
+}
+
+// WithClientDisconnectStatus configures the middleware to set the span status to Error with the given
+// description when the client disconnected before the response was complete, instead of deriving it
+// from the HTTP status code the handler happened to write.
+//
+// Client disconnects are always recorded as a "client.disconnect" event and the
+// otelfuego.request.canceled_by="client" attribute; requests canceled by a deadline, e.g. from
+// http.TimeoutHandler, are recorded with otelfuego.request.canceled_by="deadline".
+//
+// Example:
+//
+//	WithClientDisconnectStatus("client disconnected")
+func WithClientDisconnectStatus(description string) Option {
+	return optionFunc(func(c *config) {
+		c.ClientDisconnectStatus = description
+	})
+}
+
+// WithLegacySpanStatus restores the span status set by earlier versions: Ok for successful requests
+// and Error for 4xx as well as 5xx responses. By default, the status of successful requests is left
+// Unset and only 5xx responses are errors, as the semantic conventions recommend for server spans, so
+// samplers and backends can apply their own heuristics and client mistakes do not show up as server
+// errors.
+func WithLegacySpanStatus() Option {
+	return optionFunc(func(c *config) {
+		c.LegacySpanStatus = true
+	})
+}
+
+// WithExperiments configures the middleware to record the A/B experiment variants assigned to requests
+// as experiment.<name> attributes. Only the listed experiments are recorded, and variant values are
+// truncated to 64 bytes.
+//
+// Example:
+//
+//	WithExperiments(
+//	    otelfuego.Experiment{Name: "checkout_flow", Header: "X-Exp-Checkout"},
+//	    otelfuego.Experiment{Name: "pricing_page", Cookie: "exp_pricing"},
+//	)
+func WithExperiments(experiments ...Experiment) Option {
+	return optionFunc(func(c *config) {
+		c.Experiments = append(c.Experiments, experiments...)
+	})
+}
+
+// WithCapturedRequestHeaders configures the middleware to record the values of the given request headers
+// as http.request.header.<name> attributes, with the header name lowercased. Headers carrying credentials
+// or personal data should not be captured.
+//
+// Example:
+//
+//	WithCapturedRequestHeaders("X-Request-ID", "Accept-Language")
+func WithCapturedRequestHeaders(headers ...string) Option {
+	return optionFunc(func(c *config) {
+		c.CapturedRequestHeaders = appendCapturedHeaders(c.CapturedRequestHeaders, headers...)
+	})
+}
+
+// WithInfrastructureHeaders configures the middleware to capture the request IDs set by CDNs and load
+// balancers, listed in InfrastructureHeaders, as with WithCapturedRequestHeaders, e.g. the CF-Ray
+// header as the http.request.header.cf-ray attribute. Spans can then be correlated with edge logs.
+func WithInfrastructureHeaders() Option {
+	return optionFunc(func(c *config) {
+		c.CapturedRequestHeaders = appendCapturedHeaders(c.CapturedRequestHeaders, InfrastructureHeaders...)
+	})
+}
+
+// WithDebugSpans configures an Instrumentation to keep its in-flight and last 128 completed request spans
+// in memory, served by its DebugHandler. Only sampled requests are kept. It has no effect on Middleware.
+func WithDebugSpans() Option {
+	return optionFunc(func(c *config) {
+		c.DebugSpans = true
+	})
+}
+
+// WithMaxSpanNames configures the maximum number of distinct span names the middleware produces,
+// protecting backends from unbounded cardinality when routes cannot be templated. Past the limit,
+// requests that would get a new name are named "HTTP {method}" and marked with the
+// otelfuego.span_name.collapsed attribute; their path is still recorded as url.path. Names seen
+// before the limit was reached keep being used. The default is 0, which disables the limit.
+func WithMaxSpanNames(n int) Option {
+	return optionFunc(func(c *config) {
+		c.MaxSpanNames = n
+	})
+}
+
+// WithMaxAttributeValueLength configures the maximum length in bytes of the user agent, query string,
+// captured header values and error messages recorded on spans, so a single oversized header does not
+// bloat every exported span. Longer values are truncated. The default is 0, which disables truncation.
+func WithMaxAttributeValueLength(n int) Option {
+	return optionFunc(func(c *config) {
+		c.MaxAttributeValueLength = n
+	})
+}
+
+// WithMaxLogEvents configures the maximum number of log events LogBridge records per request.
+// The default is 32.
+func WithMaxLogEvents(n int) Option {
+	return optionFunc(func(c *config) {
+		c.MaxLogEvents = n
+	})
+}
+
+// WithSlowestRequests configures the number of slowest recent requests an Instrumentation keeps per
+// route (see Instrumentation.SlowestRequests). The default is 5; 0 disables the store.
+func WithSlowestRequests(n int) Option {
+	return optionFunc(func(c *config) {
+		c.SlowestRequests = n
+	})
+}
+
+// WithConfigAttributes configures the middleware to publish a compact description of its effective
+// configuration as instrumentation scope attributes on every span: the enabled features
+// (otelfuego.config.features), a fingerprint of the configuration (otelfuego.config.hash) and the
+// given profile name (otelfuego.config.profile), so spans can be segmented by instrumentation config.
+func WithConfigAttributes(profile string) Option {
+	return optionFunc(func(c *config) {
+		c.PublishConfig = true
+		c.ConfigProfile = profile
+	})
+}
+
+// WithInstrumentationScope replaces the name and version of the instrumentation scope of the spans and
+// metrics recorded by the middleware, for organizations with their own scope naming conventions or
+// versioning of internal libraries, and adds attrs to the scope attributes. HistogramView matches the
+// default scope name only, so views for renamed scopes need an sdkmetric.Instrument of their own.
+//
+// Example:
+//
+//	otelfuego.WithInstrumentationScope("acme.com/platform/http", "2.3.0",
+//	    attribute.String("acme.team", "platform"))
+func WithInstrumentationScope(name, version string, attrs ...attribute.KeyValue) Option {
+	return optionFunc(func(c *config) {
+		if name == "" {
+			c.invalid = append(c.invalid, errors.New("WithInstrumentationScope: empty name"))
+			return
+		}
+		c.ScopeName = name
+		c.ScopeVersion = version
+		c.ScopeAttributes = append(c.ScopeAttributes, attrs...)
+	})
+}
+
+// Common filter functions for convenience
+
+// HealthCheckFilter returns a filter that excludes common health check endpoints
+func HealthCheckFilter() Filter {
+	return func(req *http.Request) bool {
+		path := req.URL.Path
+		return path != "/health" &&
+			path != "/healthz" &&
+			path != "/ping" &&
+			path != "/ready" &&
+			path != "/live" &&
+			path != "/metrics"
+	}
+}
+
+// PathPrefixFilter returns a filter that excludes paths with the given prefix
+func PathPrefixFilter(prefix string) Filter {
+	return func(req *http.Request) bool {
+		return !strings.HasPrefix(req.URL.Path, prefix)
+	}
+}
+
+// PathSuffixFilter returns a filter that excludes paths with the given suffix
+func PathSuffixFilter(suffix string) Filter {
+	return func(req *http.Request) bool {
+		return !strings.HasSuffix(req.URL.Path, suffix)
+	}
+}
+
+// defaultBotUserAgents are the user agent substrings excluded by UserAgentFilter without patterns
+var defaultBotUserAgents = []string{"kube-probe", "ELB-HealthChecker", "Pingdom", "Googlebot"}
+
+// UserAgentFilter returns a filter that excludes requests whose User-Agent contains one of the given
+// patterns, ignoring case, so synthetic traffic such as probes and crawlers is not traced. Without
+// patterns, requests from Kubernetes probes (kube-probe), AWS load balancer health checks
+// (ELB-HealthChecker), Pingdom and Googlebot are excluded.
+//
+// Example:
+//
+//	WithFilter(otelfuego.UserAgentFilter("kube-probe", "Datadog/Synthetics"))
+func UserAgentFilter(patterns ...string) Filter {
+	if len(patterns) == 0 {
+		patterns = defaultBotUserAgents
+	}
+	lower := make([]string, len(patterns))
+	for i, p := range patterns {
+		lower[i] = strings.ToLower(p)
+	}
+	return func(req *http.Request) bool {
+		ua := req.UserAgent()
+		if ua == "" {
+			return true
+		}
+		ua = strings.ToLower(ua)
+		for _, p := range lower {
+			if strings.Contains(ua, p) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// RemoteAddrFilter returns a filter that excludes requests whose remote address is in one of the given
+// networks, in CIDR notation or as single IP addresses, e.g. the source ranges of Kubernetes probes or
+// of internal monitoring. The address of the immediate peer (http.Request.RemoteAddr) is used, not
+// forwarding headers. Invalid networks are reported to the global OpenTelemetry error handler and
+// ignored.
+//
+// Example:
+//
+//	WithFilter(otelfuego.RemoteAddrFilter("10.0.0.0/8", "fd00::/8"))
+func RemoteAddrFilter(cidrs ...string) Filter {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := parsePrefix(cidr)
+		if err != nil {
+			otel.Handle(fmt.Errorf("otelfuego: RemoteAddrFilter: %w", err))
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return func(req *http.Request) bool {
+		addr, ok := remoteAddr(req)
+		if !ok {
+			return true
+		}
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// parsePrefix parses a network in CIDR notation or a single IP address
+func parsePrefix(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+// remoteAddr returns the IP address of the immediate peer of req
+func remoteAddr(req *http.Request) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(req.RemoteAddr); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.Trim(req.RemoteAddr, "[]"))
+	return addr.Unmap(), err == nil
+}
+
+// ExcludeRoutes returns a route filter that excludes requests matching one of the given ServeMux
+// patterns, which must be written exactly as registered, e.g. "GET /healthz"
+func ExcludeRoutes(patterns ...string) RouteFilter {
+	excluded := make(map[string]bool, len(patterns))
+	for _, p := range patterns {
+		excluded[p] = true
+	}
+	return func(req *http.Request, pattern string) bool {
+		return !excluded[pattern]
+	}
+}
+
+// SampledFilter returns a filter that traces the requests inner traces, plus a keepRatio fraction of the
+// requests inner excludes, evenly spread: with a keepRatio of 0.01, one in every 100 excluded requests is
+// traced. This keeps a trickle of health check spans to verify probes are healthy.
+//
+// Example:
+//
+//	WithFilter(otelfuego.SampledFilter(otelfuego.HealthCheckFilter(), 0.01))
+func SampledFilter(inner Filter, keepRatio float64) Filter {
+	if keepRatio <= 0 {
+		return inner
+	}
+	var excluded atomic.Uint64
+	return func(req *http.Request) bool {
+		if inner(req) || keepRatio >= 1 {
+			return true
+		}
+		// Keep the request when the number of kept requests, n*keepRatio, reaches a new integer
+		n := excluded.Add(1)
+		return uint64(float64(n)*keepRatio) > uint64(float64(n-1)*keepRatio)
 	}
 }
 
@@ -0,0 +1,46 @@
+package otelfuego
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type loggerContextKey struct{}
+
+// withRequestLogger returns ctx carrying a logger derived from base with the correlation fields of r:
+// the trace and span IDs of sc when it is sampled, the route when it is already known and the request ID,
+// if any. IDs of unsampled traces are left out, as they lead nowhere.
+func withRequestLogger(ctx context.Context, base *slog.Logger, r *http.Request, sc trace.SpanContext, reqID string) context.Context {
+	attrs := make([]any, 0, 4)
+	if sc.IsSampled() {
+		attrs = append(attrs,
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	if route := routePattern(r); route != "" {
+		attrs = append(attrs, slog.String(string(semconv.HTTPRouteKey), route))
+	}
+	if reqID != "" {
+		attrs = append(attrs, slog.String(string(requestIDKey), reqID))
+	}
+	return context.WithValue(ctx, loggerContextKey{}, base.With(attrs...))
+}
+
+// Logger returns the logger the middleware configured WithContextLogger stored for the request in ctx,
+// whose records carry the trace_id and span_id of sampled requests and their http.route and http.request_id.
+// Outside of such a middleware, slog.Default() is returned, so handlers can always log through it.
+//
+// Example:
+//
+//	otelfuego.Logger(c.Context()).Info("charging card", "amount", order.Total)
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
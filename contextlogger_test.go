@@ -0,0 +1,96 @@
+package otelfuego_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithContextLogger(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var output bytes.Buffer
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRequestID(""),
+		otelfuego.WithContextLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+	)
+
+	// Route middleware runs after routing, so the route is known
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otelfuego.Logger(r.Context()).Info("loading user", "user.id", r.PathValue("id"))
+	})))
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("X-Request-ID", "req-8c1f2a")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON record, got %q: %v", output.String(), err)
+	}
+	expected := map[string]any{
+		"msg":             "loading user",
+		"trace_id":        spans[0].SpanContext.TraceID().String(),
+		"span_id":         spans[0].SpanContext.SpanID().String(),
+		"http.route":      "/users/{id}",
+		"http.request_id": "req-8c1f2a",
+		"user.id":         "42",
+	}
+	for key, want := range expected {
+		if record[key] != want {
+			t.Errorf("Expected %s %q, got %v", key, want, record[key])
+		}
+	}
+}
+
+func TestLogger_OutsideMiddleware(t *testing.T) {
+	if logger := otelfuego.Logger(context.Background()); logger != slog.Default() {
+		t.Error("Expected the default logger outside of the middleware")
+	}
+}
+
+func TestMiddleware_WithContextLogger_Unsampled(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var output bytes.Buffer
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithContextLogger(slog.New(slog.NewJSONHandler(&output, nil))),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otelfuego.Logger(r.Context()).Info("loading user")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	// The trace of an unsampled request is never exported, so its ID would lead nowhere
+	var record map[string]any
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON record, got %q: %v", output.String(), err)
+	}
+	for _, key := range []string{"trace_id", "span_id"} {
+		if v, ok := record[key]; ok {
+			t.Errorf("Expected no %s for an unsampled request, got %v", key, v)
+		}
+	}
+}
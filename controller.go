@@ -0,0 +1,66 @@
+package otelfuego
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestBodyTypeKey = attribute.Key("fuego.request.body.type")
+	responseTypeKey    = attribute.Key("fuego.response.type")
+)
+
+// ControllerContext is the subset of fuego's controller contexts used by the typed wrappers.
+// Both fuego.ContextNoBody and fuego.ContextWithBody[B] satisfy it.
+type ControllerContext interface {
+	Context() context.Context
+}
+
+// Controller wraps a typed fuego controller so that the request body and response types are
+// recorded on the server span created by the middleware, and returned errors are recorded as
+// span events using RecordError. The span status is still derived from the HTTP status code
+// by the middleware.
+// Type names are resolved once when the controller is wrapped, not per request.
+//
+// Example:
+//
+//	fuego.Get(server, "/users/{id}", otelfuego.Controller(getUser))
+//	fuego.Post(server, "/users", otelfuego.Controller(createUser))
+func Controller[C ControllerContext, T any](controller func(C) (T, error)) func(C) (T, error) {
+	attrs := []attribute.KeyValue{
+		responseTypeKey.String(reflect.TypeFor[T]().String()),
+	}
+	if body, ok := requestBodyType(reflect.TypeFor[C]()); ok {
+		attrs = append(attrs, requestBodyTypeKey.String(body.String()))
+	}
+
+	return func(c C) (T, error) {
+		ctx := c.Context()
+		trace.SpanFromContext(ctx).SetAttributes(attrs...)
+
+		resp, err := controller(c)
+		if state := requestStateFromContext(ctx); state != nil {
+			state.controllerEnd = time.Now()
+		}
+		RecordError(ctx, err)
+		return resp, err
+	}
+}
+
+// requestBodyType returns the type of the body decoded by a fuego context, based on the
+// first return value of its Body method.
+func requestBodyType(t reflect.Type) (reflect.Type, bool) {
+	method, ok := t.MethodByName("Body")
+	if !ok || method.Type.NumOut() == 0 {
+		return nil, false
+	}
+	out := method.Type.Out(0)
+	if out.Kind() == reflect.Interface && out.NumMethod() == 0 {
+		return nil, false
+	}
+	return out, true
+}
@@ -0,0 +1,79 @@
+package otelfuego_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type createUserRequest struct {
+	Name string
+}
+
+type userResponse struct {
+	ID string
+}
+
+// testContext mimics the shape of fuego.ContextWithBody[B]
+type testContext[B any] struct {
+	ctx  context.Context
+	body B
+	err  error
+}
+
+func (c testContext[B]) Context() context.Context { return c.ctx }
+
+func (c testContext[B]) Body() (B, error) { return c.body, c.err }
+
+func TestController_RecordsTypes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	controllerErr := errors.New("user already exists")
+	controller := otelfuego.Controller(func(c testContext[createUserRequest]) (userResponse, error) {
+		return userResponse{}, controllerErr
+	})
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+	)
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := controller(testContext[createUserRequest]{ctx: r.Context()}); err != nil {
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+
+	req := httptest.NewRequest("POST", "/users", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("fuego.request.body.type"); v.AsString() != "otelfuego_test.createUserRequest" {
+		t.Errorf("Expected request body type 'otelfuego_test.createUserRequest', got '%s'", v.AsString())
+	}
+	if v, _ := attrs.Value("fuego.response.type"); v.AsString() != "otelfuego_test.userResponse" {
+		t.Errorf("Expected response type 'otelfuego_test.userResponse', got '%s'", v.AsString())
+	}
+
+	if len(spans[0].Events) != 1 || spans[0].Events[0].Name != "exception" {
+		t.Errorf("Expected controller error to be recorded as an exception event, got %v", spans[0].Events)
+	}
+}
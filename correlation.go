@@ -0,0 +1,71 @@
+package otelfuego
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// correlationHeaderKey records on a link the header carrying the correlation ID it was decoded from
+const correlationHeaderKey = attribute.Key("otelfuego.correlation.header")
+
+// CorrelationIDDecoder converts the value of a correlation ID header into the span context to link the
+// request span to. It returns false when the value does not identify a trace. The span ID may be left
+// zero when the correlation ID only identifies a trace.
+type CorrelationIDDecoder func(value string) (trace.SpanContext, bool)
+
+// ParseCorrelationID is the default CorrelationIDDecoder. It accepts W3C traceparent values, 32 hex
+// digit trace IDs, optionally followed by a 16 hex digit span ID after a '-', ':', '/' or '.', and UUIDs,
+// whose 128 bits are taken as a trace ID, as is common for correlation IDs minted alongside traces.
+func ParseCorrelationID(value string) (trace.SpanContext, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	var traceHex, spanHex string
+	switch parts := strings.Split(value, "-"); {
+	case len(parts) == 4 && len(parts[0]) == 2 && len(parts[1]) == 32 && len(parts[2]) == 16:
+		traceHex, spanHex = parts[1], parts[2]
+	case len(parts) == 5 && len(value) == 36:
+		traceHex = strings.Join(parts, "")
+	case len(value) == 32:
+		traceHex = value
+	case len(value) == 49 && strings.IndexByte("-:/.", value[32]) >= 0:
+		traceHex, spanHex = value[:32], value[33:]
+	default:
+		return trace.SpanContext{}, false
+	}
+
+	traceID, err := trace.TraceIDFromHex(traceHex)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	cfg := trace.SpanContextConfig{TraceID: traceID, Remote: true}
+	if spanHex != "" {
+		if cfg.SpanID, err = trace.SpanIDFromHex(spanHex); err != nil {
+			return trace.SpanContext{}, false
+		}
+	}
+	return trace.NewSpanContext(cfg), true
+}
+
+// correlationLinks returns the links to the traces identified by the correlation ID headers of r,
+// except for the trace the request already continues
+func correlationLinks(r *http.Request, headers []string, decode CorrelationIDDecoder, parent trace.SpanContext) []trace.Link {
+	var links []trace.Link
+	for _, header := range headers {
+		value := r.Header.Get(header)
+		if value == "" {
+			continue
+		}
+		sc, ok := decode(value)
+		if !ok || !sc.TraceID().IsValid() || sc.TraceID() == parent.TraceID() {
+			continue
+		}
+		// The attribute also keeps links without span ID, which the SDK drops as invalid otherwise
+		links = append(links, trace.Link{
+			SpanContext: sc,
+			Attributes:  []attribute.KeyValue{correlationHeaderKey.String(header)},
+		})
+	}
+	return links
+}
@@ -0,0 +1,88 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseCorrelationID(t *testing.T) {
+	tests := []struct {
+		value   string
+		traceID string
+		spanID  string
+		ok      bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"4bf92f3577b34da6a3ce929d0e0e4736", "4bf92f3577b34da6a3ce929d0e0e4736", "0000000000000000", true},
+		{"4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"4BF92F35-77B3-4DA6-A3CE-929D0E0E4736", "4bf92f3577b34da6a3ce929d0e0e4736", "0000000000000000", true},
+		{"00000000000000000000000000000000", "", "", false},
+		{"req-12345", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			sc, ok := otelfuego.ParseCorrelationID(tt.value)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok %v, got %v", tt.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if sc.TraceID().String() != tt.traceID || sc.SpanID().String() != tt.spanID {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.traceID, tt.spanID, sc.TraceID(), sc.SpanID())
+			}
+		})
+	}
+}
+
+func TestMiddleware_WithCorrelationHeaderLinks(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	legacy := trace.TraceID{1, 2, 3}
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPropagators(propagation.TraceContext{}),
+		otelfuego.WithCorrelationHeaderLinks("X-Correlation-ID", "X-Legacy-ID"),
+		otelfuego.WithCorrelationIDDecoder(func(value string) (trace.SpanContext, bool) {
+			if value != "legacy-42" {
+				return otelfuego.ParseCorrelationID(value)
+			}
+			return trace.NewSpanContext(trace.SpanContextConfig{TraceID: legacy}), true
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("X-Correlation-ID", "4bf92f3577b34da6a3ce929d0e0e4736")
+	req.Header.Set("X-Legacy-ID", "legacy-42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// The correlation ID of the continued trace is not linked
+	links := exporter.GetSpans()[0].Links
+	if len(links) != 1 {
+		t.Fatalf("Expected 1 link, got %d", len(links))
+	}
+	if links[0].SpanContext.TraceID() != legacy {
+		t.Errorf("Expected link to trace %s, got %s", legacy, links[0].SpanContext.TraceID())
+	}
+	attrs := attribute.NewSet(links[0].Attributes...)
+	if v, _ := attrs.Value("otelfuego.correlation.header"); v.AsString() != "X-Legacy-ID" {
+		t.Errorf("Expected link header 'X-Legacy-ID', got '%s'", v.AsString())
+	}
+}
@@ -0,0 +1,142 @@
+package otelfuego
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxRecentSpans bounds the number of completed request spans kept for Instrumentation.DebugHandler
+const maxRecentSpans = 128
+
+// DebugSpan describes a request span recorded for Instrumentation.DebugHandler
+type DebugSpan struct {
+	TraceID  string        `json:"trace_id"`
+	SpanID   string        `json:"span_id"`
+	Name     string        `json:"name"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration_ns"`
+	Status   int           `json:"status,omitempty"`
+	Error    bool          `json:"error"`
+}
+
+// debugSpans holds the in-flight and recently completed request spans of the middleware configured
+// with WithDebugSpans
+type debugSpans struct {
+	mu       sync.Mutex
+	inFlight map[*DebugSpan]struct{}
+	recent   []DebugSpan
+	next     int
+}
+
+func newDebugSpans() *debugSpans {
+	return &debugSpans{inFlight: make(map[*DebugSpan]struct{})}
+}
+
+// start records the span of r as in flight
+func (d *debugSpans) start(sc trace.SpanContext, name string, r *http.Request) *DebugSpan {
+	span := &DebugSpan{
+		TraceID: sc.TraceID().String(),
+		SpanID:  sc.SpanID().String(),
+		Name:    name,
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Start:   time.Now(),
+	}
+	d.mu.Lock()
+	d.inFlight[span] = struct{}{}
+	d.mu.Unlock()
+	return span
+}
+
+// finish moves span from the in-flight to the recent spans. It does nothing if span already finished.
+func (d *debugSpans) finish(span *DebugSpan, name string, status int, failed bool) {
+	end := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.inFlight[span]; !ok {
+		return
+	}
+	delete(d.inFlight, span)
+
+	if name != "" {
+		span.Name = name
+	}
+	span.Duration = end.Sub(span.Start)
+	span.Status = status
+	span.Error = failed
+	if len(d.recent) < maxRecentSpans {
+		d.recent = append(d.recent, *span)
+	} else {
+		d.recent[d.next] = *span
+	}
+	d.next = (d.next + 1) % maxRecentSpans
+}
+
+// snapshot returns the in-flight spans, oldest first, and the recent spans, most recent first
+func (d *debugSpans) snapshot(now time.Time) (inFlight, recent []DebugSpan) {
+	d.mu.Lock()
+	inFlight = make([]DebugSpan, 0, len(d.inFlight))
+	for span := range d.inFlight {
+		s := *span
+		s.Duration = now.Sub(s.Start)
+		inFlight = append(inFlight, s)
+	}
+	recent = make([]DebugSpan, 0, len(d.recent))
+	for i := 1; i <= len(d.recent); i++ {
+		recent = append(recent, d.recent[(d.next-i+maxRecentSpans)%maxRecentSpans])
+	}
+	d.mu.Unlock()
+
+	sort.Slice(inFlight, func(i, j int) bool { return inFlight[i].Start.Before(inFlight[j].Start) })
+	return inFlight, recent
+}
+
+// debugPage is the document served by Instrumentation.DebugHandler
+type debugPage struct {
+	SlowestRequests map[string][]SlowRequest `json:"slowest_requests"`
+	InFlight        []DebugSpan              `json:"in_flight,omitempty"`
+	Recent          []DebugSpan              `json:"recent,omitempty"`
+
+	// Spans is set when request spans are kept WithDebugSpans
+	Spans bool `json:"-"`
+}
+
+// serve writes page as JSON, or as HTML to browsers and with ?format=html
+func (page debugPage) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("format") == "html" || strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = debugPageTemplate.Execute(w, page)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+var debugPageTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><title>otelfuego</title></head>
+<body>
+<h1>Slowest requests</h1>
+<table border="1" cellpadding="4">
+<tr><th>Route</th><th>Time</th><th>Duration</th><th>Status</th><th>Trace ID</th></tr>
+{{range $route, $requests := .SlowestRequests}}{{range $requests}}<tr><td>{{$route}}</td><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Duration}}</td><td>{{.Status}}</td><td>{{.TraceID}}</td></tr>
+{{end}}{{end}}</table>
+{{if .Spans}}<h1>In-flight requests</h1>
+{{template "table" .InFlight}}
+<h1>Recent requests</h1>
+{{template "table" .Recent}}
+{{end}}</body>
+</html>
+{{define "table"}}<table border="1" cellpadding="4">
+<tr><th>Start</th><th>Duration</th><th>Name</th><th>Method</th><th>Path</th><th>Status</th><th>Error</th><th>Trace ID</th><th>Span ID</th></tr>
+{{range .}}<tr><td>{{.Start.Format "15:04:05.000"}}</td><td>{{.Duration}}</td><td>{{.Name}}</td><td>{{.Method}}</td><td>{{.Path}}</td><td>{{if .Status}}{{.Status}}{{end}}</td><td>{{if .Error}}yes{{end}}</td><td>{{.TraceID}}</td><td>{{.SpanID}}</td></tr>
+{{end}}</table>{{end}}`))
@@ -0,0 +1,115 @@
+//go:build !otelfuego_minimal
+
+package otelfuego_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// debugSpans is the JSON document served by Instrumentation.DebugHandler
+type debugSpans struct {
+	InFlight []otelfuego.DebugSpan `json:"in_flight"`
+	Recent   []otelfuego.DebugSpan `json:"recent"`
+}
+
+func fetchDebugSpans(t *testing.T, inst *otelfuego.Instrumentation) debugSpans {
+	t.Helper()
+	w := httptest.NewRecorder()
+	inst.DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/otelfuego", nil))
+	var spans debugSpans
+	if err := json.NewDecoder(w.Body).Decode(&spans); err != nil {
+		t.Fatal(err)
+	}
+	return spans
+}
+
+func findDebugSpan(spans []otelfuego.DebugSpan, traceID string) *otelfuego.DebugSpan {
+	for i := range spans {
+		if spans[i].TraceID == traceID {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func TestInstrumentation_DebugSpans(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	inst := otelfuego.New("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithDebugSpans(),
+	)
+
+	var inFlight *otelfuego.DebugSpan
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		traceID := otelfuego.SpanFromContext(r.Context()).SpanContext().TraceID().String()
+		inFlight = findDebugSpan(fetchDebugSpans(t, inst).InFlight, traceID)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	inst.Middleware()(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	if inFlight == nil || inFlight.Path != "/users/42" {
+		t.Fatalf("Expected the request to be listed in flight, got %+v", inFlight)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	recent := findDebugSpan(fetchDebugSpans(t, inst).Recent, spans[0].SpanContext.TraceID().String())
+	if recent == nil {
+		t.Fatal("Expected the request to be listed as recent")
+	}
+	if recent.Name != "GET /users/{id}" || recent.Status != http.StatusNotFound || !recent.Error || recent.Duration <= 0 {
+		t.Errorf("Unexpected recent span %+v", recent)
+	}
+	if findDebugSpan(fetchDebugSpans(t, inst).InFlight, recent.TraceID) != nil {
+		t.Error("Expected the completed request not to be listed in flight")
+	}
+
+	w := httptest.NewRecorder()
+	inst.DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/otelfuego?format=html", nil))
+	if !strings.Contains(w.Body.String(), recent.TraceID) || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("Expected an HTML page listing the span, got %q", w.Body.String())
+	}
+}
+
+func TestInstrumentation_DebugSpans_Isolated(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	inst := otelfuego.New("test-service", otelfuego.WithTracerProvider(tp), otelfuego.WithDebugSpans())
+	other := otelfuego.New("test-service", otelfuego.WithTracerProvider(tp), otelfuego.WithDebugSpans())
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	inst.Middleware()(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	// Each instrumentation serves the spans of its own middleware only
+	if recent := fetchDebugSpans(t, other).Recent; len(recent) != 0 {
+		t.Errorf("Expected no spans from another instrumentation, got %+v", recent)
+	}
+	if recent := fetchDebugSpans(t, inst).Recent; len(recent) != 1 {
+		t.Errorf("Expected 1 recent span, got %d", len(recent))
+	}
+}
@@ -0,0 +1,54 @@
+package otelfuego
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const canceledByKey = attribute.Key("otelfuego.request.canceled_by")
+
+// cancellationWatcher records when the request context is canceled while the handler runs,
+// which net/http does when the client closes the connection
+type cancellationWatcher struct {
+	ctx  context.Context
+	at   atomic.Int64
+	stop func() bool
+}
+
+func watchCancellation(ctx context.Context) *cancellationWatcher {
+	w := &cancellationWatcher{ctx: ctx}
+	w.stop = context.AfterFunc(ctx, func() {
+		w.at.Store(time.Now().UnixNano())
+	})
+	return w
+}
+
+// finish stops watching and records the cancellation on span, if any. It reports whether the
+// client disconnected, as opposed to the request running out of time.
+func (w *cancellationWatcher) finish(span trace.Span) bool {
+	w.stop()
+
+	err := w.ctx.Err()
+	if err == nil {
+		return false
+	}
+
+	at := time.Now()
+	if nanos := w.at.Load(); nanos != 0 {
+		at = time.Unix(0, nanos)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		span.SetAttributes(canceledByKey.String("deadline"))
+		return false
+	}
+
+	span.SetAttributes(canceledByKey.String("client"))
+	span.AddEvent("client.disconnect", trace.WithTimestamp(at))
+	return true
+}
@@ -0,0 +1,133 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_ClientDisconnect(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tests := []struct {
+		name         string
+		opts         []otelfuego.Option
+		cancel       func(context.Context) (context.Context, context.CancelFunc)
+		wantCanceled string
+		wantEvent    bool
+		wantStatus   codes.Code
+		wantDesc     string
+	}{
+		{
+			name:         "client disconnect",
+			cancel:       context.WithCancel,
+			wantCanceled: "client",
+			wantEvent:    true,
+			wantStatus:   codes.Unset,
+		},
+		{
+			name:         "client disconnect with status",
+			opts:         []otelfuego.Option{otelfuego.WithClientDisconnectStatus("client disconnected")},
+			cancel:       context.WithCancel,
+			wantCanceled: "client",
+			wantEvent:    true,
+			wantStatus:   codes.Error,
+			wantDesc:     "client disconnected",
+		},
+		{
+			name: "deadline exceeded",
+			opts: []otelfuego.Option{otelfuego.WithClientDisconnectStatus("client disconnected")},
+			cancel: func(ctx context.Context) (context.Context, context.CancelFunc) {
+				return context.WithTimeout(ctx, time.Millisecond)
+			},
+			wantCanceled: "deadline",
+			wantStatus:   codes.Unset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			ctx, cancel := tt.cancel(context.Background())
+			defer cancel()
+
+			middleware := otelfuego.Middleware("test-service",
+				append([]otelfuego.Option{otelfuego.WithTracerProvider(tp)}, tt.opts...)...,
+			)
+			handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.wantCanceled == "client" {
+					cancel()
+				}
+				<-r.Context().Done()
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/reports", nil).WithContext(ctx)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+
+			attrs := attribute.NewSet(spans[0].Attributes...)
+			if v, _ := attrs.Value("otelfuego.request.canceled_by"); v.AsString() != tt.wantCanceled {
+				t.Errorf("Expected canceled_by '%s', got '%s'", tt.wantCanceled, v.AsString())
+			}
+
+			hasEvent := false
+			for _, event := range spans[0].Events {
+				if event.Name == "client.disconnect" {
+					hasEvent = true
+				}
+			}
+			if hasEvent != tt.wantEvent {
+				t.Errorf("Expected client.disconnect event %v, got %v", tt.wantEvent, hasEvent)
+			}
+
+			if spans[0].Status.Code != tt.wantStatus || spans[0].Status.Description != tt.wantDesc {
+				t.Errorf("Expected status %v %q, got %v %q", tt.wantStatus, tt.wantDesc, spans[0].Status.Code, spans[0].Status.Description)
+			}
+		})
+	}
+}
+
+func TestMiddleware_CompletedRequestNotCanceled(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// The context is canceled after the handler returned, like net/http does for every request
+	ctx, cancel := context.WithCancel(context.Background())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	cancel()
+
+	attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+	if _, ok := attrs.Value("otelfuego.request.canceled_by"); ok {
+		t.Error("Expected completed request not to be marked as canceled")
+	}
+}
@@ -0,0 +1,27 @@
+package otelfuego
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	endUserIDKey   = attribute.Key("enduser.id")
+	endUserRoleKey = attribute.Key("enduser.role")
+)
+
+// EndUserExtractor returns the identity of the end user of a request, or empty strings if unknown
+type EndUserExtractor func(r *http.Request) (id, role string)
+
+// appendEndUserAttributes appends the enduser.id and enduser.role attributes found by extract
+func appendEndUserAttributes(attrs []attribute.KeyValue, extract EndUserExtractor, r *http.Request) []attribute.KeyValue {
+	id, role := extract(r)
+	if id != "" {
+		attrs = append(attrs, endUserIDKey.String(id))
+	}
+	if role != "" {
+		attrs = append(attrs, endUserRoleKey.String(role))
+	}
+	return attrs
+}
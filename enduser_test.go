@@ -0,0 +1,74 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// claimsKey is the context key of the claims set by the test authentication middleware
+type claimsKey struct{}
+
+type claims struct {
+	Subject, Role string
+}
+
+func TestMiddleware_WithEndUserExtractor(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithEndUserExtractor(func(req *http.Request) (string, string) {
+			if c, ok := req.Context().Value(claimsKey{}).(claims); ok {
+				return c.Subject, c.Role
+			}
+			return "", ""
+		}),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Authentication middleware running earlier in the chain
+	authenticated := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			r = r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims{Subject: "user-42", Role: "admin"}))
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	req := httptest.NewRequest("GET", "/admin", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	authenticated.ServeHTTP(httptest.NewRecorder(), req)
+	authenticated.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/public", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("enduser.id"); v.AsString() != "user-42" {
+		t.Errorf("Expected enduser.id 'user-42', got '%s'", v.AsString())
+	}
+	if v, _ := attrs.Value("enduser.role"); v.AsString() != "admin" {
+		t.Errorf("Expected enduser.role 'admin', got '%s'", v.AsString())
+	}
+
+	anonymous := attribute.NewSet(spans[1].Attributes...)
+	if _, ok := anonymous.Value("enduser.id"); ok {
+		t.Error("Expected no enduser.id for anonymous requests")
+	}
+}
@@ -0,0 +1,131 @@
+package otelfuego
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+)
+
+// Environment variables read by FromEnv
+const (
+	envFilterPaths      = "OTELFUEGO_FILTER_PATHS"
+	envCaptureHeaders   = "OTELFUEGO_CAPTURE_HEADERS"
+	envSpanNameMode     = "OTELFUEGO_SPAN_NAME_MODE"
+	envSemconvStrict    = "OTELFUEGO_SEMCONV_STRICT"
+	envCountRequestBody = "OTELFUEGO_COUNT_REQUEST_BODY"
+)
+
+// FromEnv returns an option configuring the middleware from environment variables, so operators can
+// tune the instrumentation without code changes:
+//
+//   - OTELFUEGO_FILTER_PATHS: comma-separated path prefixes of requests not to trace, in addition to
+//     any filter set with WithFilter
+//   - OTELFUEGO_CAPTURE_HEADERS: comma-separated request headers to record, like WithCapturedRequestHeaders
+//   - OTELFUEGO_SPAN_NAME_MODE: "route" for "GET /users/{id}" span names (the default), "path" for
+//     "GET /users/42" span names or "method" for "GET" span names
+//   - OTELFUEGO_SEMCONV_STRICT: "true" to report missing semconv attributes to the global OpenTelemetry
+//     error handler, like WithStrictSemconv
+//   - OTELFUEGO_COUNT_REQUEST_BODY: "true" to opt in to WithRequestBodyCounting
+//
+// The variables are read when the middleware is created, and override the options before FromEnv.
+// Invalid values are reported to the global OpenTelemetry error handler and ignored.
+//
+// Example:
+//
+//	server.Use(otelfuego.Middleware("my-service",
+//	    otelfuego.WithFilter(otelfuego.HealthCheckFilter()),
+//	    otelfuego.FromEnv(),
+//	))
+func FromEnv() Option {
+	return optionFunc(func(c *config) {
+		if v := os.Getenv(envFilterPaths); v != "" {
+			filters := []Filter{}
+			if c.Filter != nil {
+				filters = append(filters, c.Filter)
+			}
+			for _, prefix := range splitEnvList(v) {
+				filters = append(filters, PathPrefixFilter(prefix))
+			}
+			c.Filter = CombineFilters(filters...)
+		}
+
+		if v := os.Getenv(envCaptureHeaders); v != "" {
+			WithCapturedRequestHeaders(splitEnvList(v)...).apply(c)
+		}
+
+		if v := os.Getenv(envSpanNameMode); v != "" {
+			if formatter, err := spanNameFormatterForMode(v); err != nil {
+				otel.Handle(fmt.Errorf("otelfuego: invalid %s: %w", envSpanNameMode, err))
+			} else {
+				c.SpanNameFormatter = formatter
+			}
+		}
+
+		if enabled, ok := envBool(envSemconvStrict); ok {
+			c.StrictSemconv = nil
+			if enabled {
+				c.StrictSemconv = otelErrorReporter{}
+			}
+		}
+		if enabled, ok := envBool(envCountRequestBody); ok {
+			c.CountRequestBody = enabled
+		}
+	})
+}
+
+// spanNameFormatterForMode returns the span name formatter for a span name mode, "route", "path" or
+// "method"
+func spanNameFormatterForMode(mode string) (SpanNameFormatter, error) {
+	switch mode {
+	case "route":
+		return defaultSpanNameFormatter, nil
+	case "path":
+		return rawPathSpanNameFormatter, nil
+	case "method":
+		return methodSpanNameFormatter, nil
+	default:
+		return nil, fmt.Errorf("span name mode %q, expected \"route\", \"path\" or \"method\"", mode)
+	}
+}
+
+// methodSpanNameFormatter names spans after the request method only, with unknown methods named HTTP
+// as clients may choose them freely
+func methodSpanNameFormatter(operation string, r *http.Request) string {
+	return spanNameMethod(r.Method)
+}
+
+// otelErrorReporter reports to the global OpenTelemetry error handler
+type otelErrorReporter struct{}
+
+func (otelErrorReporter) Errorf(format string, args ...any) {
+	otel.Handle(fmt.Errorf(format, args...))
+}
+
+// splitEnvList splits a comma-separated list, dropping empty items
+func splitEnvList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envBool parses the boolean environment variable name, reporting whether it is set to a valid value
+func envBool(name string) (value, ok bool) {
+	v := os.Getenv(name)
+	if v == "" {
+		return false, false
+	}
+	value, err := strconv.ParseBool(v)
+	if err != nil {
+		otel.Handle(fmt.Errorf("otelfuego: invalid %s %q: %w", name, v, err))
+		return false, false
+	}
+	return value, true
+}
@@ -0,0 +1,68 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("OTELFUEGO_FILTER_PATHS", "/internal, /metrics")
+	t.Setenv("OTELFUEGO_CAPTURE_HEADERS", "X-Request-ID,accept-language")
+	t.Setenv("OTELFUEGO_SPAN_NAME_MODE", "method")
+	t.Setenv("OTELFUEGO_COUNT_REQUEST_BODY", "true")
+
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithFilter(otelfuego.HealthCheckFilter()),
+		otelfuego.FromEnv(),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, path := range []string{"/health", "/internal/cache", "/metrics", "/users/42"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-Request-ID", "abc123")
+		req.Header.Add("Accept-Language", "en")
+		req.Header.Add("Accept-Language", "fr")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected only /users/42 to be traced, got %d spans", len(spans))
+	}
+	if spans[0].Name != "GET" {
+		t.Errorf("Expected span name 'GET', got '%s'", spans[0].Name)
+	}
+
+	// Methods clients choose freely do not make up span names
+	exporter.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PURGE-EVERYTHING", "/users/42", nil))
+	if name := exporter.GetSpans()[0].Name; name != "HTTP" {
+		t.Errorf("Expected span name 'HTTP' for an unknown method, got '%s'", name)
+	}
+
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("http.request.header.x-request-id"); len(v.AsStringSlice()) != 1 || v.AsStringSlice()[0] != "abc123" {
+		t.Errorf("Expected x-request-id [abc123], got %v", v.AsStringSlice())
+	}
+	if v, _ := attrs.Value("http.request.header.accept-language"); len(v.AsStringSlice()) != 2 {
+		t.Errorf("Expected 2 accept-language values, got %v", v.AsStringSlice())
+	}
+}
@@ -0,0 +1,113 @@
+package otelfuego
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	fuegoErrorTypeKey   = attribute.Key("fuego.error.type")
+	fuegoErrorTitleKey  = attribute.Key("fuego.error.title")
+	fuegoErrorDetailKey = attribute.Key("fuego.error.detail")
+	fuegoErrorStatusKey = attribute.Key("fuego.error.status")
+	validationNameKey   = attribute.Key("fuego.validation.name")
+	validationReasonKey = attribute.Key("fuego.validation.reason")
+
+	// maxValidationErrorEvents bounds the number of validation error events recorded per error
+	maxValidationErrorEvents = 10
+)
+
+// errorWithStatus matches fuego.ErrorWithStatus, implemented by fuego.HTTPError,
+// fuego.BadRequestError, fuego.NotFoundError and the other fuego error types.
+type errorWithStatus interface {
+	error
+	StatusCode() int
+}
+
+// problemDetails is the RFC 9457 representation fuego errors are serialized to
+type problemDetails struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Errors []struct {
+		Name   string `json:"name"`
+		Reason string `json:"reason"`
+	} `json:"errors"`
+}
+
+// RecordError records err on the span in ctx. When err is, or wraps, a fuego error such as
+// fuego.HTTPError or fuego.BadRequestError, its type, title, detail and status are added to the
+// exception event, the Go error type is recorded as error.type on the span, and each validation
+// error is recorded as a separate "fuego.validation_error" event.
+//
+// Errors returned from controllers wrapped with Controller are recorded automatically.
+func RecordError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	limit := attributeValueLimit(ctx)
+	state := requestStateFromContext(ctx)
+	var httpErr errorWithStatus
+	if !errors.As(err, &httpErr) {
+		recordError(span, err, limit)
+		if state != nil {
+			state.errorType = fmt.Sprintf("%T", err)
+		}
+		return
+	}
+
+	errorType := fmt.Sprintf("%T", httpErr)
+	span.SetAttributes(semconv.ErrorTypeKey.String(errorType))
+	if state != nil {
+		state.errorRecorded = true
+		state.errorType = errorType
+	}
+
+	attrs := []attribute.KeyValue{
+		fuegoErrorStatusKey.Int(httpErr.StatusCode()),
+	}
+
+	problem := problemFromError(httpErr)
+	if problem.Type != "" {
+		attrs = append(attrs, fuegoErrorTypeKey.String(problem.Type))
+	}
+	if problem.Title != "" {
+		attrs = append(attrs, fuegoErrorTitleKey.String(truncateValue(problem.Title, limit)))
+	}
+	if problem.Detail != "" {
+		attrs = append(attrs, fuegoErrorDetailKey.String(truncateValue(problem.Detail, limit)))
+	}
+	recordError(span, err, limit, attrs...)
+
+	for i, item := range problem.Errors {
+		if i == maxValidationErrorEvents {
+			break
+		}
+		span.AddEvent("fuego.validation_error", trace.WithAttributes(
+			validationNameKey.String(item.Name),
+			validationReasonKey.String(truncateValue(item.Reason, limit)),
+		))
+	}
+}
+
+// problemFromError extracts the problem details of a fuego error. fuego errors are
+// JSON-serializable problem details; marshal them rather than depending on the fuego
+// module for field access.
+func problemFromError(err error) problemDetails {
+	var problem problemDetails
+	if data, jsonErr := json.Marshal(err); jsonErr == nil {
+		_ = json.Unmarshal(data, &problem)
+	}
+	return problem
+}
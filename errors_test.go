@@ -0,0 +1,86 @@
+package otelfuego_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// validationError mimics fuego.ErrorItem
+type validationError struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// badRequestError mimics the shape of fuego.BadRequestError
+type badRequestError struct {
+	Type   string            `json:"type,omitempty"`
+	Title  string            `json:"title,omitempty"`
+	Status int               `json:"status,omitempty"`
+	Detail string            `json:"detail,omitempty"`
+	Errors []validationError `json:"errors,omitempty"`
+}
+
+func (e badRequestError) Error() string { return e.Title + ": " + e.Detail }
+
+func (e badRequestError) StatusCode() int { return e.Status }
+
+func TestRecordError_FuegoError(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+	otelfuego.RecordError(ctx, fmt.Errorf("create user: %w", badRequestError{
+		Title:  "Bad Request",
+		Status: 400,
+		Detail: "validation failed",
+		Errors: []validationError{
+			{Name: "email", Reason: "must be a valid email"},
+		},
+	}))
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("error.type"); v.AsString() != "otelfuego_test.badRequestError" {
+		t.Errorf("Expected error.type 'otelfuego_test.badRequestError', got '%s'", v.AsString())
+	}
+
+	events := spans[0].Events
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	exception := attribute.NewSet(events[0].Attributes...)
+	if v, _ := exception.Value("fuego.error.title"); v.AsString() != "Bad Request" {
+		t.Errorf("Expected title 'Bad Request', got '%s'", v.AsString())
+	}
+	if v, _ := exception.Value("fuego.error.detail"); v.AsString() != "validation failed" {
+		t.Errorf("Expected detail 'validation failed', got '%s'", v.AsString())
+	}
+	if v, _ := exception.Value("fuego.error.status"); v.AsInt64() != 400 {
+		t.Errorf("Expected status 400, got %d", v.AsInt64())
+	}
+
+	if events[1].Name != "fuego.validation_error" {
+		t.Errorf("Expected 'fuego.validation_error' event, got '%s'", events[1].Name)
+	}
+	validation := attribute.NewSet(events[1].Attributes...)
+	if v, _ := validation.Value("fuego.validation.name"); v.AsString() != "email" {
+		t.Errorf("Expected validation name 'email', got '%s'", v.AsString())
+	}
+}
@@ -0,0 +1,53 @@
+package otelfuego
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	experimentKeyPrefix = "experiment."
+
+	// maxExperimentVariantLength bounds the length of recorded variant values
+	maxExperimentVariantLength = 64
+)
+
+// Experiment describes where the variant assigned to a request for an A/B experiment is read from.
+// The variant is taken from Header if set and present on the request, and from Cookie otherwise.
+type Experiment struct {
+	// Name of the experiment, recorded as the experiment.<Name> attribute
+	Name string
+	// Header carrying the assigned variant
+	Header string
+	// Cookie carrying the assigned variant
+	Cookie string
+}
+
+// variant returns the variant assigned to the request, if any
+func (e Experiment) variant(r *http.Request) string {
+	if e.Header != "" {
+		if v := r.Header.Get(e.Header); v != "" {
+			return v
+		}
+	}
+	if e.Cookie != "" {
+		if c, err := r.Cookie(e.Cookie); err == nil {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// appendExperimentAttributes appends an experiment.<name> attribute for each experiment the request
+// is assigned to. Variants longer than maxExperimentVariantLength bytes are truncated on a UTF-8 boundary.
+func appendExperimentAttributes(attrs []attribute.KeyValue, experiments []Experiment, r *http.Request) []attribute.KeyValue {
+	for _, e := range experiments {
+		v := e.variant(r)
+		if v == "" {
+			continue
+		}
+		attrs = append(attrs, attribute.String(experimentKeyPrefix+e.Name, truncateValue(v, maxExperimentVariantLength)))
+	}
+	return attrs
+}
@@ -0,0 +1,65 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithExperiments(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithExperiments(
+			otelfuego.Experiment{Name: "checkout_flow", Header: "X-Exp-Checkout"},
+			otelfuego.Experiment{Name: "pricing_page", Header: "X-Exp-Pricing", Cookie: "exp_pricing"},
+			otelfuego.Experiment{Name: "search", Cookie: "exp_search"},
+			otelfuego.Experiment{Name: "layout", Header: "X-Exp-Layout"},
+		),
+	)
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/checkout", nil)
+	req.Header.Set("X-Exp-Checkout", "one_page")
+	req.Header.Set("X-Exp-Unlisted", "ignored")
+	req.Header.Set("X-Exp-Layout", "v"+strings.Repeat("é", 40))
+	req.AddCookie(&http.Cookie{Name: "exp_pricing", Value: strings.Repeat("v", 100)})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("experiment.checkout_flow"); v.AsString() != "one_page" {
+		t.Errorf("Expected checkout_flow variant 'one_page', got '%s'", v.AsString())
+	}
+	if v, _ := attrs.Value("experiment.pricing_page"); len(v.AsString()) != 64 {
+		t.Errorf("Expected pricing_page variant truncated to 64 bytes, got %d", len(v.AsString()))
+	}
+	if v, _ := attrs.Value("experiment.layout"); len(v.AsString()) != 63 || !utf8.ValidString(v.AsString()) {
+		t.Errorf("Expected layout variant truncated to 63 bytes of valid UTF-8, got %q", v.AsString())
+	}
+	if attrs.HasValue("experiment.search") {
+		t.Error("Expected no attribute for unassigned experiment")
+	}
+}
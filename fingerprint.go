@@ -0,0 +1,29 @@
+package otelfuego
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const errorFingerprintKey = attribute.Key("error.fingerprint")
+
+// ErrorFingerprinter returns the fingerprint of a failed request from its error type, route and status.
+// Requests failing the same way must get the same fingerprint, so the tracing backend can group them
+// like error trackers group exceptions. The error type is the Go type of the error recorded with
+// RecordError, or the status code. No fingerprint is recorded when it returns an empty string.
+type ErrorFingerprinter func(errorType, route string, status int) string
+
+// DefaultErrorFingerprint is an ErrorFingerprinter returning the first 16 hex digits of the SHA-256
+// hash of the error type, route and status
+func DefaultErrorFingerprint(errorType, route string, status int) string {
+	h := sha256.New()
+	h.Write([]byte(errorType))
+	h.Write([]byte{0})
+	h.Write([]byte(route))
+	h.Write([]byte{0})
+	h.Write(strconv.AppendInt(nil, int64(status), 10))
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,71 @@
+package otelfuego_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithErrorFingerprint(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /files/{name}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("name") {
+		case "ok":
+		case "missing":
+			otelfuego.RecordError(r.Context(), &fs.PathError{Op: "open", Err: fs.ErrNotExist})
+			w.WriteHeader(http.StatusNotFound)
+		case "broken":
+			otelfuego.RecordError(r.Context(), errors.New("disk failure"))
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithErrorFingerprint(otelfuego.DefaultErrorFingerprint),
+	)(mux)
+
+	fingerprint := func(path string) string {
+		exporter.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+		v, _ := attrs.Value("error.fingerprint")
+		return v.AsString()
+	}
+
+	if got := fingerprint("/files/ok"); got != "" {
+		t.Errorf("Expected no fingerprint for successful requests, got %q", got)
+	}
+	missing := fingerprint("/files/missing")
+	if len(missing) != 16 {
+		t.Fatalf("Expected a 16 digit fingerprint, got %q", missing)
+	}
+	if got := fingerprint("/files/missing"); got != missing {
+		t.Errorf("Expected the fingerprint to be stable, got %q and %q", missing, got)
+	}
+	if want := otelfuego.DefaultErrorFingerprint("*fs.PathError", "/files/{name}", 404); missing != want {
+		t.Errorf("Expected the fingerprint of the recorded error type, got %q instead of %q", missing, want)
+	}
+	for _, path := range []string{"/files/unknown", "/files/broken"} {
+		if got := fingerprint(path); got == missing || got == "" {
+			t.Errorf("Expected a different fingerprint for %s, got %q", path, got)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package otelfuego
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	forwardKindKey   = attribute.Key("otelfuego.forward.kind")
+	forwardOriginKey = attribute.Key("otelfuego.forward.origin_span_id")
+
+	// originParam is the query parameter carrying the originating span across a Redirect
+	originParam = "otelfuego_origin"
+
+	// originTTL bounds the time between a Redirect and its follow-up request
+	originTTL = time.Minute
+)
+
+// originAEAD seals the originating span of redirects, so clients can neither read its trace and span
+// IDs from the Location header nor forge links to other traces. The key is generated per process, so
+// follow-up requests reaching another instance of the service are not linked. It is nil if no key
+// could be generated.
+var originAEAD = sync.OnceValue(func() cipher.AEAD {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil
+	}
+	return aead
+})
+
+// forwardKey is the context key under which Forward stores the originating span context
+type forwardKey struct{}
+
+// Forward returns a shallow copy of r marked as forwarded from the span in r's context, for handlers
+// that re-dispatch a request to another route on the same server, e.g. through the router's ServeHTTP.
+// The span the middleware creates for the forwarded request links to the originating span and records
+// otelfuego.forward.kind="forward". Requests without a span are returned unchanged.
+//
+// Example:
+//
+//	mux.ServeHTTP(w, otelfuego.Forward(r))
+func Forward(r *http.Request) *http.Request {
+	origin := trace.SpanContextFromContext(r.Context())
+	if !origin.IsValid() {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), forwardKey{}, origin))
+}
+
+// Redirect replies to the request with a redirect to url, like http.Redirect, and marks the follow-up
+// request as originating from the span in r's context. The span the middleware creates for the
+// follow-up request links to the originating span and records otelfuego.forward.kind="redirect".
+//
+// The originating span is carried in a query parameter, encrypted with a key of the process and only
+// honored for a minute, so Redirect should only be used for redirects to routes on the same server.
+func Redirect(w http.ResponseWriter, r *http.Request, url string, code int) {
+	if origin := trace.SpanContextFromContext(r.Context()); origin.IsValid() {
+		if token := sealOrigin(origin, time.Now()); token != "" {
+			url = withOriginParam(url, token)
+		}
+	}
+	http.Redirect(w, r, url, code)
+}
+
+// sealOrigin returns the origin query parameter value carrying origin, sealed at now, or an empty
+// string if it cannot be sealed
+func sealOrigin(origin trace.SpanContext, now time.Time) string {
+	aead := originAEAD()
+	if aead == nil {
+		return ""
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+32+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return ""
+	}
+	traceID, spanID := origin.TraceID(), origin.SpanID()
+	plaintext := make([]byte, 0, 32)
+	plaintext = append(plaintext, traceID[:]...)
+	plaintext = append(plaintext, spanID[:]...)
+	plaintext = binary.BigEndian.AppendUint64(plaintext, uint64(now.Unix()))
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil))
+}
+
+// openOrigin returns the originating span sealed in value by sealOrigin, if sealed by this process
+// less than originTTL before now
+func openOrigin(value string, now time.Time) (trace.SpanContext, bool) {
+	aead := originAEAD()
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if aead == nil || err != nil || len(sealed) < aead.NonceSize() {
+		return trace.SpanContext{}, false
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil || len(plaintext) != 32 {
+		return trace.SpanContext{}, false
+	}
+	sealedAt := time.Unix(int64(binary.BigEndian.Uint64(plaintext[24:])), 0)
+	if now.Sub(sealedAt) > originTTL || sealedAt.After(now.Add(time.Second)) {
+		return trace.SpanContext{}, false
+	}
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	copy(traceID[:], plaintext[:16])
+	copy(spanID[:], plaintext[16:24])
+	origin := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, Remote: true})
+	return origin, origin.IsValid()
+}
+
+// withOriginParam adds the origin query parameter to target, keeping any fragment at the end
+func withOriginParam(target, value string) string {
+	fragment := ""
+	if i := strings.IndexByte(target, '#'); i >= 0 {
+		target, fragment = target[:i], target[i:]
+	}
+	sep := "?"
+	if strings.Contains(target, "?") {
+		sep = "&"
+	}
+	return target + sep + originParam + "=" + url.QueryEscape(value) + fragment
+}
+
+// forwardedFrom returns the span r was forwarded or redirected from, and how
+func forwardedFrom(r *http.Request) (trace.SpanContext, string, bool) {
+	if origin, ok := r.Context().Value(forwardKey{}).(trace.SpanContext); ok {
+		return origin, "forward", true
+	}
+	if r.URL.RawQuery == "" || !strings.Contains(r.URL.RawQuery, originParam) {
+		return trace.SpanContext{}, "", false
+	}
+
+	origin, ok := openOrigin(r.URL.Query().Get(originParam), time.Now())
+	if !ok {
+		return trace.SpanContext{}, "", false
+	}
+	return origin, "redirect", true
+}
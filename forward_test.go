@@ -0,0 +1,118 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestForwardAndRedirect(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))
+
+	mux := http.NewServeMux()
+	mux.Handle("/old", middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otelfuego.Redirect(w, r, "/new?page=2", http.StatusFound)
+	})))
+	mux.Handle("/legacy", middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = "/new"
+		mux.ServeHTTP(w, otelfuego.Forward(r))
+	})))
+	mux.Handle("/new", middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	tests := []struct {
+		name     string
+		path     string
+		wantKind string
+	}{
+		{name: "forward", path: "/legacy", wantKind: "forward"},
+		{name: "redirect", path: "/old", wantKind: "redirect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if location := w.Header().Get("Location"); location != "" {
+				mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", location, nil))
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 2 {
+				t.Fatalf("Expected 2 spans, got %d", len(spans))
+			}
+
+			var origin, followUp tracetest.SpanStub
+			for _, span := range spans {
+				if span.Name == "GET /new" {
+					followUp = span
+				} else {
+					origin = span
+				}
+			}
+
+			if len(followUp.Links) != 1 || followUp.Links[0].SpanContext.SpanID() != origin.SpanContext.SpanID() {
+				t.Fatalf("Expected follow-up request to link to the originating span, got %v", followUp.Links)
+			}
+
+			attrs := attribute.NewSet(followUp.Attributes...)
+			if v, _ := attrs.Value("otelfuego.forward.kind"); v.AsString() != tt.wantKind {
+				t.Errorf("Expected forward kind '%s', got '%s'", tt.wantKind, v.AsString())
+			}
+			if v, _ := attrs.Value("otelfuego.forward.origin_span_id"); v.AsString() != origin.SpanContext.SpanID().String() {
+				t.Errorf("Expected origin span ID '%s', got '%s'", origin.SpanContext.SpanID(), v.AsString())
+			}
+		})
+	}
+}
+
+func TestRedirect_OriginParam(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))
+	redirect := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otelfuego.Redirect(w, r, "/new", http.StatusFound)
+	}))
+	target := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// The Location header does not reveal the trace to the client
+	w := httptest.NewRecorder()
+	redirect.ServeHTTP(w, httptest.NewRequest("GET", "/old", nil))
+	origin := exporter.GetSpans()[0].SpanContext
+	location := w.Header().Get("Location")
+	if strings.Contains(location, origin.TraceID().String()) || strings.Contains(location, origin.SpanID().String()) {
+		t.Errorf("Expected the Location header not to carry the trace and span IDs, got %q", location)
+	}
+
+	// Clients cannot link their requests to arbitrary traces
+	exporter.Reset()
+	forged := "/new?otelfuego_origin=" + origin.TraceID().String() + "-" + origin.SpanID().String()
+	target.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", forged, nil))
+	if links := exporter.GetSpans()[0].Links; len(links) != 0 {
+		t.Errorf("Expected a forged origin not to be linked, got %v", links)
+	}
+}
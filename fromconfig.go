@@ -0,0 +1,178 @@
+package otelfuego
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Config is a declarative alternative to functional options for teams loading middleware settings
+// from files. The zero value of each field keeps the default behavior. Settings that cannot be
+// expressed in a file, such as the TracerProvider, are passed through Options.
+//
+// Example:
+//
+//	var cfg otelfuego.Config
+//	if err := json.Unmarshal(data, &cfg); err != nil {
+//	    return err
+//	}
+//	cfg.Options = []otelfuego.Option{otelfuego.WithTracerProvider(tp)}
+//	middleware, err := otelfuego.MiddlewareFromConfig(cfg)
+type Config struct {
+	// ServiceName is the service passed to the middleware. Required.
+	ServiceName string `json:"service_name"`
+	// ServiceNameAttribute records ServiceName as service.name on every span, see WithServiceNameAttribute
+	ServiceNameAttribute bool `json:"service_name_attribute,omitempty"`
+	// FilterPaths lists path prefixes of requests not to trace, see PathPrefixFilter
+	FilterPaths []string `json:"filter_paths,omitempty"`
+	// CaptureHeaders lists request headers to record, see WithCapturedRequestHeaders
+	CaptureHeaders []string `json:"capture_headers,omitempty"`
+	// SpanNameMode is "route" (the default), "path" or "method", see FromEnv
+	SpanNameMode string `json:"span_name_mode,omitempty"`
+	// GraphQLPath is the path of the GraphQL endpoint, see WithGraphQL
+	GraphQLPath string `json:"graphql_path,omitempty"`
+	// Experiments lists the A/B experiments to record, see WithExperiments
+	Experiments []Experiment `json:"experiments,omitempty"`
+	// PhaseSpans enables phase spans, see WithPhaseSpans
+	PhaseSpans bool `json:"phase_spans,omitempty"`
+	// CountRequestBody enables request body counting, see WithRequestBodyCounting
+	CountRequestBody bool `json:"count_request_body,omitempty"`
+	// MaxSpansPerSecond limits the rate of spans, see WithMaxSpansPerSecond
+	MaxSpansPerSecond int `json:"max_spans_per_second,omitempty"`
+	// RequestAnomalies enables recording malformed headers, see WithRequestAnomalies
+	RequestAnomalies bool `json:"request_anomalies,omitempty"`
+	// MaxLogEvents overrides the number of log events recorded per request, see WithMaxLogEvents
+	MaxLogEvents int `json:"max_log_events,omitempty"`
+	// ClientDisconnectStatus is the span status description of client disconnects, see WithClientDisconnectStatus
+	ClientDisconnectStatus string `json:"client_disconnect_status,omitempty"`
+	// ConfigProfile publishes the configuration as scope attributes, see WithConfigAttributes
+	ConfigProfile string `json:"config_profile,omitempty"`
+
+	// Options are applied after the settings above
+	Options []Option `json:"-"`
+}
+
+// MiddlewareFromConfig returns the middleware described by cfg, like NewMiddleware, or an error
+// listing every invalid or conflicting setting.
+func MiddlewareFromConfig(cfg Config) (func(http.Handler) http.Handler, error) {
+	opts, err := cfg.options()
+	if err != nil {
+		return nil, err
+	}
+	return NewMiddleware(cfg.ServiceName, opts...)
+}
+
+// options validates cfg and converts it to functional options
+func (cfg Config) options() ([]Option, error) {
+	var errs []error
+	var opts []Option
+
+	if cfg.ServiceName == "" {
+		errs = append(errs, errors.New("service_name is required"))
+	}
+
+	if len(cfg.FilterPaths) > 0 {
+		filters := make([]Filter, 0, len(cfg.FilterPaths))
+		for _, prefix := range cfg.FilterPaths {
+			if !strings.HasPrefix(prefix, "/") {
+				errs = append(errs, fmt.Errorf("filter_paths: %q must start with /", prefix))
+			}
+			if cfg.GraphQLPath != "" && strings.HasPrefix(cfg.GraphQLPath, prefix) {
+				errs = append(errs, fmt.Errorf("filter_paths: %q excludes graphql_path %q", prefix, cfg.GraphQLPath))
+			}
+			filters = append(filters, PathPrefixFilter(prefix))
+		}
+		opts = append(opts, WithFilter(CombineFilters(filters...)))
+	}
+
+	for _, h := range cfg.CaptureHeaders {
+		if !validHeaderName(h) {
+			errs = append(errs, fmt.Errorf("capture_headers: %q is not a valid header name", h))
+		}
+	}
+	if len(cfg.CaptureHeaders) > 0 {
+		opts = append(opts, WithCapturedRequestHeaders(cfg.CaptureHeaders...))
+	}
+
+	if cfg.SpanNameMode != "" {
+		formatter, err := spanNameFormatterForMode(cfg.SpanNameMode)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("span_name_mode: %w", err))
+		}
+		opts = append(opts, WithSpanNameFormatter(formatter))
+	}
+
+	if cfg.GraphQLPath != "" {
+		if !strings.HasPrefix(cfg.GraphQLPath, "/") {
+			errs = append(errs, fmt.Errorf("graphql_path: %q must start with /", cfg.GraphQLPath))
+		}
+		opts = append(opts, WithGraphQL(cfg.GraphQLPath))
+	}
+
+	names := make(map[string]bool, len(cfg.Experiments))
+	for _, e := range cfg.Experiments {
+		switch {
+		case e.Name == "":
+			errs = append(errs, errors.New("experiments: name is required"))
+		case names[e.Name]:
+			errs = append(errs, fmt.Errorf("experiments: %q is listed more than once", e.Name))
+		case e.Header == "" && e.Cookie == "":
+			errs = append(errs, fmt.Errorf("experiments: %q needs a header or a cookie", e.Name))
+		}
+		names[e.Name] = true
+	}
+	if len(cfg.Experiments) > 0 {
+		opts = append(opts, WithExperiments(cfg.Experiments...))
+	}
+
+	if cfg.PhaseSpans {
+		opts = append(opts, WithPhaseSpans())
+	}
+	if cfg.ServiceNameAttribute {
+		opts = append(opts, WithServiceNameAttribute())
+	}
+	if cfg.CountRequestBody {
+		opts = append(opts, WithRequestBodyCounting())
+	}
+	if cfg.MaxSpansPerSecond < 0 {
+		errs = append(errs, fmt.Errorf("max_spans_per_second: %d must not be negative", cfg.MaxSpansPerSecond))
+	} else if cfg.MaxSpansPerSecond > 0 {
+		opts = append(opts, WithMaxSpansPerSecond(cfg.MaxSpansPerSecond))
+	}
+	if cfg.RequestAnomalies {
+		opts = append(opts, WithRequestAnomalies())
+	}
+	if cfg.MaxLogEvents < 0 {
+		errs = append(errs, fmt.Errorf("max_log_events: %d must not be negative", cfg.MaxLogEvents))
+	} else if cfg.MaxLogEvents > 0 {
+		opts = append(opts, WithMaxLogEvents(cfg.MaxLogEvents))
+	}
+	if cfg.ClientDisconnectStatus != "" {
+		opts = append(opts, WithClientDisconnectStatus(cfg.ClientDisconnectStatus))
+	}
+	if cfg.ConfigProfile != "" {
+		opts = append(opts, WithConfigAttributes(cfg.ConfigProfile))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("otelfuego: invalid config: %w", err)
+	}
+	return append(opts, cfg.Options...), nil
+}
+
+// validHeaderName reports whether name is a valid HTTP header field name (an RFC 9110 token)
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range []byte(name) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package otelfuego_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewareFromConfig(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var cfg otelfuego.Config
+	err := json.Unmarshal([]byte(`{
+		"service_name": "checkout",
+		"service_name_attribute": true,
+		"filter_paths": ["/internal"],
+		"span_name_mode": "method",
+		"experiments": [{"name": "flow", "header": "X-Exp-Flow"}]
+	}`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Options = []otelfuego.Option{otelfuego.WithTracerProvider(tp)}
+
+	middleware, err := otelfuego.MiddlewareFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, path := range []string{"/internal/cache", "/orders"} {
+		req := httptest.NewRequest("POST", path, nil)
+		req.Header.Set("X-Exp-Flow", "b")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "POST" {
+		t.Errorf("Expected span name 'POST', got '%s'", spans[0].Name)
+	}
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("service.name"); v.AsString() != "checkout" {
+		t.Errorf("Expected service.name 'checkout', got '%s'", v.AsString())
+	}
+	if v, _ := attrs.Value("experiment.flow"); v.AsString() != "b" {
+		t.Errorf("Expected experiment.flow 'b', got '%s'", v.AsString())
+	}
+}
+
+func TestMiddlewareFromConfig_Invalid(t *testing.T) {
+	_, err := otelfuego.MiddlewareFromConfig(otelfuego.Config{
+		FilterPaths:    []string{"/api"},
+		GraphQLPath:    "/api/graphql",
+		CaptureHeaders: []string{"X Bad"},
+		SpanNameMode:   "full",
+		Experiments:    []otelfuego.Experiment{{Name: "flow"}},
+		MaxLogEvents:   -1,
+	})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	for _, want := range []string{
+		"service_name is required",
+		`"/api" excludes graphql_path`,
+		`"X Bad" is not a valid header name`,
+		`span name mode "full"`,
+		`"flow" needs a header or a cookie`,
+		"max_log_events",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got: %v", want, err)
+		}
+	}
+}
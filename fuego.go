@@ -2,14 +2,21 @@ package otelfuego
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -34,82 +41,643 @@ const (
 //	        return !strings.Contains(req.URL.Path, "/health")
 //	    }),
 //	))
+//
+// Invalid options are reported to the error handler set with WithErrorHandler, or the global
+// OpenTelemetry error handler; use NewMiddleware to get them as an error instead.
 func Middleware(service string, opts ...Option) func(http.Handler) http.Handler {
 	cfg := newConfig(opts...)
+	if err := cfg.validate(); err != nil {
+		cfg.handleError(err)
+	}
+	return newMiddleware(service, cfg).handler
+}
+
+// middleware holds the state shared by all requests instrumented by a Middleware
+type middleware struct {
+	service     string
+	cfg         *config
+	tracer      trace.Tracer
+	propagators propagation.TextMapPropagator
+
+	kind    trace.SpanKind
+	semconv SemconvMode
+
+	// spanKind and internalKind are built once as the options would otherwise be allocated per request
+	spanKind     trace.SpanStartOption
+	internalKind trace.SpanStartOption
+
+	// slowest is set for middleware created through an Instrumentation
+	slowest *slowestRequests
+
+	// debug is set for middleware created through an Instrumentation WithDebugSpans
+	debug *debugSpans
+
+	// budget is set when the rate of spans is limited
+	budget  *spanBudget
+	metrics *selfMetrics
+
+	// requestMetrics report about the requests served rather than about the middleware
+	requestMetrics *requestMetrics
+
+	// names is set when the number of distinct span names is limited
+	names *cardinalityGuard
+
+	// routeSpanNames is set when spans are named by the default formatter, which uses the route
+	routeSpanNames bool
 
-	// Get tracer from configured provider or global
+	// contextHeaders are the headers carrying the trace context for the propagators
+	contextHeaders []string
+}
+
+// tracerFor returns the tracer of the providers set with WithTracerProvider and
+// WithAdditionalTracerProviders, or of the global provider
+func tracerFor(cfg *config) trace.Tracer {
 	tracerProvider := cfg.TracerProvider
 	if tracerProvider == nil {
 		tracerProvider = otel.GetTracerProvider()
 	}
-
-	tracer := tracerProvider.Tracer(
-		instrumentationName,
-		trace.WithInstrumentationVersion(instrumentationVersion),
+	if len(cfg.AdditionalTracerProviders) > 0 {
+		providers := append([]trace.TracerProvider{tracerProvider}, cfg.AdditionalTracerProviders...)
+		tracerProvider = &multiTracerProvider{providers: providers}
+	}
+	return tracerProvider.Tracer(
+		cfg.ScopeName,
+		trace.WithInstrumentationVersion(cfg.ScopeVersion),
+		trace.WithInstrumentationAttributes(cfg.scopeAttributes()...),
 	)
+}
+
+// propagatorsFor returns the propagators set with WithPropagators, or the global propagators
+func propagatorsFor(cfg *config) propagation.TextMapPropagator {
+	if cfg.Propagators != nil {
+		return cfg.Propagators
+	}
+	return otel.GetTextMapPropagator()
+}
+
+func newMiddleware(service string, cfg *config) *middleware {
+	tracer := tracerFor(cfg)
+	propagators := propagatorsFor(cfg)
 
-	// Get propagators from config or use global
-	propagators := cfg.Propagators
-	if propagators == nil {
-		propagators = otel.GetTextMapPropagator()
+	spanKind := cfg.SpanKind
+	if spanKind == trace.SpanKindUnspecified {
+		spanKind = trace.SpanKindServer
 	}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Apply request filter if configured
-			if cfg.Filter != nil && !cfg.Filter(r) {
-				next.ServeHTTP(w, r)
+	m := &middleware{
+		service:      service,
+		cfg:          cfg,
+		tracer:       tracer,
+		propagators:  propagators,
+		kind:         spanKind,
+		semconv:      cfg.Semconv,
+		spanKind:     trace.WithSpanKind(spanKind),
+		internalKind: trace.WithSpanKind(trace.SpanKindInternal),
+		metrics:      newSelfMetrics(cfg),
+	}
+	if m.semconv == 0 {
+		m.semconv = semconvModeFromEnv()
+	}
+	if cfg.MaxSpansPerSecond > 0 {
+		m.budget = newSpanBudget(cfg.MaxSpansPerSecond)
+	}
+	m.cfg = m.metrics.guardHooks(cfg)
+	m.requestMetrics = newRequestMetrics(m.cfg)
+	m.routeSpanNames = isDefaultSpanNameFormatter(cfg.SpanNameFormatter)
+	for _, field := range propagators.Fields() {
+		// Baggage and trace state are only meaningful with a trace context carried by another header
+		if field != "baggage" && field != "tracestate" {
+			m.contextHeaders = append(m.contextHeaders, http.CanonicalHeaderKey(field))
+		}
+	}
+	if cfg.MaxSpanNames > 0 {
+		m.names = newCardinalityGuard(cfg.MaxSpanNames)
+	}
+	return m
+}
+
+// serviceName returns the logical service handling r
+func (m *middleware) serviceName(r *http.Request) string {
+	if m.cfg.ServiceNameFunc != nil {
+		if name := m.cfg.ServiceNameFunc(r); name != "" {
+			return name
+		}
+	}
+	return m.service
+}
+
+// extract returns ctx with the trace context propagated in the headers of r, counting requests
+// carrying trace context headers no valid context could be extracted from. The trace state and the
+// baggage r is not trusted with are left out when untrustedState is set, whichever carrier they come
+// from.
+func (m *middleware) extract(ctx context.Context, r *http.Request, untrustedState bool) context.Context {
+	var carrier propagation.TextMapCarrier = propagation.HeaderCarrier(r.Header)
+	if m.cfg.CarrierFactory != nil {
+		if c := m.cfg.CarrierFactory(r); c != nil {
+			carrier = c
+		}
+	}
+	if untrustedState {
+		carrier = untrustedStateCarrier{TextMapCarrier: carrier, allowed: m.cfg.AllowedBaggageKeys}
+	}
+	ctx = m.propagators.Extract(ctx, carrier)
+	if trace.SpanContextFromContext(ctx).IsRemote() {
+		return ctx
+	}
+	for _, h := range m.contextHeaders {
+		var value string
+		if m.cfg.CarrierFactory != nil {
+			value = carrier.Get(h)
+		} else if values := r.Header[h]; len(values) > 0 {
+			value = values[0]
+		}
+		if value != "" && !rootOnlyXRayHeader(h, value) {
+			m.metrics.propagationFailures.Add(ctx, 1, m.metrics.addOptions...)
+			if m.cfg.ErrorHandler != nil {
+				m.cfg.handleError(fmt.Errorf("otelfuego: no valid trace context in %s header %q", h, truncateValue(value, maxReportedHeaderValue)))
+			}
+			break
+		}
+	}
+	return ctx
+}
+
+// stripState returns r without the trace state and baggage headers it is not trusted with, and
+// whether it is not trusted with them
+func (m *middleware) stripState(r *http.Request) (*http.Request, bool) {
+	if m.cfg.StripIncomingState == nil || !m.cfg.StripIncomingState(r) {
+		return r, false
+	}
+	return stripIncomingState(r, m.cfg.AllowedBaggageKeys), true
+}
+
+// handler wraps next with the middleware
+func (m *middleware) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.serveHTTP(w, r, next)
+	})
+}
+
+// serveHTTP instruments a single request handled by next
+func (m *middleware) serveHTTP(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	cfg := m.cfg
+
+	var timings requestTimings
+	if cfg.TimingAttributes || cfg.OverheadMetric {
+		timings.entry = time.Now()
+	}
+
+	// Skip tracing while disabled at runtime, or for requests excluded by the filter
+	if cfg.Enabled != nil && !cfg.Enabled() {
+		m.metrics.droppedSpans.Add(r.Context(), 1, m.metrics.disabled)
+		next.ServeHTTP(w, r)
+		return
+	}
+	if (cfg.Filter != nil && !cfg.Filter(r)) ||
+		(cfg.RouteFilter != nil && !cfg.RouteFilter(r, matchedPattern(r, cfg.RouteFilterMux))) {
+		m.metrics.droppedSpans.Add(r.Context(), 1, m.metrics.filtered)
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	// Requests already instrumented by an outer middleware of this package get a single server span
+	spanKind := m.spanKind
+	if r.Context().Value(instrumentedKey{}) != nil && r.Context().Value(forwardKey{}) == nil {
+		switch cfg.Nested {
+		case NestedSkip:
+			if cfg.RouteGroup != "" {
+				trace.SpanFromContext(r.Context()).SetAttributes(routeGroupKey.String(cfg.RouteGroup))
+			}
+			next.ServeHTTP(w, r)
+			return
+		case NestedInternal:
+			spanKind = m.internalKind
+		}
+	}
+
+	// Pass requests beyond the span budget through, keeping their trace context for downstream calls
+	if m.budget != nil && !m.budget.allow(time.Now()) {
+		var tenant string
+		if cfg.TenantExtractor != nil {
+			tenant = tenantID(cfg.TenantExtractor, r)
+		}
+		m.metrics.droppedSpans.Add(r.Context(), 1, m.metrics.rateLimitedFor(tenant))
+		if cfg.StripIncomingContext == nil || !cfg.StripIncomingContext(r) {
+			var untrustedState bool
+			r, untrustedState = m.stripState(r)
+			r = r.WithContext(m.extract(r.Context(), r, untrustedState))
+		}
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	// Drop the trace context of untrusted clients instead of continuing it
+	var strippedAttrs []attribute.KeyValue
+	ctx := r.Context()
+	untrusted := cfg.StripIncomingContext != nil && cfg.StripIncomingContext(r)
+	if untrusted {
+		r, strippedAttrs = stripIncomingContext(r, m.propagators, cfg.PreserveStrippedContext, cfg.MaxAttributeValueLength)
+	} else {
+		// Extract context from headers for distributed tracing
+		var untrustedState bool
+		r, untrustedState = m.stripState(r)
+		ctx = m.extract(ctx, r, untrustedState)
+	}
+
+	// Generate span name using configured formatter or default
+	spanName := cfg.SpanNameFormatter(spanOperation(r.Method), r)
+
+	// Default names get the route once the request went through fuego's ServeMux
+	renameOnRoute := m.routeSpanNames && routePattern(r) == ""
+
+	// Name GraphQL requests after their operation rather than the single endpoint path
+	var gql *graphQLOperation
+	if !minimalBuild && cfg.GraphQLPath != "" && r.URL.Path == cfg.GraphQLPath {
+		op := readGraphQLOperation(r)
+		gql = &op
+		if op.Type != "" {
+			spanName, renameOnRoute = op.spanName(), false
+		}
+	}
+
+	// Sniff the body for content mismatches before handlers try to decode it
+	var bodyMismatches []string
+	if cfg.SniffBody {
+		bodyMismatches = sniffBody(r)
+	}
+	rejected := cfg.RejectBodyMismatch && len(bodyMismatches) > 0
+
+	// Prefer the OpenAPI operationId when the route is already known (per-route middleware)
+	op, hasOp := cfg.OpenAPIOperations.lookup(r.Method, routePattern(r))
+	if hasOp && op.OperationID != "" {
+		spanName = op.OperationID
+	}
+
+	// Trust the operation name computed by the API gateway over any name derived here
+	var namedByHeader bool
+	if cfg.SpanNameHeader != nil {
+		if name, ok := cfg.SpanNameHeader.spanName(r); ok {
+			spanName, namedByHeader, renameOnRoute = name, true, false
+		}
+	}
+
+	// Collapse new span names once too many distinct names were produced; the path stays in url.path
+	var collapsed bool
+	if m.names != nil && !m.names.allow(spanName) {
+		spanName, collapsed, renameOnRoute = spanOperation(r.Method), true, false
+	}
+
+	// Start span with extracted context. The request attributes are passed at creation
+	// so that samplers can take them into account.
+	attrs := acquireAttributes()
+	defer releaseAttributes(attrs)
+	*attrs = m.semconv.apply(appendRequestAttributes(*attrs, r, cfg.MaxAttributeValueLength, cfg.OmittedAttributes))
+	if r.TLS != nil {
+		*attrs = appendTLSAttributes(*attrs, r.TLS, cfg.TLSClientSubject)
+	}
+	if collapsed {
+		*attrs = append(*attrs, spanNameCollapsedKey.Bool(true))
+	}
+	*attrs = append(*attrs, cfg.Attributes...)
+
+	// Add vendor-specific trace state before samplers see the parent span context
+	if cfg.TraceStateMutator != nil {
+		ctx = mutateTraceState(ctx, r, cfg.TraceStateMutator)
+	}
+
+	// Let the sampler of the route drop the request before any span is started, and hand its other
+	// decisions to RouteSampler on the tracer provider
+	var routeSampled bool
+	if cfg.RouteSamplers != nil {
+		if result, dropped, ok := cfg.RouteSamplers.sample(ctx, r, spanName, m.kind, *attrs); ok {
+			if result.Decision == sdktrace.Drop {
+				ctx = context.WithValue(trace.ContextWithSpanContext(ctx, dropped), instrumentedKey{}, true)
+				serveUntraced(w, r.WithContext(ctx), next, rejected)
 				return
 			}
+			ctx, routeSampled = context.WithValue(ctx, routeSamplingKey{}, &result), true
+		}
+	}
+
+	// Connect the traces of legacy correlation IDs to the request span
+	var links []trace.Link
+	if len(cfg.CorrelationHeaders) > 0 {
+		links = correlationLinks(r, cfg.CorrelationHeaders, cfg.CorrelationIDDecoder, trace.SpanContextFromContext(ctx))
+	}
 
-			// Extract context from headers for distributed tracing
-			ctx := propagators.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
-
-			// Generate span name using configured formatter or default
-			spanName := cfg.SpanNameFormatter("HTTP "+r.Method, r)
-
-			// Start span with extracted context
-			ctx, span := tracer.Start(ctx, spanName,
-				trace.WithSpanKind(trace.SpanKindServer),
-				trace.WithAttributes(
-					semconv.HTTPRequestMethodKey.String(r.Method),
-					semconv.HTTPRouteKey.String(r.URL.Path),
-					semconv.UserAgentOriginalKey.String(r.UserAgent()),
-					semconv.URLPathKey.String(r.URL.Path),
-					semconv.URLQueryKey.String(r.URL.RawQuery),
-				),
-			)
-			defer span.End()
-
-			// Set additional service attribute
-			span.SetAttributes(attribute.String("service.name", service))
-
-			// Create response writer wrapper to capture status code and response size
-			wrapped := &responseWriter{
-				ResponseWriter: w,
-				statusCode:     http.StatusOK, // Default to 200
+	// Tie requests forwarded or redirected by another handler to the originating span
+	if origin, kind, forwarded := forwardedFrom(r); forwarded {
+		*attrs = append(*attrs, forwardKindKey.String(kind), forwardOriginKey.String(origin.SpanID().String()))
+		if kind == "forward" {
+			// Middleware nested in the one handling the forwarded request must not take it for a forward
+			ctx = context.WithValue(ctx, forwardKey{}, nil)
+		}
+		links = append(links, trace.Link{SpanContext: origin})
+	}
+	var span trace.Span
+	if len(links) > 0 {
+		ctx, span = m.tracer.Start(ctx, spanName, spanKind, trace.WithAttributes(*attrs...), trace.WithLinks(links...))
+	} else {
+		ctx, span = m.tracer.Start(ctx, spanName, spanKind, trace.WithAttributes(*attrs...))
+	}
+	if routeSampled {
+		// The decision only applies to the request span, not to the spans of the handler
+		ctx = context.WithValue(ctx, routeSamplingKey{}, nil)
+	}
+	ctx = context.WithValue(ctx, instrumentedKey{}, true)
+	if cfg.OverheadMetric {
+		// Deferred before ending the span, so that the time spent ending it is included
+		spanCtx := ctx
+		defer func() { m.metrics.recordOverhead(spanCtx, timings, time.Now()) }()
+	}
+	defer span.End()
+	m.metrics.startedSpans.Add(ctx, 1, m.metrics.addOptions...)
+	start := time.Now()
+
+	// Assign the request ID before the handler writes the response headers
+	var reqID string
+	if cfg.RequestIDHeader != "" {
+		reqID = requestID(ctx, r, cfg.RequestIDHeader, untrusted, span.SpanContext())
+		w.Header().Set(cfg.RequestIDHeader, reqID)
+		ctx = context.WithValue(ctx, requestIDContextKey{}, reqID)
+	}
+
+	// Give handlers a logger carrying the correlation fields of the request
+	if cfg.ContextLogger != nil {
+		ctx = withRequestLogger(ctx, cfg.ContextLogger, r, span.SpanContext(), reqID)
+	}
+
+	// Unsampled requests only need the span context to be propagated: skip the
+	// response writer wrapper and all attribute collection
+	if !span.IsRecording() {
+		if !m.requestMetrics.enabled() {
+			serveUntraced(w, r.WithContext(ctx), next, rejected)
+			return
+		}
+		// Request metrics still need the status code and first byte of unsampled requests
+		wrapped := acquireResponseWriter(w)
+		defer releaseResponseWriter(wrapped)
+		r = r.WithContext(ctx)
+		serveUntraced(wrapped, r, next, rejected)
+		if !wrapped.firstByte.IsZero() {
+			m.requestMetrics.recordTimeToFirstByte(ctx, r, wrapped.statusCode, wrapped.firstByte.Sub(start))
+		}
+		m.requestMetrics.recordDuration(ctx, r, wrapped.statusCode, time.Since(start))
+		return
+	}
+
+	// The service name belongs to the resource, unless requested per span or resolved per request
+	*attrs = (*attrs)[:0]
+	if cfg.ServiceNameAttribute || cfg.ServiceNameFunc != nil {
+		*attrs = append(*attrs, attribute.String("service.name", m.serviceName(r)))
+	}
+	if cfg.RouteGroup != "" {
+		*attrs = append(*attrs, routeGroupKey.String(cfg.RouteGroup))
+	}
+	*attrs = append(*attrs, strippedAttrs...)
+	if reqID != "" {
+		*attrs = append(*attrs, requestIDKey.String(reqID))
+	}
+	*attrs = appendExperimentAttributes(*attrs, cfg.Experiments, r)
+	*attrs = appendHeaderAttributes(*attrs, cfg.CapturedRequestHeaders, r, cfg.MaxAttributeValueLength)
+	if cfg.EndUserExtractor != nil {
+		*attrs = appendEndUserAttributes(*attrs, cfg.EndUserExtractor, r)
+	}
+	var tenant string
+	if cfg.TenantExtractor != nil {
+		if tenant = tenantID(cfg.TenantExtractor, r); tenant != "" {
+			*attrs = append(*attrs, tenantIDKey.String(tenant))
+		}
+	}
+	if len(cfg.QueueTimeHeaders) > 0 {
+		if d, ok := queueDuration(r, cfg.QueueTimeHeaders, start); ok {
+			*attrs = append(*attrs, queueDurationKey.Float64(d.Seconds()))
+		}
+	}
+	if len(bodyMismatches) > 0 {
+		*attrs = append(*attrs, bodyMismatchKey.StringSlice(bodyMismatches))
+	}
+	if cfg.RecordAnomalies {
+		if anomalies := requestAnomalies(r); len(anomalies) > 0 {
+			*attrs = append(*attrs, requestAnomaliesKey.StringSlice(anomalies))
+		}
+	}
+	for _, compute := range cfg.LazyAttributes {
+		*attrs = append(*attrs, compute(r)...)
+	}
+	span.SetAttributes(*attrs...)
+
+	// Create response writer wrapper to capture status code and response size
+	wrapped := acquireResponseWriter(w)
+	defer releaseResponseWriter(wrapped)
+	if gql != nil {
+		if gql.Type != "" {
+			span.SetAttributes(gql.attributes(cfg.MaxAttributeValueLength)...)
+		}
+		// GraphQL errors are reported in the response body of 200 responses
+		wrapped.captureLimit = maxGraphQLBodyBytes
+	}
+	if !minimalBuild {
+		wrapped.errorCaptureLimit = cfg.ErrorResponseBodyCapture
+	}
+	wrapped.span = span
+	if cfg.ResponseThroughput != nil {
+		wrapped.progressInterval = cfg.ResponseThroughput.progressInterval
+	}
+
+	// Record the progress of long-running requests on their span
+	if cfg.LongRequestInterval > 0 {
+		defer startHeartbeat(span, start, cfg.LongRequestInterval)()
+	}
+
+	// Track the request for DebugHandler; requests whose handler panicked are recorded as failed
+	var debug *DebugSpan
+	if m.debug != nil {
+		debug = m.debug.start(span.SpanContext(), spanName, r)
+		defer m.debug.finish(debug, "", 0, true)
+	}
+
+	// Update request context with span context and instrumentation state
+	state := &requestState{tracer: m.tracer, cfg: cfg, tenant: tenant}
+	r = r.WithContext(withRequestState(ctx, state))
+
+	// Count the request body when its size is not announced, e.g. for chunked uploads
+	var body *countingBody
+	if cfg.CountRequestBody && r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody {
+		body = &countingBody{ReadCloser: r.Body}
+		r.Body = body
+	}
+
+	// Keep the beginning of the body read by the handler, recorded if the request fails
+	var captured *capturingBody
+	if c := cfg.FailedRequestBodyCapture; !minimalBuild && c != nil && r.Body != nil && r.Body != http.NoBody && c.captures(r) {
+		captured = &capturingBody{ReadCloser: r.Body, limit: c.limit}
+		r.Body = captured
+	}
+
+	// Call next handler, watching for clients going away in the meantime
+	if m.slowest != nil || cfg.TimingAttributes || cfg.LatencyFlags != nil || cfg.OverheadMetric {
+		timings.handlerStart = time.Now()
+	}
+	cancellation := watchCancellation(r.Context())
+	if rejected {
+		rejectBodyMismatch(wrapped)
+	} else {
+		next.ServeHTTP(wrapped, r)
+	}
+	if cfg.TimingAttributes || cfg.OverheadMetric {
+		timings.handlerEnd = time.Now()
+	}
+	disconnected := cancellation.finish(span)
+
+	// The route is known once the request went through fuego's ServeMux
+	if route := routePattern(r); route != "" {
+		span.SetAttributes(semconv.HTTPRouteKey.String(route))
+		span.SetAttributes(cfg.RouteAttributes.lookup(r)...)
+		if cfg.PathParams {
+			span.SetAttributes(appendPathParamAttributes(nil, r, cfg.RedactedPathParams, cfg.MaxAttributeValueLength)...)
+		}
+
+		if !hasOp {
+			op, hasOp = cfg.OpenAPIOperations.lookup(r.Method, route)
+			if hasOp && op.OperationID != "" && !namedByHeader {
+				spanName, renameOnRoute = op.OperationID, false
+				span.SetName(spanName)
 			}
+		}
+		if renameOnRoute {
+			spanName = routeSpanName(r.Method, route)
+			span.SetName(spanName)
+		}
+	}
+	if hasOp && op.OperationID != "" {
+		span.SetAttributes(openAPIOperationIDKey.String(op.OperationID))
+	}
+	if hasOp && cfg.OpenAPIMetadata {
+		span.SetAttributes(op.metadataAttributes(cfg.MaxAttributeValueLength)...)
+	}
+
+	if !minimalBuild {
+		recordSerializeSpan(ctx, state)
+	}
 
-			// Update request context with span context
-			r = r.WithContext(ctx)
+	// Set span status based on HTTP status code, or the errors of a GraphQL response
+	graphQLErrors := 0
+	if gql != nil {
+		graphQLErrors = graphQLErrorCount(wrapped.captured)
+	}
+	failed := wrapped.statusCode >= 400
+	switch {
+	case disconnected && cfg.ClientDisconnectStatus != "":
+		failed = true
+		span.SetStatus(codes.Error, cfg.ClientDisconnectStatus)
+	case wrapped.statusCode >= 500, failed && cfg.LegacySpanStatus:
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", wrapped.statusCode))
+	case failed:
+		// The status of client errors is left unset, as the server did not fail
+	case graphQLErrors > 0:
+		failed = true
+		span.SetAttributes(graphQLErrorsKey.Int(graphQLErrors))
+		span.SetStatus(codes.Error, "GraphQL errors")
+	case cfg.LegacySpanStatus:
+		span.SetStatus(codes.Ok, "")
+	}
 
-			// Call next handler
-			next.ServeHTTP(wrapped, r)
+	if isRedirect(wrapped.statusCode) {
+		span.AddEvent("redirect", redirectEventOptions(wrapped.Header().Get("Location"), cfg.MaxAttributeValueLength)...)
+	}
 
-			// Set span status based on HTTP status code
-			if wrapped.statusCode >= 400 {
-				span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", wrapped.statusCode))
-			} else {
-				span.SetStatus(codes.Ok, "")
+	// Add request body size and response attributes
+	*attrs = (*attrs)[:0]
+	if r.ContentLength >= 0 {
+		*attrs = append(*attrs, semconv.HTTPRequestBodySizeKey.Int64(r.ContentLength))
+	} else if body != nil {
+		*attrs = append(*attrs, semconv.HTTPRequestBodySizeKey.Int64(body.n))
+	}
+	*attrs = append(*attrs, attribute.Int("http.response.status_code", wrapped.statusCode))
+	*attrs = wrapped.appendSizeAttributes(*attrs)
+	if cfg.ResponseThroughput != nil {
+		*attrs = wrapped.appendThroughputAttributes(*attrs, cfg.ResponseThroughput.minBytes, time.Now())
+	}
+	if cfg.ContentNegotiation {
+		if accept := preferredMediaType(r.Header.Get("Accept")); accept != "" {
+			*attrs = append(*attrs, acceptKey.String(truncateValue(accept, cfg.MaxAttributeValueLength)))
+		}
+		if contentType := mediaType(wrapped.Header().Get("Content-Type")); contentType != "" {
+			*attrs = append(*attrs, responseContentTypeKey.String(contentType))
+		}
+	}
+	if wrapped.statusCode >= 500 && !state.errorRecorded {
+		*attrs = append(*attrs, semconv.ErrorTypeKey.String(strconv.Itoa(wrapped.statusCode)))
+	}
+	if failed && cfg.ErrorFingerprinter != nil {
+		errorType := state.errorType
+		if errorType == "" {
+			errorType = strconv.Itoa(wrapped.statusCode)
+		}
+		if fingerprint := cfg.ErrorFingerprinter(errorType, routePattern(r), wrapped.statusCode); fingerprint != "" {
+			*attrs = append(*attrs, errorFingerprintKey.String(fingerprint))
+		}
+	}
+	if captured != nil && len(captured.captured) > 0 && cfg.FailedRequestBodyCapture.recordsStatus(wrapped.statusCode, cfg.FailedRequestBodyStatuses) {
+		*attrs = append(*attrs, requestBodySnippetKey.String(bodySnippet(captured.captured, captured.limit)))
+	}
+	if wrapped.statusCode >= 500 && wrapped.errorCaptureLimit > 0 && len(wrapped.captured) > 0 {
+		*attrs = append(*attrs, responseBodySnippetKey.String(bodySnippet(wrapped.captured, wrapped.errorCaptureLimit)))
+	}
+	if !wrapped.firstByte.IsZero() {
+		ttfb := wrapped.firstByte.Sub(start)
+		*attrs = append(*attrs, timeToFirstByteKey.Float64(ttfb.Seconds()))
+		m.requestMetrics.recordTimeToFirstByte(ctx, r, wrapped.statusCode, ttfb)
+	}
+	if cfg.LatencyFlags != nil {
+		*attrs = cfg.LatencyFlags.appendAttributes(*attrs, routePattern(r), time.Since(timings.handlerStart), failed)
+	}
+	span.SetAttributes(m.semconv.apply(*attrs)...)
+
+	m.requestMetrics.recordDuration(ctx, r, wrapped.statusCode, time.Since(start))
+
+	if debug != nil {
+		m.debug.finish(debug, spanName, wrapped.statusCode, failed)
+	}
+
+	// Report missing semconv attributes in strict mode
+	if cfg.StrictSemconv != nil && m.semconv != SemconvOld {
+		if reader, ok := span.(attributeReader); ok {
+			if gaps := semconvGaps(reader.Attributes(), r, wrapped.statusCode, cfg.OmittedAttributes); len(gaps) > 0 {
+				cfg.StrictSemconv.Errorf("otelfuego: %s %s span is missing semconv attributes: %s",
+					r.Method, r.URL.Path, strings.Join(gaps, ", "))
 			}
+		}
+	}
 
-			// Add response attributes
-			span.SetAttributes(
-				attribute.Int("http.response.status_code", wrapped.statusCode),
-				attribute.Int("http.response.body.size", wrapped.bytesWritten),
-			)
+	if m.slowest != nil {
+		// Raw paths and methods are unbounded: unmatched requests share an entry per known method
+		route := routePattern(r)
+		if route == "" {
+			route = "(unmatched)"
+		}
+		m.slowest.record(metricMethod(r.Method)+" "+route, SlowRequest{
+			TraceID:  span.SpanContext().TraceID().String(),
+			Duration: time.Since(timings.handlerStart),
+			Status:   wrapped.statusCode,
+			Time:     timings.handlerStart,
 		})
 	}
+
+	// Record the time spent in the middleware around the next handler last, so it is all accounted for
+	if cfg.TimingAttributes {
+		span.SetAttributes(timings.attributes(time.Now())...)
+	}
+}
+
+// serveUntraced serves an unsampled request, whose context only carries the span context to propagate
+func serveUntraced(w http.ResponseWriter, r *http.Request, next http.Handler, rejected bool) {
+	if rejected {
+		rejectBodyMismatch(w)
+		return
+	}
+	next.ServeHTTP(w, r)
 }
 
 // FuegoMiddleware is a convenience function that returns a Fuego-compatible middleware
@@ -118,18 +686,116 @@ func FuegoMiddleware(service string, opts ...Option) func(http.Handler) http.Han
 	return Middleware(service, opts...)
 }
 
+// maxPooledAttributes bounds the capacity of the attribute slices kept for reuse, so that a few
+// requests with many captured headers or parameters don't pin large slices in the pool
+const maxPooledAttributes = 128
+
+// attributesPool holds attribute slices reused across requests. Spans copy the attributes
+// passed to Start and SetAttributes, so a slice can be reused as soon as the call returned.
+var attributesPool = sync.Pool{
+	New: func() any {
+		attrs := make([]attribute.KeyValue, 0, 16)
+		return &attrs
+	},
+}
+
+func acquireAttributes() *[]attribute.KeyValue {
+	return attributesPool.Get().(*[]attribute.KeyValue)
+}
+
+func releaseAttributes(attrs *[]attribute.KeyValue) {
+	if cap(*attrs) > maxPooledAttributes {
+		return
+	}
+	clear(*attrs)
+	*attrs = (*attrs)[:0]
+	attributesPool.Put(attrs)
+}
+
+// responseWriterPool holds responseWriter wrappers reused across requests. A wrapper must not
+// be used once the handler it was passed to returned, as required by http.Handler.
+var responseWriterPool = sync.Pool{
+	New: func() any {
+		return new(responseWriter)
+	},
+}
+
+func acquireResponseWriter(w http.ResponseWriter) *responseWriter {
+	rw := responseWriterPool.Get().(*responseWriter)
+	rw.ResponseWriter = w
+	rw.statusCode = http.StatusOK // Default to 200
+	return rw
+}
+
+func releaseResponseWriter(rw *responseWriter) {
+	*rw = responseWriter{}
+	responseWriterPool.Put(rw)
+}
+
+// countingBody wraps a request body to count the bytes read by the handler
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code and response size
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode    int
 	bytesWritten  int
 	headerWritten bool
+
+	// firstByte is when the response header was written, i.e. the first byte of the response was sent
+	firstByte time.Time
+
+	// captured holds up to captureLimit bytes of the response body
+	captured     []byte
+	captureLimit int
+
+	// errorCaptureLimit raises captureLimit once a 5xx status is written
+	errorCaptureLimit int
+
+	// span records informational responses. It is only set for recording spans: unsampled requests get
+	// no wrapper unless request metrics are enabled, and wrappers serving metrics or access logs leave it nil
+	span trace.Span
+
+	// encoded is set when the body was already encoded when the header was written, and gzipped when it
+	// was encoded with gzip, whose trailer in the last bytes written, tail, holds the uncompressed size
+	encoded bool
+	gzipped bool
+	tail    [4]byte
+
+	// progressInterval is the interval of progress events recorded on span while the body is written
+	progressInterval time.Duration
+	lastProgress     time.Time
 }
 
 func (rw *responseWriter) WriteHeader(statusCode int) {
+	if isInformational(statusCode) {
+		// Informational responses precede the final response, so the status is not latched
+		if rw.span != nil {
+			recordInformational(rw.span, statusCode, rw.Header())
+		}
+		rw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
 	if !rw.headerWritten {
 		rw.statusCode = statusCode
 		rw.headerWritten = true
+		rw.firstByte = time.Now()
+		if encoding := rw.Header().Get("Content-Encoding"); encodedBody(encoding) {
+			rw.encoded = true
+			rw.gzipped = strings.EqualFold(encoding, "gzip")
+		}
+		if statusCode >= 500 {
+			rw.captureLimit = max(rw.captureLimit, rw.errorCaptureLimit)
+		}
 		rw.ResponseWriter.WriteHeader(statusCode)
 	}
 }
@@ -140,9 +806,36 @@ func (rw *responseWriter) Write(data []byte) (int, error) {
 	}
 	n, err := rw.ResponseWriter.Write(data)
 	rw.bytesWritten += n
+	if rw.gzipped {
+		appendTail(&rw.tail, data[:n])
+	}
+	if rw.progressInterval > 0 {
+		rw.recordProgress()
+	}
+	if remaining := rw.captureLimit - len(rw.captured); remaining > 0 {
+		rw.captured = append(rw.captured, data[:min(n, remaining)]...)
+	}
 	return n, err
 }
 
+// ReadFrom implements io.ReaderFrom, forwarding to the underlying ResponseWriter when it supports it
+// so that sendfile optimizations used by http.ServeContent and io.Copy keep working
+func (rw *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if !rw.headerWritten {
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	// Body capture, gzip trailers and progress events need to see the data, so only forward when none is needed
+	if rf, ok := rw.ResponseWriter.(io.ReaderFrom); ok && rw.captureLimit == 0 && !rw.gzipped && rw.progressInterval == 0 {
+		n, err := rf.ReadFrom(src)
+		rw.bytesWritten += int(n)
+		return n, err
+	}
+
+	// Hide ReadFrom from io.Copy to avoid calling back into this method
+	return io.Copy(struct{ io.Writer }{rw}, src)
+}
+
 // Flush implements http.Flusher if the underlying ResponseWriter supports it
 func (rw *responseWriter) Flush() {
 	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
@@ -150,6 +843,20 @@ func (rw *responseWriter) Flush() {
 	}
 }
 
+// Push implements http.Pusher if the underlying ResponseWriter supports it
+func (rw *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := rw.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying ResponseWriter, so that http.ResponseController can reach
+// features such as SetWriteDeadline and EnableFullDuplex
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Hijack implements http.Hijacker if the underlying ResponseWriter supports it
 func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if hijacker, ok := rw.ResponseWriter.(http.Hijacker); ok {
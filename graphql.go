@@ -0,0 +1,240 @@
+package otelfuego
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+)
+
+const (
+	graphQLErrorsKey = attribute.Key("graphql.errors.count")
+
+	// maxGraphQLBodyBytes bounds how much of GraphQL request and response bodies is parsed
+	maxGraphQLBodyBytes = 64 << 10
+
+	// maxGraphQLNameLength bounds the operation names used in span names; longer names are truncated,
+	// and longer operationName parameters are ignored
+	maxGraphQLNameLength = 128
+)
+
+// graphQLOperation describes the operation of a GraphQL-over-HTTP request
+type graphQLOperation struct {
+	Type string
+	Name string
+}
+
+// spanName returns the span name recommended by the GraphQL semantic conventions
+func (op graphQLOperation) spanName() string {
+	if op.Name == "" {
+		return op.Type
+	}
+	return op.Type + " " + op.Name
+}
+
+// attributes returns the operation attributes, with the name truncated to limit bytes
+func (op graphQLOperation) attributes(limit int) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.GraphqlOperationTypeKey.String(op.Type)}
+	if op.Name != "" {
+		attrs = append(attrs, semconv.GraphqlOperationNameKey.String(truncateValue(op.Name, limit)))
+	}
+	return attrs
+}
+
+// readGraphQLOperation extracts the GraphQL operation from the query string of GET requests or the
+// first maxGraphQLBodyBytes of a JSON POST body: the operation of the document selected by a valid
+// operationName parameter, or its first operation. The consumed body bytes are put back in front of
+// r.Body so the handler still reads the complete body.
+func readGraphQLOperation(r *http.Request) graphQLOperation {
+	var params struct {
+		Query         string `json:"query"`
+		OperationName string `json:"operationName"`
+	}
+
+	if r.Method == http.MethodGet {
+		params.Query = r.URL.Query().Get("query")
+		params.OperationName = r.URL.Query().Get("operationName")
+	} else if r.Body != nil && r.Body != http.NoBody {
+		prefix, err := io.ReadAll(io.LimitReader(r.Body, maxGraphQLBodyBytes))
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+		if err != nil || json.Unmarshal(prefix, &params) != nil {
+			return graphQLOperation{}
+		}
+	}
+
+	if params.Query == "" {
+		return graphQLOperation{}
+	}
+
+	// Clients choose the operation name freely: only well-formed, bounded names are trusted
+	if !validGraphQLName(params.OperationName) {
+		params.OperationName = ""
+	}
+	op := parseGraphQLOperation(params.Query, params.OperationName)
+	if params.OperationName != "" && op.Type != "" {
+		op.Name = params.OperationName
+	}
+	op.Name = truncateValue(op.Name, maxGraphQLNameLength)
+	return op
+}
+
+// parseGraphQLOperation returns the type and name of the operation of a GraphQL document named name,
+// or of its first operation when name is empty or names no operation. Selection sets defining no
+// operation are anonymous queries; fragments are skipped.
+func parseGraphQLOperation(query, name string) graphQLOperation {
+	var first graphQLOperation
+	found := false
+	depth := 0
+	pending := false // a definition keyword was read, and its selection set not reached yet
+	for {
+		if depth == 0 {
+			query = skipGraphQLIgnored(query)
+		}
+		if query == "" {
+			break
+		}
+		switch c := query[0]; {
+		case c == '"':
+			query = skipGraphQLString(query)
+			continue
+		case c == '#':
+			query = skipGraphQLIgnored(query)
+			continue
+		case c == '{' || c == '(' || c == '[':
+			if depth == 0 && c == '{' {
+				if !pending && !found {
+					first, found = graphQLOperation{Type: "query"}, true
+				}
+				pending = false
+			}
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && isGraphQLNameChar(c) && !pending:
+			word := graphQLName(query)
+			query = query[len(word):]
+			pending = true
+			switch word {
+			case "query", "mutation", "subscription":
+				op := graphQLOperation{Type: word, Name: graphQLName(skipGraphQLIgnored(query))}
+				if name != "" && op.Name == name {
+					return op
+				}
+				if !found {
+					first, found = op, true
+				}
+			}
+			continue
+		}
+		query = query[1:]
+	}
+	return first
+}
+
+// graphQLName returns the name s starts with, if any
+func graphQLName(s string) string {
+	end := 0
+	for end < len(s) && isGraphQLNameChar(s[end]) {
+		end++
+	}
+	return s[:end]
+}
+
+// validGraphQLName reports whether name is a GraphQL Name of up to maxGraphQLNameLength bytes
+func validGraphQLName(name string) bool {
+	if name == "" || len(name) > maxGraphQLNameLength || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	return graphQLName(name) == name
+}
+
+// skipGraphQLString skips the string or block string s starts with
+func skipGraphQLString(s string) string {
+	if rest, ok := strings.CutPrefix(s, `"""`); ok {
+		for i := 0; i+2 < len(rest); i++ {
+			if rest[i] == '\\' && strings.HasPrefix(rest[i+1:], `"""`) {
+				i += 3
+				continue
+			}
+			if strings.HasPrefix(rest[i:], `"""`) {
+				return rest[i+3:]
+			}
+		}
+		return ""
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"', '\n':
+			return s[i+1:]
+		}
+	}
+	return ""
+}
+
+// skipGraphQLIgnored skips leading whitespace, commas and comments
+func skipGraphQLIgnored(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n,\ufeff")
+		if !strings.HasPrefix(s, "#") {
+			return s
+		}
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			s = s[i+1:]
+		} else {
+			return ""
+		}
+	}
+}
+
+func isGraphQLNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// graphQLErrorCount returns the number of entries in the top-level "errors" array of a GraphQL
+// response. The body may be truncated; members after the truncation point are not inspected.
+func graphQLErrorCount(body []byte) int {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return 0
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return 0
+		}
+		if key != "errors" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return 0
+			}
+			continue
+		}
+
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return 0
+		}
+		count := 0
+		for dec.More() {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				// Truncated inside the array: at least count+1 errors were reported
+				return count + 1
+			}
+			count++
+		}
+		return count
+	}
+	return 0
+}
@@ -0,0 +1,173 @@
+//go:build !otelfuego_minimal
+
+package otelfuego_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithGraphQL(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithGraphQL("/graphql"),
+	)
+
+	tests := []struct {
+		name     string
+		request  *http.Request
+		response string
+		wantName string
+		wantType string
+		status   codes.Code
+	}{
+		{
+			name:     "named query",
+			request:  httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "# users\nquery GetUser($id: ID!) { user(id: $id) { name } }"}`)),
+			response: `{"data": {"user": {"name": "Ada"}}}`,
+			wantName: "query GetUser",
+			wantType: "query",
+			status:   codes.Unset,
+		},
+		{
+			name:     "mutation with errors",
+			request:  httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "mutation { deleteUser(id: 1) }", "operationName": "DeleteUser"}`)),
+			response: `{"data": null, "errors": [{"message": "forbidden"}]}`,
+			wantName: "mutation DeleteUser",
+			wantType: "mutation",
+			status:   codes.Error,
+		},
+		{
+			name:     "operation selected by name",
+			request:  httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "query A { a } fragment F on User { name } mutation B($s: String = \"{\") { b }", "operationName": "B"}`)),
+			response: `{"data": {"b": true}}`,
+			wantName: "mutation B",
+			wantType: "mutation",
+			status:   codes.Unset,
+		},
+		{
+			name:     "invalid operation name",
+			request:  httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "query GetUser { me { id } }", "operationName": "Get User\n<script>"}`)),
+			response: `{"data": {"me": {"id": "1"}}}`,
+			wantName: "query GetUser",
+			wantType: "query",
+			status:   codes.Unset,
+		},
+		{
+			name:     "oversized operation name",
+			request:  httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "query GetUser { me { id } }", "operationName": "`+strings.Repeat("A", 1000)+`"}`)),
+			response: `{"data": {"me": {"id": "1"}}}`,
+			wantName: "query GetUser",
+			wantType: "query",
+			status:   codes.Unset,
+		},
+		{
+			name:     "anonymous query over GET",
+			request:  httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape("{ me { id } }"), nil),
+			response: `{"data": {"me": {"id": "1"}}, "errors": []}`,
+			wantName: "query",
+			wantType: "query",
+			status:   codes.Unset,
+		},
+		{
+			name:     "other endpoint",
+			request:  httptest.NewRequest("POST", "/users", strings.NewReader(`{"query": "mutation { x }"}`)),
+			response: `{"errors": [{"message": "ignored"}]}`,
+			wantName: "POST",
+			status:   codes.Unset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			var body []byte
+			handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = io.ReadAll(r.Body)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+
+			var original string
+			if tt.request.Body != nil {
+				original, _ = readAndRestore(tt.request)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), tt.request)
+
+			if string(body) != original {
+				t.Errorf("Expected handler to read the complete body %q, got %q", original, body)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			if spans[0].Name != tt.wantName {
+				t.Errorf("Expected span name '%s', got '%s'", tt.wantName, spans[0].Name)
+			}
+			if spans[0].Status.Code != tt.status {
+				t.Errorf("Expected status %v, got %v", tt.status, spans[0].Status.Code)
+			}
+
+			attrs := attribute.NewSet(spans[0].Attributes...)
+			if v, _ := attrs.Value("graphql.operation.type"); v.AsString() != tt.wantType {
+				t.Errorf("Expected operation type '%s', got '%s'", tt.wantType, v.AsString())
+			}
+		})
+	}
+}
+
+// readAndRestore reads the body of r and replaces it with an identical reader
+func readAndRestore(r *http.Request) (string, error) {
+	data, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(strings.NewReader(string(data)))
+	return string(data), err
+}
+
+func TestMiddleware_WithGraphQL_LongOperationName(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithGraphQL("/graphql"),
+		otelfuego.WithMaxAttributeValueLength(16),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	name := strings.Repeat("A", 1000)
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "query `+name+` { me { id } }"}`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	span := exporter.GetSpans()[0]
+	if span.Name != "query "+name[:128] {
+		t.Errorf("Expected the operation name truncated to 128 bytes in the span name, got %d bytes", len(span.Name))
+	}
+	attrs := attribute.NewSet(span.Attributes...)
+	if v, _ := attrs.Value("graphql.operation.name"); v.AsString() != name[:16] {
+		t.Errorf("Expected graphql.operation.name truncated to 16 bytes, got '%s'", v.AsString())
+	}
+}
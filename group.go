@@ -0,0 +1,39 @@
+package otelfuego
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// routeGroupKey records the prefix of the fuego route group that served a request
+const routeGroupKey = attribute.Key("fuego.route.group")
+
+// GroupMiddleware returns a middleware for the routes of a fuego route group, configured with the
+// group's own options, such as filters, route samplers or captured headers, and recording prefix as the
+// fuego.route.group attribute, so e.g. /admin and /public can be instrumented differently. Register it
+// on each group instead of registering Middleware on the server: a request already instrumented by an
+// outer middleware only gets the group attribute added to the outer span, unless WithNestedMode says
+// otherwise.
+//
+// Example:
+//
+//	admin := fuego.Group(server, "/admin")
+//	fuego.Use(admin, otelfuego.GroupMiddleware("my-service", "/admin",
+//	    otelfuego.WithCapturedRequestHeaders("X-Admin-User"),
+//	))
+//	public := fuego.Group(server, "/public")
+//	fuego.Use(public, otelfuego.GroupMiddleware("my-service", "/public",
+//	    otelfuego.WithRouteSampler("/public/", sdktrace.TraceIDRatioBased(0.1)),
+//	))
+func GroupMiddleware(service, prefix string, opts ...Option) func(http.Handler) http.Handler {
+	return Middleware(service, append([]Option{WithRouteGroup(prefix)}, opts...)...)
+}
+
+// WithRouteGroup configures the middleware to record prefix as the fuego.route.group attribute of the
+// requests it instruments. See GroupMiddleware.
+func WithRouteGroup(prefix string) Option {
+	return optionFunc(func(c *config) {
+		c.RouteGroup = prefix
+	})
+}
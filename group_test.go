@@ -0,0 +1,74 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestGroupMiddleware(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// Route groups wrap the handlers registered on them with their middleware, like fuego.Group
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	admin := otelfuego.GroupMiddleware("test-service", "/admin",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithCapturedRequestHeaders("X-Admin-User"),
+	)
+	public := otelfuego.GroupMiddleware("test-service", "/public",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithFilter(otelfuego.PathSuffixFilter(".css")),
+	)
+	mux := http.NewServeMux()
+	mux.Handle("GET /admin/users", admin(noop))
+	mux.Handle("GET /public/docs", public(noop))
+	mux.Handle("GET /public/style.css", public(noop))
+
+	for _, path := range []string{"/admin/users", "/public/docs", "/public/style.css"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-Admin-User", "alice")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected the public filter to drop a span, got %d spans", len(spans))
+	}
+	for i, want := range []struct {
+		group       string
+		adminHeader bool
+	}{{"/admin", true}, {"/public", false}} {
+		attrs := attribute.NewSet(spans[i].Attributes...)
+		if v, _ := attrs.Value("fuego.route.group"); v.AsString() != want.group {
+			t.Errorf("Expected fuego.route.group %q, got %q", want.group, v.AsString())
+		}
+		if _, ok := attrs.Value("http.request.header.x-admin-user"); ok != want.adminHeader {
+			t.Errorf("Expected the admin header captured %v for %s, got %v", want.adminHeader, want.group, ok)
+		}
+	}
+
+	// Nested in a server-wide middleware, the group is recorded on the outer span
+	exporter.Reset()
+	handler := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))(mux)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/admin/users", nil))
+	spans = exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected a single span, got %d", len(spans))
+	}
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("fuego.route.group"); v.AsString() != "/admin" {
+		t.Errorf("Expected fuego.route.group on the outer span, got %q", v.AsString())
+	}
+}
@@ -0,0 +1,75 @@
+package otelfuego
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const requestHeaderKeyPrefix = "http.request.header."
+
+// capturedHeader is a request header recorded as an http.request.header.<name> attribute
+type capturedHeader struct {
+	name string
+	key  attribute.Key
+}
+
+func newCapturedHeader(name string) capturedHeader {
+	return capturedHeader{
+		name: http.CanonicalHeaderKey(name),
+		key:  attribute.Key(requestHeaderKeyPrefix + strings.ToLower(name)),
+	}
+}
+
+// InfrastructureHeaders are the request IDs set by CDNs and load balancers that WithInfrastructureHeaders
+// captures: Cloudflare, AWS load balancers, Azure Front Door and Fly.io
+var InfrastructureHeaders = []string{"CF-Ray", "X-Amzn-Trace-Id", "X-Azure-Ref", "Fly-Request-Id"}
+
+// appendCapturedHeaders appends the headers not captured yet to captured
+func appendCapturedHeaders(captured []capturedHeader, names ...string) []capturedHeader {
+next:
+	for _, name := range names {
+		h := newCapturedHeader(name)
+		for _, c := range captured {
+			if c.name == h.name {
+				continue next
+			}
+		}
+		captured = append(captured, h)
+	}
+	return captured
+}
+
+// appendHeaderAttributes appends an http.request.header.<name> attribute for each captured header
+// present on the request, with each value truncated to limit bytes
+func appendHeaderAttributes(attrs []attribute.KeyValue, headers []capturedHeader, r *http.Request, limit int) []attribute.KeyValue {
+	for _, h := range headers {
+		values := r.Header[h.name]
+		if len(values) == 0 {
+			continue
+		}
+		if limit > 0 {
+			values = truncateValues(values, limit)
+		}
+		attrs = append(attrs, h.key.StringSlice(values))
+	}
+	return attrs
+}
+
+// truncateValues returns values with each value truncated to limit bytes, copying values only when
+// one of them is too long
+func truncateValues(values []string, limit int) []string {
+	for i, v := range values {
+		if len(v) <= limit {
+			continue
+		}
+		truncated := make([]string, len(values))
+		copy(truncated, values[:i])
+		for j := i; j < len(values); j++ {
+			truncated[j] = truncateValue(values[j], limit)
+		}
+		return truncated
+	}
+	return values
+}
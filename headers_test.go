@@ -0,0 +1,54 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithInfrastructureHeaders(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// Headers listed twice are recorded once
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithCapturedRequestHeaders("cf-ray"),
+		otelfuego.WithInfrastructureHeaders(),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("CF-Ray", "8c1f2a3b4d5e6f70-AMS")
+	req.Header.Set("X-Amzn-Trace-Id", "Root=1-67891233-abcdef012345678912345678")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	span := exporter.GetSpans()[0]
+	var rays int
+	for _, kv := range span.Attributes {
+		if kv.Key == "http.request.header.cf-ray" {
+			rays++
+		}
+	}
+	if rays != 1 {
+		t.Errorf("Expected the CF-Ray header to be recorded once, got %d", rays)
+	}
+	attrs := attribute.NewSet(span.Attributes...)
+	if v, _ := attrs.Value("http.request.header.x-amzn-trace-id"); len(v.AsStringSlice()) != 1 ||
+		v.AsStringSlice()[0] != "Root=1-67891233-abcdef012345678912345678" {
+		t.Errorf("Expected the X-Amzn-Trace-Id header to be recorded, got %v", v.AsStringSlice())
+	}
+	if _, ok := attrs.Value("http.request.header.fly-request-id"); ok {
+		t.Error("Expected absent headers not to be recorded")
+	}
+}
@@ -0,0 +1,38 @@
+package otelfuego
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// elapsedKey records the time elapsed since the start of a request still in progress
+const elapsedKey = attribute.Key("otelfuego.elapsed_ms")
+
+// startHeartbeat adds an "in-progress" event to span every interval from start until the returned
+// function is called, which must happen before the span ends
+func startHeartbeat(span trace.Span, start time.Time, interval time.Duration) (stop func()) {
+	var mu sync.Mutex
+	var stopped bool
+	var timer *time.Timer
+
+	mu.Lock()
+	defer mu.Unlock()
+	timer = time.AfterFunc(interval, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		span.AddEvent("in-progress", trace.WithAttributes(elapsedKey.Float64(milliseconds(time.Since(start)))))
+		timer.Reset(interval)
+	})
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		timer.Stop()
+	}
+}
@@ -0,0 +1,59 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithLongRequestEvents(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithLongRequestEvents(10*time.Millisecond),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(35 * time.Millisecond)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	var elapsed []float64
+	for _, e := range exporter.GetSpans()[0].Events {
+		if e.Name == "in-progress" {
+			attrs := attribute.NewSet(e.Attributes...)
+			v, _ := attrs.Value("otelfuego.elapsed_ms")
+			elapsed = append(elapsed, v.AsFloat64())
+		}
+	}
+	if len(elapsed) < 2 {
+		t.Fatalf("Expected at least 2 in-progress events, got %v", elapsed)
+	}
+	for i, ms := range elapsed {
+		if ms < float64(10*(i+1)) {
+			t.Errorf("Expected event %d after at least %dms, got %vms", i, 10*(i+1), ms)
+		}
+	}
+
+	// Requests completing within the interval get no events, and none are added once the span ended
+	exporter.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+	time.Sleep(20 * time.Millisecond)
+	if events := exporter.GetSpans()[0].Events; len(events) != 0 {
+		t.Errorf("Expected no events for fast requests, got %v", events)
+	}
+}
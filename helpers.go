@@ -0,0 +1,39 @@
+package otelfuego
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanFromContext returns the server span created by the middleware for the request in ctx.
+// Outside of an instrumented request it returns a no-op span, so the result is always safe to use.
+//
+// Example:
+//
+//	func getUser(c fuego.ContextNoBody) (User, error) {
+//	    span := otelfuego.SpanFromContext(c.Context())
+//	    ...
+//	}
+func SpanFromContext(ctx context.Context) trace.Span {
+	return trace.SpanFromContext(ctx)
+}
+
+// SetAttributes sets attributes on the span of the request in ctx
+//
+// Example:
+//
+//	otelfuego.SetAttributes(c.Context(), attribute.String("user.plan", user.Plan))
+func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// AddEvent adds an event with the given name and attributes to the span of the request in ctx
+//
+// Example:
+//
+//	otelfuego.AddEvent(c.Context(), "cache.miss", attribute.String("cache.key", key))
+func AddEvent(ctx context.Context, name string, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,56 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHandlerHelpers(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+	)
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !otelfuego.SpanFromContext(r.Context()).IsRecording() {
+			t.Error("Expected recording span in request context")
+		}
+		otelfuego.SetAttributes(r.Context(), attribute.String("user.plan", "pro"))
+		otelfuego.AddEvent(r.Context(), "cache.miss", attribute.String("cache.key", "user:42"))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("user.plan"); v.AsString() != "pro" {
+		t.Errorf("Expected user.plan 'pro', got '%s'", v.AsString())
+	}
+
+	if len(spans[0].Events) != 1 || spans[0].Events[0].Name != "cache.miss" {
+		t.Fatalf("Expected a single 'cache.miss' event, got %v", spans[0].Events)
+	}
+
+	// Helpers must be safe to call outside of an instrumented request
+	otelfuego.SetAttributes(context.Background(), attribute.String("ignored", "value"))
+	otelfuego.AddEvent(context.Background(), "ignored")
+}
@@ -0,0 +1,51 @@
+package otelfuego
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// linkHeaderKey records the links hinted by 103 Early Hints responses
+const linkHeaderKey = attribute.Key("http.response.header.link")
+
+// isInformational reports whether status is a 1xx informational response, which precedes the final
+// response and may be sent several times. 101 Switching Protocols is final.
+func isInformational(status int) bool {
+	return status >= 100 && status < 200 && status != http.StatusSwitchingProtocols
+}
+
+// recordInformational records an informational response written with header as a span event:
+// "http.continue" for 100 Continue, "http.early_hints" with the hinted links for 103 Early Hints, and
+// "http.informational" otherwise
+func recordInformational(span trace.Span, status int, header http.Header) {
+	name := "http.informational"
+	attrs := []trace.EventOption{trace.WithAttributes(semconv.HTTPResponseStatusCode(status))}
+	switch status {
+	case http.StatusContinue:
+		name = "http.continue"
+	case http.StatusEarlyHints:
+		name = "http.early_hints"
+		if links := header.Values("Link"); len(links) > 0 {
+			attrs = append(attrs, trace.WithAttributes(linkHeaderKey.StringSlice(links)))
+		}
+	}
+	span.AddEvent(name, attrs...)
+}
+
+// WriteEarlyHints sends a 103 Early Hints response with the given Link header values, e.g.
+// "</style.css>; rel=preload; as=style", so the client can start fetching resources while the final
+// response is prepared. The middleware records it as an "http.early_hints" span event. The links are
+// sent again with the final response unless removed from w.Header().
+//
+// Example:
+//
+//	otelfuego.WriteEarlyHints(w, "</app.js>; rel=preload; as=script")
+func WriteEarlyHints(w http.ResponseWriter, links ...string) {
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+}
@@ -0,0 +1,161 @@
+package otelfuego
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSlowestRequests is the number of slowest requests kept per route
+	defaultSlowestRequests = 5
+
+	// slowestRequestsWindow is how long a request is kept among the slowest requests of its route
+	slowestRequestsWindow = 15 * time.Minute
+
+	// maxSlowestRoutes bounds the number of routes tracked by the slowest requests store
+	maxSlowestRoutes = 256
+)
+
+// Instrumentation is a middleware that also exposes what it observed about the requests it served.
+// Use Middleware when only the spans are needed.
+//
+// Example:
+//
+//	inst := otelfuego.New("my-service")
+//	server.Use(inst.Middleware())
+//	server.Mux.Handle("/debug/otelfuego", inst.DebugHandler())
+type Instrumentation struct {
+	m       *middleware
+	slowest *slowestRequests
+}
+
+// New returns an Instrumentation for service configured with opts. Invalid options are reported like
+// Middleware does.
+func New(service string, opts ...Option) *Instrumentation {
+	cfg := newConfig(opts...)
+	if err := cfg.validate(); err != nil {
+		cfg.handleError(err)
+	}
+	m := newMiddleware(service, cfg)
+	if !minimalBuild && m.cfg.SlowestRequests > 0 {
+		m.slowest = &slowestRequests{limit: m.cfg.SlowestRequests}
+	}
+	if !minimalBuild && m.cfg.DebugSpans {
+		m.debug = newDebugSpans()
+	}
+	return &Instrumentation{m: m, slowest: m.slowest}
+}
+
+// Middleware returns the middleware instrumenting HTTP requests, like the package-level Middleware
+func (i *Instrumentation) Middleware() func(http.Handler) http.Handler {
+	return i.m.handler
+}
+
+// SlowRequest describes one of the slowest recent requests of a route
+type SlowRequest struct {
+	TraceID  string        `json:"trace_id"`
+	Duration time.Duration `json:"duration_ns"`
+	Status   int           `json:"status"`
+	Time     time.Time     `json:"time"`
+}
+
+// SlowestRequests returns the slowest sampled requests of the last 15 minutes, slowest first, keyed
+// by "METHOD route", or "METHOD (unmatched)" for requests matching no route. The number of requests
+// kept per route is set by WithSlowestRequests.
+func (i *Instrumentation) SlowestRequests() map[string][]SlowRequest {
+	if i.slowest == nil {
+		return map[string][]SlowRequest{}
+	}
+	return i.slowest.snapshot(time.Now())
+}
+
+// DebugHandler returns a zpages-style handler serving what the instrumentation observed, giving on-call
+// engineers pointers to representative traces: the slowest requests per route and, WithDebugSpans, the
+// in-flight and recent request spans. It serves JSON, or HTML to browsers and with ?format=html. It
+// should not be exposed publicly.
+func (i *Instrumentation) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := debugPage{SlowestRequests: i.SlowestRequests()}
+		if debug := i.m.debug; debug != nil {
+			page.InFlight, page.Recent = debug.snapshot(time.Now())
+			page.Spans = true
+		}
+		page.serve(w, r)
+	})
+}
+
+// slowestRequests is a bounded store of the slowest recent requests per route
+type slowestRequests struct {
+	limit int
+
+	mu     sync.Mutex
+	routes map[string][]SlowRequest
+}
+
+// record adds req to the slowest requests of route if it is among the slowest ones
+func (s *slowestRequests) record(route string, req SlowRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.routes == nil {
+		s.routes = make(map[string][]SlowRequest)
+	}
+	requests, ok := s.routes[route]
+	if !ok && len(s.routes) >= maxSlowestRoutes {
+		s.expire(req.Time)
+		if len(s.routes) >= maxSlowestRoutes {
+			return
+		}
+	}
+
+	requests = expireSlowRequests(requests, req.Time)
+	if len(requests) == s.limit {
+		if req.Duration <= requests[len(requests)-1].Duration {
+			s.routes[route] = requests
+			return
+		}
+		requests = requests[:len(requests)-1]
+	}
+	i := sort.Search(len(requests), func(i int) bool { return requests[i].Duration < req.Duration })
+	requests = append(requests, SlowRequest{})
+	copy(requests[i+1:], requests[i:])
+	requests[i] = req
+	s.routes[route] = requests
+}
+
+// snapshot returns a copy of the unexpired requests per route
+func (s *slowestRequests) snapshot(now time.Time) map[string][]SlowRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now)
+	routes := make(map[string][]SlowRequest, len(s.routes))
+	for route, requests := range s.routes {
+		routes[route] = append([]SlowRequest(nil), requests...)
+	}
+	return routes
+}
+
+// expire removes the expired requests, and the routes left without requests. s.mu must be held.
+func (s *slowestRequests) expire(now time.Time) {
+	for route, requests := range s.routes {
+		if requests = expireSlowRequests(requests, now); len(requests) > 0 {
+			s.routes[route] = requests
+		} else {
+			delete(s.routes, route)
+		}
+	}
+}
+
+// expireSlowRequests removes the requests older than the slowest requests window, keeping the order
+func expireSlowRequests(requests []SlowRequest, now time.Time) []SlowRequest {
+	kept := requests[:0]
+	for _, req := range requests {
+		if now.Sub(req.Time) < slowestRequestsWindow {
+			kept = append(kept, req)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,78 @@
+//go:build !otelfuego_minimal
+
+package otelfuego_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrumentation_SlowestRequests(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	inst := otelfuego.New("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithSlowestRequests(2),
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		delay, _ := time.ParseDuration(r.URL.Query().Get("delay"))
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := inst.Middleware()(mux)
+
+	traceIDs := make(map[string]string)
+	for _, delay := range []string{"1ms", "30ms", "15ms"} {
+		exporter.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/"+delay+"?delay="+delay, nil))
+		traceIDs[delay] = exporter.GetSpans()[0].SpanContext.TraceID().String()
+	}
+
+	slowest := inst.SlowestRequests()["GET /users/{id}"]
+	if len(slowest) != 2 {
+		t.Fatalf("Expected 2 slowest requests, got %d", len(slowest))
+	}
+	if slowest[0].TraceID != traceIDs["30ms"] || slowest[1].TraceID != traceIDs["15ms"] {
+		t.Errorf("Expected the 30ms and 15ms requests slowest first, got %+v", slowest)
+	}
+	if slowest[0].Duration < 30*time.Millisecond || slowest[0].Status != http.StatusOK {
+		t.Errorf("Expected a 200 of at least 30ms, got %+v", slowest[0])
+	}
+
+	// Unmatched requests share an entry rather than one per path
+	for _, path := range []string{"/wp-login.php", "/.env"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if unmatched := inst.SlowestRequests()["GET (unmatched)"]; len(unmatched) != 2 || unmatched[0].Status != http.StatusNotFound {
+		t.Errorf("Expected the 2 unmatched requests under GET (unmatched), got %+v", inst.SlowestRequests())
+	}
+
+	w := httptest.NewRecorder()
+	inst.DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/otelfuego", nil))
+
+	var debug struct {
+		SlowestRequests map[string][]otelfuego.SlowRequest `json:"slowest_requests"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &debug); err != nil {
+		t.Fatalf("Failed to decode debug response: %v", err)
+	}
+	if got := debug.SlowestRequests["GET /users/{id}"]; len(got) != 2 || got[0].TraceID != traceIDs["30ms"] {
+		t.Errorf("Expected debug endpoint to serve the slowest requests, got %+v", got)
+	}
+}
@@ -0,0 +1,17 @@
+// Package signals gives the signal-specific packages of otelfuego, such as otelfuegometrics, access to
+// the middleware internals, so they share its options without widening its API. Package otelfuego sets
+// the functions when initialized.
+package signals
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// MetricsMiddleware returns the request metrics middleware configured with opts, which are
+// otelfuego.Option values
+var MetricsMiddleware func(opts ...any) func(http.Handler) http.Handler
+
+// AccessLogMiddleware returns the access log middleware writing to logger, configured with opts, which
+// are otelfuego.Option values
+var AccessLogMiddleware func(logger *slog.Logger, opts ...any) func(http.Handler) http.Handler
@@ -0,0 +1,40 @@
+package otelfuego
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	latencyBucketKey = attribute.Key("latency.bucket")
+	errorFlagKey     = attribute.Key("error")
+)
+
+// latencyFlags holds the thresholds above which requests are flagged as slow
+type latencyFlags struct {
+	slow   time.Duration
+	routes map[string]time.Duration
+}
+
+// threshold returns the slow threshold of route
+func (f *latencyFlags) threshold(route string) time.Duration {
+	if threshold, ok := f.routes[route]; ok {
+		return threshold
+	}
+	return f.slow
+}
+
+// appendAttributes appends the latency.bucket attribute of a request to route that took elapsed,
+// and error=true if it failed
+func (f *latencyFlags) appendAttributes(attrs []attribute.KeyValue, route string, elapsed time.Duration, failed bool) []attribute.KeyValue {
+	bucket := "normal"
+	if elapsed >= f.threshold(route) {
+		bucket = "slow"
+	}
+	attrs = append(attrs, latencyBucketKey.String(bucket))
+	if failed {
+		attrs = append(attrs, errorFlagKey.Bool(true))
+	}
+	return attrs
+}
@@ -0,0 +1,69 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithLatencyFlags(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithLatencyFlags(10*time.Millisecond, map[string]time.Duration{
+			"/reports/{id}": time.Second,
+		}),
+	)(mux)
+
+	tests := []struct {
+		path       string
+		wantBucket string
+		wantError  bool
+	}{
+		{path: "/users/1", wantBucket: "slow"},
+		{path: "/reports/1", wantBucket: "normal"},
+		{path: "/orders/1", wantBucket: "normal", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			exporter.Reset()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+
+			attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+			if v, _ := attrs.Value("latency.bucket"); v.AsString() != tt.wantBucket {
+				t.Errorf("Expected latency.bucket '%s', got '%s'", tt.wantBucket, v.AsString())
+			}
+			if v, _ := attrs.Value("error"); v.AsBool() != tt.wantError {
+				t.Errorf("Expected error %v, got %v", tt.wantError, v.AsBool())
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package otelfuego
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+)
+
+// flusher is implemented by SDK tracer and meter providers
+type flusher interface {
+	ForceFlush(ctx context.Context) error
+}
+
+// shutdowner is implemented by SDK tracer and meter providers
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// providers returns the tracer and meter providers the instrumentation exports to: the configured
+// ones, or the global ones
+func (i *Instrumentation) providers() []any {
+	cfg := i.m.cfg
+	var providers []any
+	if cfg.TracerProvider != nil {
+		providers = append(providers, cfg.TracerProvider)
+	} else {
+		providers = append(providers, otel.GetTracerProvider())
+	}
+	for _, tp := range cfg.AdditionalTracerProviders {
+		providers = append(providers, tp)
+	}
+	if cfg.MeterProvider != nil {
+		providers = append(providers, cfg.MeterProvider)
+	} else {
+		providers = append(providers, otel.GetMeterProvider())
+	}
+	return providers
+}
+
+// ForceFlush exports the spans and metrics buffered by the providers the instrumentation uses, as set
+// with WithTracerProvider, WithAdditionalTracerProviders and WithMeterProvider, or the global ones.
+// Providers that do not buffer, such as no-op providers, are skipped.
+func (i *Instrumentation) ForceFlush(ctx context.Context) error {
+	var errs []error
+	for _, p := range i.providers() {
+		if f, ok := p.(flusher); ok {
+			errs = append(errs, f.ForceFlush(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown flushes and stops the providers the instrumentation uses, like ForceFlush, so buffered
+// spans and metrics are exported before the process exits. Call it once the server stopped serving
+// requests; spans of later requests are dropped.
+//
+// Example:
+//
+//	<-ctx.Done()
+//	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	_ = server.Shutdown(shutdownCtx)
+//	_ = inst.Shutdown(shutdownCtx)
+func (i *Instrumentation) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, p := range i.providers() {
+		if s, ok := p.(shutdowner); ok {
+			errs = append(errs, s.Shutdown(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
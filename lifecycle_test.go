@@ -0,0 +1,67 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// countingExporter counts the spans exported, including once shut down
+type countingExporter struct {
+	spans atomic.Int32
+}
+
+func (e *countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.spans.Add(int32(len(spans)))
+	return nil
+}
+
+func (e *countingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func TestInstrumentation_Shutdown(t *testing.T) {
+	// Batching span processor, which only exports when flushed
+	exporter := &countingExporter{}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
+
+	inst := otelfuego.New("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+	)
+	handler := inst.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+	if err := inst.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := exporter.spans.Load(); n != 1 {
+		t.Fatalf("Expected 1 span exported by ForceFlush, got %d", n)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/orders", nil))
+	if err := inst.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := exporter.spans.Load(); n != 2 {
+		t.Fatalf("Expected buffered spans to be exported by Shutdown, got %d", n)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+	_ = tp.ForceFlush(context.Background())
+	if n := exporter.spans.Load(); n != 2 {
+		t.Errorf("Expected spans of later requests to be dropped, got %d exported", n)
+	}
+}
@@ -0,0 +1,165 @@
+package otelfuego
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	logSeverityKey      = attribute.Key("log.severity")
+	logMessageKey       = attribute.Key("log.message")
+	logEventsDroppedKey = attribute.Key("otelfuego.log_events.dropped")
+
+	// defaultMaxLogEvents bounds the number of log events recorded per request
+	defaultMaxLogEvents = 32
+)
+
+// LogBridge returns a slog.Handler that records log records at WARN level and above as "log" events
+// on the span in ctx, so error context lands on the span even when logs and traces are shipped to
+// separate systems. All records are also passed to next, which may be nil, with the tenant.id
+// attribute added when the middleware found the tenant of the request (see WithTenantExtractor).
+//
+// The number of events recorded per request is bounded (see WithMaxLogEvents); records beyond the
+// limit are counted in the otelfuego.log_events.dropped span attribute.
+//
+// Example:
+//
+//	logger := slog.New(otelfuego.LogBridge(r.Context(), slog.Default().Handler()))
+//	logger.Warn("payment provider slow", "provider", "stripe")
+func LogBridge(ctx context.Context, next slog.Handler) slog.Handler {
+	limit := int64(defaultMaxLogEvents)
+	counter := new(atomic.Int64)
+	if state := requestStateFromContext(ctx); state != nil {
+		limit = int64(state.cfg.MaxLogEvents)
+		counter = &state.logEvents
+		if state.tenant != "" && next != nil {
+			next = next.WithAttrs([]slog.Attr{slog.String(string(tenantIDKey), state.tenant)})
+		}
+	}
+	return &logBridge{
+		span:    trace.SpanFromContext(ctx),
+		next:    next,
+		limit:   limit,
+		counter: counter,
+	}
+}
+
+// logBridge is the slog.Handler returned by LogBridge
+type logBridge struct {
+	span    trace.Span
+	next    slog.Handler
+	limit   int64
+	counter *atomic.Int64
+
+	// attrs and group hold the state accumulated through WithAttrs and WithGroup
+	attrs []attribute.KeyValue
+	group string
+}
+
+func (h *logBridge) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.records(level) {
+		return true
+	}
+	return h.next != nil && h.next.Enabled(ctx, level)
+}
+
+func (h *logBridge) Handle(ctx context.Context, record slog.Record) error {
+	if h.records(record.Level) {
+		h.recordEvent(record)
+	}
+	if h.next != nil && h.next.Enabled(ctx, record.Level) {
+		return h.next.Handle(ctx, record)
+	}
+	return nil
+}
+
+// records reports whether records at level are recorded as span events
+func (h *logBridge) records(level slog.Level) bool {
+	return !minimalBuild && level >= slog.LevelWarn && h.span.IsRecording()
+}
+
+func (h *logBridge) recordEvent(record slog.Record) {
+	if n := h.counter.Add(1); n > h.limit {
+		h.span.SetAttributes(logEventsDroppedKey.Int64(n - h.limit))
+		return
+	}
+
+	attrs := make([]attribute.KeyValue, 0, 2+len(h.attrs)+record.NumAttrs())
+	attrs = append(attrs,
+		logSeverityKey.String(record.Level.String()),
+		logMessageKey.String(record.Message),
+	)
+	attrs = append(attrs, h.attrs...)
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = appendSlogAttr(attrs, h.group, a)
+		return true
+	})
+
+	h.span.AddEvent("log", trace.WithTimestamp(record.Time), trace.WithAttributes(attrs...))
+}
+
+func (h *logBridge) WithAttrs(as []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make([]attribute.KeyValue, 0, len(h.attrs)+len(as))
+	clone.attrs = append(clone.attrs, h.attrs...)
+	for _, a := range as {
+		clone.attrs = appendSlogAttr(clone.attrs, h.group, a)
+	}
+	if h.next != nil {
+		clone.next = h.next.WithAttrs(as)
+	}
+	return &clone
+}
+
+func (h *logBridge) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.group = joinGroup(h.group, name)
+	if h.next != nil {
+		clone.next = h.next.WithGroup(name)
+	}
+	return &clone
+}
+
+// appendSlogAttr converts a slog attribute to span attributes, flattening groups into dotted keys
+func appendSlogAttr(attrs []attribute.KeyValue, group string, a slog.Attr) []attribute.KeyValue {
+	value := a.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		prefix := group
+		if a.Key != "" {
+			prefix = joinGroup(group, a.Key)
+		}
+		for _, ga := range value.Group() {
+			attrs = appendSlogAttr(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	if a.Key == "" {
+		return attrs
+	}
+
+	key := attribute.Key(joinGroup(group, a.Key))
+	switch value.Kind() {
+	case slog.KindBool:
+		return append(attrs, key.Bool(value.Bool()))
+	case slog.KindInt64:
+		return append(attrs, key.Int64(value.Int64()))
+	case slog.KindFloat64:
+		return append(attrs, key.Float64(value.Float64()))
+	default:
+		return append(attrs, key.String(value.String()))
+	}
+}
+
+func joinGroup(group, name string) string {
+	if group == "" {
+		return name
+	}
+	return group + "." + name
+}
@@ -0,0 +1,78 @@
+//go:build !otelfuego_minimal
+
+package otelfuego_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestLogBridge(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMaxLogEvents(2),
+	)
+
+	var output bytes.Buffer
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := slog.New(otelfuego.LogBridge(r.Context(), slog.NewTextHandler(&output, nil)))
+		logger.Info("handling request")
+		logger.WithGroup("payment").Warn("provider slow", "provider", "stripe", "attempt", 2)
+		logger.Error("provider failed")
+		logger.Error("giving up")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/checkout", nil))
+
+	if lines := strings.Count(output.String(), "\n"); lines != 4 {
+		t.Errorf("Expected all 4 records to reach the next handler, got %d", lines)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	events := spans[0].Events
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 log events, got %d", len(events))
+	}
+
+	warn := attribute.NewSet(events[0].Attributes...)
+	if v, _ := warn.Value("log.severity"); v.AsString() != "WARN" {
+		t.Errorf("Expected severity 'WARN', got '%s'", v.AsString())
+	}
+	if v, _ := warn.Value("log.message"); v.AsString() != "provider slow" {
+		t.Errorf("Expected message 'provider slow', got '%s'", v.AsString())
+	}
+	if v, _ := warn.Value("payment.provider"); v.AsString() != "stripe" {
+		t.Errorf("Expected payment.provider 'stripe', got '%s'", v.AsString())
+	}
+	if v, _ := warn.Value("payment.attempt"); v.AsInt64() != 2 {
+		t.Errorf("Expected payment.attempt 2, got %d", v.AsInt64())
+	}
+
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("otelfuego.log_events.dropped"); v.AsInt64() != 1 {
+		t.Errorf("Expected 1 dropped log event, got %d", v.AsInt64())
+	}
+}
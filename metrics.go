@@ -0,0 +1,199 @@
+package otelfuego
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+)
+
+const (
+	timeToFirstByteKey = attribute.Key("http.server.time_to_first_byte")
+	statusClassKey     = attribute.Key("http.response.status_class")
+)
+
+// MetricAttribute selects an attribute of the request metrics, see WithMetricAttributes
+type MetricAttribute int
+
+const (
+	// MetricMethod is the request method, http.request.method, with unknown methods recorded as _OTHER
+	MetricMethod MetricAttribute = iota
+	// MetricRoute is the matched route, http.route
+	MetricRoute
+	// MetricStatusCode is the response status code, http.response.status_code, along with error.type
+	// for 5xx responses
+	MetricStatusCode
+	// MetricStatusClass is the class of the response status code, http.response.status_class, e.g. "5xx"
+	MetricStatusClass
+)
+
+// defaultMetricAttributes are the attributes of request metrics unless set with WithMetricAttributes
+var defaultMetricAttributes = []MetricAttribute{MetricMethod, MetricRoute, MetricStatusCode}
+
+// meterFor returns the meter of the provider set with WithMeterProvider, or of the global provider. Minimal
+// builds record no metrics and get a no-op meter.
+func meterFor(cfg *config) metric.Meter {
+	if minimalBuild {
+		return noop.Meter{}
+	}
+	meterProvider := cfg.MeterProvider
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	return meterProvider.Meter(cfg.ScopeName,
+		metric.WithInstrumentationVersion(cfg.ScopeVersion),
+		metric.WithInstrumentationAttributes(cfg.ScopeAttributes...),
+	)
+}
+
+// requestMetrics are the metrics the middleware reports about the requests it serves. Instruments are
+// nil unless enabled. Measurements are recorded with the context of the request span, so that the SDK
+// attaches the trace and span IDs of sampled requests as exemplars, linking latency spikes to traces.
+type requestMetrics struct {
+	requestDuration metric.Float64Histogram
+	timeToFirstByte metric.Float64Histogram
+	attributes      []MetricAttribute
+	routes          routeAttributes
+	static          []attribute.KeyValue
+
+	// tenants is set when measurements record the tenant found by extractTenant
+	tenants       *cardinalityGuard
+	extractTenant TenantExtractor
+}
+
+func newRequestMetrics(cfg *config) *requestMetrics {
+	meter := meterFor(cfg)
+	m := &requestMetrics{
+		attributes:    defaultMetricAttributes,
+		routes:        cfg.RouteAttributes,
+		static:        cfg.Attributes,
+		tenants:       newMetricTenants(cfg),
+		extractTenant: cfg.TenantExtractor,
+	}
+	if cfg.MetricAttributes != nil {
+		m.attributes = cfg.MetricAttributes
+	}
+	if minimalBuild {
+		return m
+	}
+	var err error
+	if cfg.RequestMetrics {
+		if m.requestDuration, err = meter.Float64Histogram("http.server.request.duration", durationHistogramOptions(cfg,
+			"Duration of HTTP server requests",
+		)...); err != nil {
+			otel.Handle(err)
+		}
+	}
+	if cfg.TimeToFirstByteMetric {
+		if m.timeToFirstByte, err = meter.Float64Histogram("http.server.time_to_first_byte", durationHistogramOptions(cfg,
+			"Duration from the start of the request span until the response header was written",
+		)...); err != nil {
+			otel.Handle(err)
+		}
+	}
+	return m
+}
+
+// enabled reports whether any request metric is recorded
+func (m *requestMetrics) enabled() bool {
+	return m.requestDuration != nil || m.timeToFirstByte != nil
+}
+
+// recordDuration records the duration of r, answered with status
+func (m *requestMetrics) recordDuration(ctx context.Context, r *http.Request, status int, d time.Duration) {
+	if m.requestDuration == nil {
+		return
+	}
+	m.requestDuration.Record(ctx, d.Seconds(), metric.WithAttributes(m.attributesOf(ctx, r, status)...))
+}
+
+// recordTimeToFirstByte records the time to first byte of r, answered with status
+func (m *requestMetrics) recordTimeToFirstByte(ctx context.Context, r *http.Request, status int, ttfb time.Duration) {
+	if m.timeToFirstByte == nil {
+		return
+	}
+	m.timeToFirstByte.Record(ctx, ttfb.Seconds(), metric.WithAttributes(m.attributesOf(ctx, r, status)...))
+}
+
+// attributesOf returns the attributes of the measurements of r, answered with status: the selected
+// request metric attributes, followed by those set for its route, its tenant if recorded and those set
+// WithAttributes
+func (m *requestMetrics) attributesOf(ctx context.Context, r *http.Request, status int) []attribute.KeyValue {
+	attrs := append(requestMetricAttributes(r, status, m.attributes), m.routes.lookup(r)...)
+	if m.tenants != nil {
+		if tenant := tenantOf(ctx, m.extractTenant, r); tenant != "" {
+			attrs = append(attrs, metricTenant(m.tenants, tenant))
+		}
+	}
+	return append(attrs, m.static...)
+}
+
+// requestMetricAttributes returns the selected low-cardinality attributes of request metrics for r,
+// answered with status
+func requestMetricAttributes(r *http.Request, status int, selected []MetricAttribute) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(selected)+1)
+	for _, a := range selected {
+		switch a {
+		case MetricMethod:
+			attrs = append(attrs, semconv.HTTPRequestMethodKey.String(metricMethod(r.Method)))
+		case MetricRoute:
+			if route := routePattern(r); route != "" {
+				attrs = append(attrs, semconv.HTTPRouteKey.String(route))
+			}
+		case MetricStatusCode:
+			attrs = append(attrs, semconv.HTTPResponseStatusCodeKey.Int(status))
+			if status >= 500 {
+				attrs = append(attrs, semconv.ErrorTypeKey.String(strconv.Itoa(status)))
+			}
+		case MetricStatusClass:
+			attrs = append(attrs, statusClassKey.String(strconv.Itoa(status/100)+"xx"))
+		}
+	}
+	return attrs
+}
+
+// metricMethod returns method, or _OTHER for methods not defined by RFC 9110 and RFC 5789, which
+// clients may choose freely
+func metricMethod(method string) string {
+	if spanNameMethod(method) == "HTTP" {
+		return "_OTHER"
+	}
+	return method
+}
+
+// durationHistogramOptions returns the options of a duration histogram in seconds, with the bucket
+// boundaries set with WithDurationHistogramBoundaries if any
+func durationHistogramOptions(cfg *config, description string) []metric.Float64HistogramOption {
+	opts := []metric.Float64HistogramOption{metric.WithDescription(description), metric.WithUnit("s")}
+	if len(cfg.DurationBoundaries) > 0 {
+		opts = append(opts, metric.WithExplicitBucketBoundaries(cfg.DurationBoundaries...))
+	}
+	return opts
+}
+
+// HistogramView returns a view for the meter provider setting the bucket boundaries of the named
+// histogram of this package, e.g. "otelfuego.overhead", taking precedence over
+// WithDurationHistogramBoundaries. It lets each duration metric use buckets of its own.
+//
+// Example:
+//
+//	provider := sdkmetric.NewMeterProvider(
+//	    sdkmetric.WithReader(reader),
+//	    sdkmetric.WithView(otelfuego.HistogramView("http.server.time_to_first_byte", 0.001, 0.005, 0.025, 0.1)),
+//	)
+func HistogramView(name string, boundaries ...float64) sdkmetric.View {
+	return sdkmetric.NewView(
+		sdkmetric.Instrument{Name: name, Scope: instrumentation.Scope{Name: instrumentationName}},
+		sdkmetric.Stream{Aggregation: sdkmetric.AggregationExplicitBucketHistogram{
+			Boundaries: append([]float64(nil), boundaries...),
+		}},
+	)
+}
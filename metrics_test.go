@@ -0,0 +1,387 @@
+//go:build !otelfuego_minimal
+
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// histogramPoints returns the data points of the histogram name collected by reader
+func histogramPoints(t *testing.T, reader sdkmetric.Reader, name string) []metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if h, ok := m.Data.(metricdata.Histogram[float64]); ok && m.Name == name {
+				return h.DataPoints
+			}
+		}
+	}
+	return nil
+}
+
+func TestMiddleware_TimeToFirstByte(t *testing.T) {
+	// Setup in-memory span exporter and metric reader for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithTimeToFirstByteMetric(),
+	)
+
+	// Streaming endpoint starting slowly, then taking even longer to finish
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("header\n"))
+		time.Sleep(40 * time.Millisecond)
+		_, _ = w.Write([]byte("rows\n"))
+	})
+	middleware(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports/7", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	v, ok := attrs.Value("http.server.time_to_first_byte")
+	if !ok || v.AsFloat64() < 0.02 || v.AsFloat64() >= 0.06 {
+		t.Errorf("Expected a time to first byte between 20ms and 60ms, got %v", v.AsFloat64())
+	}
+
+	points := histogramPoints(t, reader, "http.server.time_to_first_byte")
+	if len(points) != 1 || points[0].Count != 1 {
+		t.Fatalf("Expected 1 time to first byte measurement, got %+v", points)
+	}
+	if route, _ := points[0].Attributes.Value("http.route"); route.AsString() != "/reports/{id}" {
+		t.Errorf("Expected the measurement to carry the route, got '%s'", route.AsString())
+	}
+}
+
+func TestMiddleware_TimeToFirstByte_Unsampled(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	// Metrics cover all requests, not only sampled ones
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithTimeToFirstByteMetric(),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports/7", nil))
+
+	points := histogramPoints(t, reader, "http.server.time_to_first_byte")
+	if len(points) != 1 || points[0].Count != 1 {
+		t.Fatalf("Expected 1 time to first byte measurement of the unsampled request, got %+v", points)
+	}
+	if status, _ := points[0].Attributes.Value("http.response.status_code"); status.AsInt64() != 200 {
+		t.Errorf("Expected the measurement to carry the status code, got %d", status.AsInt64())
+	}
+}
+
+func TestMiddleware_DurationHistogramBoundaries(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// The view of the overhead histogram takes precedence over the option
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithView(otelfuego.HistogramView("otelfuego.overhead", 0.0001, 0.001)),
+	)
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithTimeToFirstByteMetric(),
+		otelfuego.WithOverheadMetric(),
+		otelfuego.WithDurationHistogramBoundaries([]float64{0.001, 0.005, 0.01}),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ping", nil))
+
+	for name, want := range map[string][]float64{
+		"http.server.time_to_first_byte": {0.001, 0.005, 0.01},
+		"otelfuego.overhead":             {0.0001, 0.001},
+	} {
+		points := histogramPoints(t, reader, name)
+		if len(points) != 1 {
+			t.Fatalf("Expected 1 %s data point, got %d", name, len(points))
+		}
+		if !slices.Equal(points[0].Bounds, want) {
+			t.Errorf("Expected %s bounds %v, got %v", name, want, points[0].Bounds)
+		}
+	}
+}
+
+func TestMiddleware_WithRequestMetrics(t *testing.T) {
+	// Every other request is sampled; both are measured
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.TraceIDRatioBased(0)))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+	sampledTP := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = sampledTP.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "0" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	for _, provider := range []*sdktrace.TracerProvider{tp, sampledTP} {
+		handler := otelfuego.Middleware("test-service",
+			otelfuego.WithTracerProvider(provider),
+			otelfuego.WithMeterProvider(mp),
+			otelfuego.WithRequestMetrics(),
+		)(mux)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/0", nil))
+	}
+
+	points := histogramPoints(t, reader, "http.server.request.duration")
+	counts := make(map[int64]uint64)
+	for _, p := range points {
+		if route, _ := p.Attributes.Value("http.route"); route.AsString() != "/users/{id}" {
+			t.Errorf("Expected the measurement to carry the route, got '%s'", route.AsString())
+		}
+		status, _ := p.Attributes.Value("http.response.status_code")
+		errorType, hasErrorType := p.Attributes.Value("error.type")
+		if hasErrorType != (status.AsInt64() >= 500) || (hasErrorType && errorType.AsString() != "500") {
+			t.Errorf("Unexpected error.type %q for status %d", errorType.AsString(), status.AsInt64())
+		}
+		counts[status.AsInt64()] += p.Count
+	}
+	if counts[200] != 2 || counts[500] != 2 {
+		t.Errorf("Expected 2 successful and 2 failed requests, got %v", counts)
+	}
+}
+
+func TestMiddleware_WithMetricAttributes(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithRequestMetrics(),
+		otelfuego.WithMetricAttributes(otelfuego.MetricMethod, otelfuego.MetricStatusClass),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/2", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("FOO", "/users/3", nil))
+
+	points := histogramPoints(t, reader, "http.server.request.duration")
+	counts := make(map[string]uint64)
+	for _, p := range points {
+		if p.Attributes.Len() != 2 {
+			t.Errorf("Expected only the method and status class, got %v", p.Attributes.ToSlice())
+		}
+		method, _ := p.Attributes.Value("http.request.method")
+		class, _ := p.Attributes.Value("http.response.status_class")
+		counts[method.AsString()+" "+class.AsString()] += p.Count
+	}
+	if counts["GET 4xx"] != 2 || counts["_OTHER 4xx"] != 1 {
+		t.Errorf("Expected 2 GET and 1 _OTHER 4xx requests, got %v", counts)
+	}
+}
+
+func TestMiddleware_Exemplars(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithRequestMetrics(),
+		otelfuego.WithTimeToFirstByteMetric(),
+		otelfuego.WithOverheadMetric(),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	traceID := spans[0].SpanContext.TraceID()
+
+	// Each histogram links its measurement to the trace of the request
+	for _, name := range []string{"http.server.request.duration", "http.server.time_to_first_byte", "otelfuego.overhead"} {
+		points := histogramPoints(t, reader, name)
+		if len(points) != 1 || len(points[0].Exemplars) != 1 {
+			t.Fatalf("Expected 1 %s exemplar, got %+v", name, points)
+		}
+		if got := points[0].Exemplars[0].TraceID; !slices.Equal(got, traceID[:]) {
+			t.Errorf("Expected the %s exemplar to carry trace %s, got %x", name, traceID, got)
+		}
+	}
+}
+
+func TestMiddleware_WithAttributes(t *testing.T) {
+	// Setup in-memory span exporter and metric reader for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	color := attribute.String("deployment.color", "blue")
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithRequestMetrics(),
+		otelfuego.WithAttributes(attribute.String("cloud.region", "eu-west-1"), color),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("cloud.region"); v.AsString() != "eu-west-1" {
+		t.Errorf("Expected cloud.region 'eu-west-1' on the span, got '%s'", v.AsString())
+	}
+
+	for _, p := range histogramPoints(t, reader, "http.server.request.duration") {
+		if v, _ := p.Attributes.Value("deployment.color"); v.AsString() != "blue" {
+			t.Errorf("Expected deployment.color 'blue' on request metrics, got %v", p.Attributes.ToSlice())
+		}
+	}
+	if started := sumCounter(t, reader, "otelfuego.spans.started", color); started != 1 {
+		t.Errorf("Expected 1 started span with deployment.color, got %d", started)
+	}
+}
+
+func TestMiddleware_WithMaxMetricTenants(t *testing.T) {
+	// Unsampled requests are measured too, without a span to take the tenant from
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithRequestMetrics(),
+		otelfuego.WithTenantExtractor(otelfuego.TenantFromHeader("X-Tenant-ID")),
+		otelfuego.WithMaxMetricTenants(2),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tenant := range []string{"acme", "globex", "initech", "acme", "umbrella", ""} {
+		req := httptest.NewRequest("GET", "/invoices", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	counts := make(map[string]uint64)
+	for _, p := range histogramPoints(t, reader, "http.server.request.duration") {
+		tenant, _ := p.Attributes.Value("tenant.id")
+		counts[tenant.AsString()] += p.Count
+	}
+	want := map[string]uint64{"acme": 2, "globex": 1, "_OTHER": 2, "": 1}
+	if len(counts) != len(want) {
+		t.Errorf("Expected tenants %v, got %v", want, counts)
+	}
+	for tenant, n := range want {
+		if counts[tenant] != n {
+			t.Errorf("Expected %d requests of tenant %q, got %d", n, tenant, counts[tenant])
+		}
+	}
+}
+
+func TestMiddleware_WithRouteAttributes_Metrics(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithRequestMetrics(),
+		otelfuego.WithRouteAttributes(map[string][]attribute.KeyValue{
+			"/users/{id}":        {attribute.String("team", "identity"), attribute.String("tier", "standard")},
+			"DELETE /users/{id}": {attribute.String("team", "identity"), attribute.String("tier", "critical")},
+		}),
+	)(mux)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/users/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	tiers := make(map[string]string)
+	for _, p := range histogramPoints(t, reader, "http.server.request.duration") {
+		method, _ := p.Attributes.Value("http.request.method")
+		route, _ := p.Attributes.Value("http.route")
+		tier, _ := p.Attributes.Value("tier")
+		tiers[method.AsString()+" "+route.AsString()] = tier.AsString()
+	}
+	if tiers["GET /users/{id}"] != "standard" || tiers["DELETE /users/{id}"] != "critical" || tiers["GET /health"] != "" {
+		t.Errorf("Expected route attributes on request metrics, got %v", tiers)
+	}
+}
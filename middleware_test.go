@@ -2,15 +2,23 @@ package otelfuego_test
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestMiddleware_BasicUsage(t *testing.T) {
@@ -52,8 +60,9 @@ func TestMiddleware_BasicUsage(t *testing.T) {
 	}
 
 	span := spans[0]
-	if span.Name != "GET /test" {
-		t.Errorf("Expected span name 'GET /test', got '%s'", span.Name)
+	// No route matched, so the span is named after the method only
+	if span.Name != "GET" {
+		t.Errorf("Expected span name 'GET', got '%s'", span.Name)
 	}
 }
 
@@ -103,6 +112,247 @@ func TestMiddleware_WithFilter(t *testing.T) {
 	}
 }
 
+func TestRemoteAddrFilter(t *testing.T) {
+	filter := otelfuego.RemoteAddrFilter("10.0.0.0/8", "192.168.1.7", "fd00::/8", "not-a-network")
+
+	tests := []struct {
+		remoteAddr string
+		traced     bool
+	}{
+		{"10.12.0.3:41234", false},
+		{"192.168.1.7:8080", false},
+		{"192.168.1.8:8080", true},
+		{"[fd00::1]:443", false},
+		{"[::ffff:10.0.0.1]:443", false},
+		{"203.0.113.9:51000", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if got := filter(req); got != tt.traced {
+			t.Errorf("RemoteAddr %q: expected traced=%v, got %v", tt.remoteAddr, tt.traced, got)
+		}
+	}
+}
+
+func TestUserAgentFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    otelfuego.Filter
+		userAgent string
+		traced    bool
+	}{
+		{"kubernetes probe", otelfuego.UserAgentFilter(), "kube-probe/1.29", false},
+		{"load balancer", otelfuego.UserAgentFilter(), "ELB-HealthChecker/2.0", false},
+		{"crawler ignoring case", otelfuego.UserAgentFilter(), "Mozilla/5.0 (compatible; googlebot/2.1)", false},
+		{"browser", otelfuego.UserAgentFilter(), "Mozilla/5.0 (X11; Linux x86_64)", true},
+		{"no user agent", otelfuego.UserAgentFilter(), "", true},
+		{"custom pattern", otelfuego.UserAgentFilter("Synthetics"), "Datadog/Synthetics", false},
+		{"custom patterns replace the defaults", otelfuego.UserAgentFilter("Synthetics"), "kube-probe/1.29", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("User-Agent", tt.userAgent)
+			if got := tt.filter(req); got != tt.traced {
+				t.Errorf("Expected traced=%v, got %v", tt.traced, got)
+			}
+		})
+	}
+}
+
+func TestSampledFilter(t *testing.T) {
+	filter := otelfuego.SampledFilter(otelfuego.HealthCheckFilter(), 0.01)
+
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		if filter(httptest.NewRequest("GET", "/healthz", nil)) {
+			kept++
+		}
+	}
+	if kept != 10 {
+		t.Errorf("Expected 10 of 1000 health checks to be kept, got %d", kept)
+	}
+
+	if !filter(httptest.NewRequest("GET", "/api/users", nil)) {
+		t.Error("Expected requests traced by the inner filter to be traced")
+	}
+}
+
+func TestMiddleware_WithEnabledFunc(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var enabled atomic.Bool
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithEnabledFunc(enabled.Load),
+	)
+
+	served := 0
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("Expected 0 spans while disabled, got %d", len(spans))
+	}
+
+	enabled.Store(true)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+	if spans := exporter.GetSpans(); len(spans) != 1 {
+		t.Errorf("Expected 1 span once enabled, got %d", len(spans))
+	}
+
+	if served != 2 {
+		t.Errorf("Expected both requests to be served, got %d", served)
+	}
+}
+
+func TestMiddleware_WithSpanKind(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	for _, kind := range []trace.SpanKind{trace.SpanKindServer, trace.SpanKindInternal} {
+		exporter.Reset()
+
+		handler := otelfuego.Middleware("test-service",
+			otelfuego.WithTracerProvider(tp),
+			otelfuego.WithSpanKind(kind),
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+
+		if got := exporter.GetSpans()[0].SpanKind; got != kind {
+			t.Errorf("Expected span kind %v, got %v", kind, got)
+		}
+	}
+}
+
+func TestMiddleware_SpanStatus(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tests := []struct {
+		status     int
+		legacy     bool
+		wantStatus codes.Code
+	}{
+		{http.StatusOK, false, codes.Unset},
+		{http.StatusNotFound, false, codes.Unset},
+		{http.StatusBadGateway, false, codes.Error},
+		{http.StatusOK, true, codes.Ok},
+		{http.StatusNotFound, true, codes.Error},
+		{http.StatusBadGateway, true, codes.Error},
+	}
+	for _, tt := range tests {
+		exporter.Reset()
+
+		opts := []otelfuego.Option{otelfuego.WithTracerProvider(tp)}
+		if tt.legacy {
+			opts = append(opts, otelfuego.WithLegacySpanStatus())
+		}
+		handler := otelfuego.Middleware("test-service", opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+
+		if got := exporter.GetSpans()[0].Status.Code; got != tt.wantStatus {
+			t.Errorf("Expected status %v for %d (legacy %v), got %v", tt.wantStatus, tt.status, tt.legacy, got)
+		}
+	}
+}
+
+func TestMiddleware_ServiceNameAttribute(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName("checkout"))),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tests := []struct {
+		name string
+		opts []otelfuego.Option
+		want string
+	}{
+		{"default", nil, ""},
+		{"WithServiceNameAttribute", []otelfuego.Option{otelfuego.WithServiceNameAttribute()}, "checkout"},
+	}
+	for _, tt := range tests {
+		exporter.Reset()
+
+		handler := otelfuego.Middleware("checkout", append(tt.opts, otelfuego.WithTracerProvider(tp))...)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+
+		span := exporter.GetSpans()[0]
+		attrs := attribute.NewSet(span.Attributes...)
+		if v, _ := attrs.Value("service.name"); v.AsString() != tt.want {
+			t.Errorf("%s: expected service.name attribute '%s', got '%s'", tt.name, tt.want, v.AsString())
+		}
+		if v, _ := span.Resource.Set().Value("service.name"); v.AsString() != "checkout" {
+			t.Errorf("%s: expected service.name resource attribute 'checkout', got '%s'", tt.name, v.AsString())
+		}
+	}
+}
+
+func TestMiddleware_WithServiceNameFunc(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("gateway",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithServiceNameFunc(func(req *http.Request) string {
+			return map[string]string{"billing.example.com": "billing"}[req.Host]
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for host, want := range map[string]string{"billing.example.com": "billing", "unknown.example.com": "gateway"} {
+		exporter.Reset()
+
+		req := httptest.NewRequest("GET", "/invoices", nil)
+		req.Host = host
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+		if v, _ := attrs.Value("service.name"); v.AsString() != want {
+			t.Errorf("Expected service.name '%s' for %s, got '%s'", want, host, v.AsString())
+		}
+	}
+}
+
 func TestMiddleware_WithCustomSpanNameFormatter(t *testing.T) {
 	// Setup in-memory span exporter for testing
 	exporter := tracetest.NewInMemoryExporter()
@@ -145,6 +395,53 @@ func TestMiddleware_WithCustomSpanNameFormatter(t *testing.T) {
 	}
 }
 
+func TestMiddleware_RouteSpanNames(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		opts   []otelfuego.Option
+		method string
+		path   string
+		want   string
+	}{
+		{name: "matched route", method: "GET", path: "/users/42", want: "GET /users/{id}"},
+		{name: "unknown route", method: "GET", path: "/some/random/path", want: "GET"},
+		{name: "unknown method", method: "PURGE", path: "/users/42", want: "HTTP /users/{id}"},
+		{name: "raw path", opts: []otelfuego.Option{otelfuego.WithRawPathSpanNames()}, method: "GET", path: "/users/42", want: "GET /users/42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup in-memory span exporter for testing
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(
+				sdktrace.WithSyncer(exporter),
+				sdktrace.WithSampler(sdktrace.AlwaysSample()),
+			)
+			defer func() { _ = tp.Shutdown(context.Background()) }()
+
+			middleware := otelfuego.Middleware("test-service", append(tt.opts, otelfuego.WithTracerProvider(tp))...)
+			middleware(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			if spans[0].Name != tt.want {
+				t.Errorf("Expected span name '%s', got '%s'", tt.want, spans[0].Name)
+			}
+			attrs := attribute.NewSet(spans[0].Attributes...)
+			if v, _ := attrs.Value("url.path"); v.AsString() != tt.path {
+				t.Errorf("Expected url.path '%s', got '%s'", tt.path, v.AsString())
+			}
+		})
+	}
+}
+
 func TestMiddleware_DistributedTracing(t *testing.T) {
 	// Setup in-memory span exporter for testing
 	exporter := tracetest.NewInMemoryExporter()
@@ -196,7 +493,7 @@ func TestMiddleware_DistributedTracing(t *testing.T) {
 	// Find child span (should have parent trace ID)
 	var childSpan *tracetest.SpanStub
 	for _, span := range spans {
-		if span.Name == "GET /test" {
+		if span.Name == "GET" {
 			childSpan = &span
 			break
 		}
@@ -216,6 +513,226 @@ func TestMiddleware_DistributedTracing(t *testing.T) {
 	}
 }
 
+func TestMiddleware_WithConfigAttributes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	newHandler := func(opts ...otelfuego.Option) http.Handler {
+		opts = append(opts, otelfuego.WithTracerProvider(tp), otelfuego.WithConfigAttributes("edge"))
+		return otelfuego.Middleware("test-service", opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	scopeOf := func(handler http.Handler) attribute.Set {
+		exporter.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("Expected 1 span, got %d", len(spans))
+		}
+		return spans[0].InstrumentationScope.Attributes
+	}
+
+	plain := scopeOf(newHandler())
+	filtered := scopeOf(newHandler(otelfuego.WithFilter(otelfuego.HealthCheckFilter())))
+
+	if v, _ := plain.Value("otelfuego.config.profile"); v.AsString() != "edge" {
+		t.Errorf("Expected profile 'edge', got '%s'", v.AsString())
+	}
+
+	features, _ := filtered.Value("otelfuego.config.features")
+	if got := strings.Join(features.AsStringSlice(), ","); got != "filter,tracer_provider" {
+		t.Errorf("Expected features 'filter,tracer_provider', got '%s'", got)
+	}
+
+	plainHash, _ := plain.Value("otelfuego.config.hash")
+	filteredHash, _ := filtered.Value("otelfuego.config.hash")
+	if plainHash.AsString() == "" || plainHash.AsString() == filteredHash.AsString() {
+		t.Errorf("Expected distinct config hashes, got '%s' and '%s'", plainHash.AsString(), filteredHash.AsString())
+	}
+}
+
+func TestMiddleware_WithConfigAttributes_Features(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// Options changing the telemetry produced must change the published config
+	tests := []struct {
+		option  otelfuego.Option
+		feature string
+	}{
+		{otelfuego.WithMaxLogEvents(5), "max_log_events:5"},
+		{otelfuego.WithMaxAttributeValueLength(64), "max_attribute_value_length:64"},
+		{otelfuego.WithPathParamAttributes("token"), "redacted_path_params"},
+		{otelfuego.WithBodySniffing(true), "reject_body_mismatch"},
+		{otelfuego.WithStripIncomingState(func(*http.Request) bool { return true }, "session.id"), "allowed_baggage_keys"},
+	}
+	for _, tt := range tests {
+		exporter.Reset()
+		otelfuego.Middleware("test-service", tt.option, otelfuego.WithTracerProvider(tp), otelfuego.WithConfigAttributes(""))(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+		features, _ := exporter.GetSpans()[0].InstrumentationScope.Attributes.Value("otelfuego.config.features")
+		if !slices.Contains(features.AsStringSlice(), tt.feature) {
+			t.Errorf("Expected feature '%s', got %v", tt.feature, features.AsStringSlice())
+		}
+	}
+}
+
+func TestMiddleware_UnsampledRequest(t *testing.T) {
+	// Setup in-memory span exporter that never samples
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.NeverSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+	)
+
+	w := httptest.NewRecorder()
+	handler := middleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if rw != w {
+			t.Error("Expected unsampled request to receive the original ResponseWriter")
+		}
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			t.Error("Expected unsampled request to carry a valid span context")
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("Expected 0 spans, got %d", len(spans))
+	}
+}
+
+func TestMiddleware_WithInstrumentationScope(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithInstrumentationScope("acme.com/platform/http", "2.3.0", attribute.String("acme.team", "platform")),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+	scope := exporter.GetSpans()[0].InstrumentationScope
+	if scope.Name != "acme.com/platform/http" || scope.Version != "2.3.0" {
+		t.Errorf("Expected scope acme.com/platform/http 2.3.0, got %s %s", scope.Name, scope.Version)
+	}
+	if v, _ := scope.Attributes.Value("acme.team"); v.AsString() != "platform" {
+		t.Errorf("Expected scope attribute acme.team 'platform', got '%s'", v.AsString())
+	}
+}
+
+func TestMiddleware_WithLazyAttributes(t *testing.T) {
+	for _, sampled := range []bool{true, false} {
+		// Setup in-memory span exporter for testing
+		exporter := tracetest.NewInMemoryExporter()
+		sampler := sdktrace.NeverSample()
+		if sampled {
+			sampler = sdktrace.AlwaysSample()
+		}
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter), sdktrace.WithSampler(sampler))
+		defer func() { _ = tp.Shutdown(context.Background()) }()
+
+		var calls int
+		handler := otelfuego.Middleware("test-service",
+			otelfuego.WithTracerProvider(tp),
+			otelfuego.WithLazyAttributes(func(r *http.Request) []attribute.KeyValue {
+				calls++
+				return []attribute.KeyValue{attribute.String("account.plan", r.Header.Get("X-Plan"))}
+			}),
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Plan", "pro")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if !sampled {
+			if calls != 0 {
+				t.Errorf("Expected lazy attributes not to be computed for unsampled requests, got %d calls", calls)
+			}
+			continue
+		}
+		if calls != 1 {
+			t.Errorf("Expected lazy attributes to be computed once, got %d calls", calls)
+		}
+		attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+		if v, _ := attrs.Value("account.plan"); v.AsString() != "pro" {
+			t.Errorf("Expected account.plan 'pro', got '%s'", v.AsString())
+		}
+	}
+}
+
+func TestMiddleware_RequestBodySize(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRequestBodyCounting(),
+	)
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	tests := []struct {
+		name          string
+		contentLength int64
+		want          int64
+	}{
+		{name: "content length", contentLength: 11, want: 11},
+		{name: "chunked", contentLength: -1, want: 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			req := httptest.NewRequest("POST", "/upload", strings.NewReader("hello world"))
+			req.ContentLength = tt.contentLength
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			attrs := attribute.NewSet(spans[0].Attributes...)
+			if v, _ := attrs.Value("http.request.body.size"); v.AsInt64() != tt.want {
+				t.Errorf("Expected request body size %d, got %d", tt.want, v.AsInt64())
+			}
+		})
+	}
+}
+
 func ExampleMiddleware() {
 	// Basic usage with default configuration
 	middleware := otelfuego.Middleware("my-service")
@@ -0,0 +1,131 @@
+package otelfuego
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+)
+
+// multiTracerProvider fans out span creation to several providers. The first provider is the primary
+// one: its span contexts are the ones propagated and returned by SpanContext.
+type multiTracerProvider struct {
+	embedded.TracerProvider
+
+	providers []trace.TracerProvider
+}
+
+func (p *multiTracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	tracers := make([]trace.Tracer, len(p.providers))
+	for i, provider := range p.providers {
+		tracers[i] = provider.Tracer(name, opts...)
+	}
+	return &multiTracer{provider: p, tracers: tracers}
+}
+
+// multiTracer is the trace.Tracer of a multiTracerProvider
+type multiTracer struct {
+	embedded.Tracer
+
+	provider *multiTracerProvider
+	tracers  []trace.Tracer
+}
+
+// Start starts a span with each tracer. When the parent span in ctx was started by a multiTracer too,
+// each span is parented to the parent span of the same provider, keeping every provider's trace intact.
+func (t *multiTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent, _ := trace.SpanFromContext(ctx).(*multiSpan)
+
+	span := &multiSpan{provider: t.provider, spans: make([]trace.Span, len(t.tracers))}
+	primaryCtx := ctx
+	for i, tracer := range t.tracers {
+		spanCtx := ctx
+		if parent != nil && i < len(parent.spans) {
+			spanCtx = trace.ContextWithSpan(ctx, parent.spans[i])
+		}
+		spanCtx, span.spans[i] = tracer.Start(spanCtx, name, opts...)
+		if i == 0 {
+			primaryCtx = spanCtx
+		}
+	}
+	return trace.ContextWithSpan(primaryCtx, span), span
+}
+
+// multiSpan is the trace.Span of a multiTracer, forwarding every call to the span of each provider
+type multiSpan struct {
+	embedded.Span
+
+	provider *multiTracerProvider
+	spans    []trace.Span
+}
+
+func (s *multiSpan) End(opts ...trace.SpanEndOption) {
+	for _, span := range s.spans {
+		span.End(opts...)
+	}
+}
+
+func (s *multiSpan) AddEvent(name string, opts ...trace.EventOption) {
+	for _, span := range s.spans {
+		span.AddEvent(name, opts...)
+	}
+}
+
+func (s *multiSpan) AddLink(link trace.Link) {
+	for _, span := range s.spans {
+		span.AddLink(link)
+	}
+}
+
+func (s *multiSpan) IsRecording() bool {
+	for _, span := range s.spans {
+		if span.IsRecording() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *multiSpan) RecordError(err error, opts ...trace.EventOption) {
+	for _, span := range s.spans {
+		span.RecordError(err, opts...)
+	}
+}
+
+func (s *multiSpan) SpanContext() trace.SpanContext {
+	return s.spans[0].SpanContext()
+}
+
+func (s *multiSpan) SetStatus(code codes.Code, description string) {
+	for _, span := range s.spans {
+		span.SetStatus(code, description)
+	}
+}
+
+func (s *multiSpan) SetName(name string) {
+	for _, span := range s.spans {
+		span.SetName(name)
+	}
+}
+
+func (s *multiSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, span := range s.spans {
+		span.SetAttributes(kv...)
+	}
+}
+
+func (s *multiSpan) TracerProvider() trace.TracerProvider {
+	return s.provider
+}
+
+// Attributes returns the attributes of the first span exposing them, for WithStrictSemconv
+func (s *multiSpan) Attributes() []attribute.KeyValue {
+	for _, span := range s.spans {
+		if reader, ok := span.(attributeReader); ok {
+			return reader.Attributes()
+		}
+	}
+	return nil
+}
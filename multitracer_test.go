@@ -0,0 +1,63 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware_WithAdditionalTracerProviders(t *testing.T) {
+	// Setup one in-memory span exporter per provider
+	primary := tracetest.NewInMemoryExporter()
+	primaryTP := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(primary),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = primaryTP.Shutdown(context.Background()) }()
+
+	secondary := tracetest.NewInMemoryExporter()
+	secondaryTP := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(secondary),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = secondaryTP.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(primaryTP),
+		otelfuego.WithAdditionalTracerProviders(secondaryTP),
+	)
+
+	var requestSpan trace.SpanContext
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestSpan = trace.SpanContextFromContext(r.Context())
+		tracer := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("app")
+		_, child := tracer.Start(r.Context(), "load-user")
+		child.End()
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	for name, exporter := range map[string]*tracetest.InMemoryExporter{"primary": primary, "secondary": secondary} {
+		spans := exporter.GetSpans()
+		if len(spans) != 2 {
+			t.Fatalf("Expected 2 %s spans, got %d", name, len(spans))
+		}
+		child, server := spans[0], spans[1]
+		if child.Name != "load-user" || server.Name != "GET" {
+			t.Fatalf("Unexpected %s spans %q and %q", name, child.Name, server.Name)
+		}
+		if child.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("Expected %s child span to be parented to the %s server span", name, name)
+		}
+	}
+
+	if primary.GetSpans()[1].SpanContext.SpanID() != requestSpan.SpanID() {
+		t.Error("Expected the request context to carry the primary span context")
+	}
+}
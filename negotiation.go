@@ -0,0 +1,48 @@
+package otelfuego
+
+import (
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	acceptKey              = attribute.Key("http.request.accept")
+	responseContentTypeKey = attribute.Key("http.response.content_type")
+)
+
+// mediaType returns the lowercased media type of a Content-Type header value or Accept media range,
+// without its parameters, e.g. "text/html" for "text/html; charset=utf-8"
+func mediaType(value string) string {
+	if i := strings.IndexByte(value, ';'); i >= 0 {
+		value = value[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// preferredMediaType returns the media range of an Accept header value with the highest quality, the
+// first one on ties. Parameters are dropped to keep the attribute low-cardinality.
+func preferredMediaType(accept string) string {
+	var preferred string
+	best := -1.0
+	for _, part := range strings.Split(accept, ",") {
+		media := mediaType(part)
+		if media == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(part, ";")[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > best {
+			preferred, best = media, q
+		}
+	}
+	return preferred
+}
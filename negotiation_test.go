@@ -0,0 +1,66 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithContentNegotiationAttributes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// Serve HTML or JSON depending on the Accept header, like fuego's DataOrTemplate
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithContentNegotiationAttributes(),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "" {
+			return
+		}
+		if r.Header.Get("Accept") == "application/json" {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		accept          string
+		wantAccept      string
+		wantContentType string
+	}{
+		{"application/json", "application/json", "application/json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html", "text/html"},
+		{"application/json;q=0.5, Text/HTML;level=1", "text/html", "text/html"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		exporter.Reset()
+		req := httptest.NewRequest("GET", "/users", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+		if v, _ := attrs.Value("http.request.accept"); v.AsString() != tt.wantAccept {
+			t.Errorf("Expected http.request.accept %q for %q, got %q", tt.wantAccept, tt.accept, v.AsString())
+		}
+		if v, _ := attrs.Value("http.response.content_type"); v.AsString() != tt.wantContentType {
+			t.Errorf("Expected http.response.content_type %q for %q, got %q", tt.wantContentType, tt.accept, v.AsString())
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware_Nested(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tests := []struct {
+		name      string
+		opts      []otelfuego.Option
+		wantSpans int
+		wantKind  trace.SpanKind
+	}{
+		{name: "skip", wantSpans: 1},
+		{name: "internal", opts: []otelfuego.Option{otelfuego.WithNestedMode(otelfuego.NestedInternal)}, wantSpans: 2, wantKind: trace.SpanKindInternal},
+		{name: "server", opts: []otelfuego.Option{otelfuego.WithNestedMode(otelfuego.NestedServer)}, wantSpans: 2, wantKind: trace.SpanKindServer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			opts := append([]otelfuego.Option{otelfuego.WithTracerProvider(tp)}, tt.opts...)
+			global := otelfuego.Middleware("test-service", opts...)
+			group := otelfuego.Middleware("test-service", opts...)
+
+			served := false
+			handler := global(group(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served = true
+				w.WriteHeader(http.StatusOK)
+			})))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/admin/users", nil))
+
+			if !served {
+				t.Error("Expected request to be served")
+			}
+			spans := exporter.GetSpans()
+			if len(spans) != tt.wantSpans {
+				t.Fatalf("Expected %d spans, got %d", tt.wantSpans, len(spans))
+			}
+			if tt.wantSpans == 2 {
+				inner, outer := spans[0], spans[1]
+				if inner.SpanKind != tt.wantKind {
+					t.Errorf("Expected nested span kind %v, got %v", tt.wantKind, inner.SpanKind)
+				}
+				if inner.Parent.SpanID() != outer.SpanContext.SpanID() {
+					t.Error("Expected nested span to be a child of the outer server span")
+				}
+			}
+		})
+	}
+}
+
+func TestMiddleware_Nested_Unsampled(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.NeverSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// The nested middleware samples everything, so it would record a span if it took the request
+	// for an uninstrumented one
+	nestedTP := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = nestedTP.Shutdown(context.Background()) }()
+
+	global := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))
+	group := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(nestedTP))
+
+	served := false
+	handler := global(group(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/admin/users", nil))
+
+	if !served {
+		t.Error("Expected request to be served")
+	}
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("Expected no span for a request left unsampled by the outer middleware, got %d", len(spans))
+	}
+}
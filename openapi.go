@@ -0,0 +1,100 @@
+package otelfuego
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	openAPIOperationIDKey = attribute.Key("openapi.operation_id")
+	openAPITagsKey        = attribute.Key("openapi.tags")
+	openAPISummaryKey     = attribute.Key("openapi.summary")
+)
+
+// OpenAPIOperation holds the OpenAPI metadata of a single operation
+type OpenAPIOperation struct {
+	OperationID string
+	Tags        []string
+	Summary     string
+}
+
+// OpenAPIOperations maps "METHOD /path" route keys, e.g. "GET /users/{id}", to their OpenAPI operation
+type OpenAPIOperations map[string]OpenAPIOperation
+
+// ParseOpenAPI extracts the operations of a JSON OpenAPI document, such as the one generated by fuego.
+//
+// Example:
+//
+//	spec, _ := json.Marshal(server.OpenAPI.Description())
+//	ops, err := otelfuego.ParseOpenAPI(spec)
+func ParseOpenAPI(spec []byte) (OpenAPIOperations, error) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("otelfuego: parse OpenAPI document: %w", err)
+	}
+
+	ops := make(OpenAPIOperations)
+	for path, item := range doc.Paths {
+		for method, raw := range item {
+			if !isOpenAPIMethod(method) {
+				continue
+			}
+			var op struct {
+				OperationID string   `json:"operationId"`
+				Tags        []string `json:"tags"`
+				Summary     string   `json:"summary"`
+			}
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("otelfuego: parse OpenAPI operation %s %s: %w", method, path, err)
+			}
+			ops[operationKey(strings.ToUpper(method), path)] = OpenAPIOperation{
+				OperationID: op.OperationID,
+				Tags:        op.Tags,
+				Summary:     op.Summary,
+			}
+		}
+	}
+	return ops, nil
+}
+
+// metadataAttributes returns the tags and summary of op as attributes, the summary truncated to limit
+func (op OpenAPIOperation) metadataAttributes(limit int) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if len(op.Tags) > 0 {
+		attrs = append(attrs, openAPITagsKey.StringSlice(op.Tags))
+	}
+	if op.Summary != "" {
+		attrs = append(attrs, openAPISummaryKey.String(truncateValue(op.Summary, limit)))
+	}
+	return attrs
+}
+
+// lookup returns the operation matching the request method and route
+func (ops OpenAPIOperations) lookup(method, route string) (OpenAPIOperation, bool) {
+	if ops == nil || route == "" {
+		return OpenAPIOperation{}, false
+	}
+	op, ok := ops[operationKey(method, route)]
+	return op, ok
+}
+
+func operationKey(method, path string) string {
+	return method + " " + path
+}
+
+// isOpenAPIMethod reports whether key of a path item object is an HTTP method,
+// as opposed to fields like "parameters" or "summary"
+func isOpenAPIMethod(key string) bool {
+	switch strings.ToUpper(key) {
+	case http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+		http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace:
+		return true
+	}
+	return false
+}
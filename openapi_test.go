@@ -0,0 +1,160 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const testOpenAPISpec = `{
+	"openapi": "3.1.0",
+	"paths": {
+		"/users/{id}": {
+			"parameters": [{"name": "id", "in": "path"}],
+			"get": {"operationId": "GET_users_by_id", "tags": ["users", "accounts"], "summary": "Get a user"},
+			"delete": {"operationId": "deleteUser"}
+		},
+		"/users": {
+			"post": {"operationId": "createUser"}
+		}
+	}
+}`
+
+func TestParseOpenAPI(t *testing.T) {
+	ops, err := otelfuego.ParseOpenAPI([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(ops) != 3 {
+		t.Errorf("Expected 3 operations, got %d", len(ops))
+	}
+	if op := ops["DELETE /users/{id}"]; op.OperationID != "deleteUser" {
+		t.Errorf("Expected operationId 'deleteUser', got '%s'", op.OperationID)
+	}
+	if op := ops["GET /users/{id}"]; len(op.Tags) != 2 || op.Tags[0] != "users" || op.Summary != "Get a user" {
+		t.Errorf("Expected tags [users accounts] and summary 'Get a user', got %v and '%s'", op.Tags, op.Summary)
+	}
+
+	if _, err := otelfuego.ParseOpenAPI([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid document")
+	}
+}
+
+func TestMiddleware_WithOpenAPIOperations(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	ops, err := otelfuego.ParseOpenAPI([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithOpenAPIOperations(ops),
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("POST /users", middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		path    string
+		want    string
+		route   string
+	}{
+		{name: "global middleware", handler: middleware(mux), method: "GET", path: "/users/42", want: "GET_users_by_id", route: "/users/{id}"},
+		{name: "route middleware", handler: mux, method: "POST", path: "/users", want: "createUser", route: "/users"},
+		{name: "unknown route", handler: middleware(mux), method: "GET", path: "/unknown", want: "GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			tt.handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			if spans[0].Name != tt.want {
+				t.Errorf("Expected span name '%s', got '%s'", tt.want, spans[0].Name)
+			}
+
+			attrs := attribute.NewSet(spans[0].Attributes...)
+			if tt.route != "" {
+				if v, _ := attrs.Value("http.route"); v.AsString() != tt.route {
+					t.Errorf("Expected route '%s', got '%s'", tt.route, v.AsString())
+				}
+				if v, _ := attrs.Value("openapi.operation_id"); v.AsString() != tt.want {
+					t.Errorf("Expected operation id '%s', got '%s'", tt.want, v.AsString())
+				}
+			} else if attrs.HasValue("openapi.operation_id") {
+				t.Error("Expected no operation id for unknown route")
+			}
+		})
+	}
+}
+
+func TestMiddleware_WithOpenAPIMetadata(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	ops, err := otelfuego.ParseOpenAPI([]byte(testOpenAPISpec))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {})
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithOpenAPIOperations(ops),
+		otelfuego.WithOpenAPIMetadata(),
+	)(mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+
+	spans := exporter.GetSpans()
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("openapi.tags"); len(v.AsStringSlice()) != 2 || v.AsStringSlice()[1] != "accounts" {
+		t.Errorf("Expected tags [users accounts], got %v", v.AsStringSlice())
+	}
+	if v, _ := attrs.Value("openapi.summary"); v.AsString() != "Get a user" {
+		t.Errorf("Expected summary 'Get a user', got '%s'", v.AsString())
+	}
+
+	// Operations without tags or summary get no metadata attributes
+	attrs = attribute.NewSet(spans[1].Attributes...)
+	if attrs.HasValue("openapi.tags") || attrs.HasValue("openapi.summary") {
+		t.Error("Expected no metadata attributes for createUser")
+	}
+}
@@ -0,0 +1,35 @@
+// Package otelfuegolog writes structured access logs of the requests served, with the route, status and
+// duration fields of the spans and metrics of otelfuego. It takes the options of otelfuego, so filters
+// and route attributes are configured once for traces, metrics and logs. Records go to a slog.Logger,
+// whose handler may be the OpenTelemetry slog bridge to export them as OpenTelemetry logs.
+//
+// Example:
+//
+//	opts := []otelfuego.Option{otelfuego.WithFilter(otelfuego.HealthCheckFilter())}
+//	server.Use(otelfuego.Middleware("user-service", opts...))
+//	server.Use(otelfuegolog.Middleware(slog.Default(), opts...))
+package otelfuegolog
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/internal/signals"
+)
+
+// Middleware returns a middleware writing a record per request to logger, or to slog.Default() when nil:
+// at ERROR level for 5xx responses, WARN for 4xx and INFO otherwise. Records carry the method, route,
+// path, status, body size and duration of the request, its request ID (see otelfuego.WithRequestID), its
+// tenant (see otelfuego.WithTenantExtractor), the attributes of its route (see
+// otelfuego.WithRouteAttributes) and the deployment attributes (see otelfuego.WithAttributes).
+// Registered inside the tracing middleware, they also carry the trace_id and span_id of the request
+// span when it is sampled, and are logged with its context for bridges correlating logs with traces. Requests excluded by
+// filters are not logged.
+func Middleware(logger *slog.Logger, opts ...otelfuego.Option) func(http.Handler) http.Handler {
+	options := make([]any, len(opts))
+	for i, opt := range opts {
+		options[i] = opt
+	}
+	return signals.AccessLogMiddleware(logger, options...)
+}
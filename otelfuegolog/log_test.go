@@ -0,0 +1,80 @@
+package otelfuegolog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/otelfuegolog"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	opts := []otelfuego.Option{
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithFilter(otelfuego.HealthCheckFilter()),
+		otelfuego.WithRouteAttributes(map[string][]attribute.KeyValue{"/users/{id}": {attribute.String("team", "identity")}}),
+		otelfuego.WithTenantExtractor(otelfuego.TenantFromHeader("X-Tenant-ID")),
+	}
+	handler := otelfuego.Middleware("test-service", opts...)(otelfuegolog.Middleware(logger, opts...)(mux))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	if err := json.NewDecoder(&buf).Decode(&record); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected a single record, the health check being filtered, got more: %s", buf.String())
+	}
+
+	span := exporter.GetSpans()[0]
+	want := map[string]any{
+		"level":                     "WARN",
+		"msg":                       "http request",
+		"http.request.method":       "GET",
+		"http.route":                "/users/{id}",
+		"url.path":                  "/users/42",
+		"http.response.status_code": float64(404),
+		"http.response.body.size":   float64(9),
+		"trace_id":                  span.SpanContext.TraceID().String(),
+		"span_id":                   span.SpanContext.SpanID().String(),
+		"team":                      "identity",
+		"tenant.id":                 "acme",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, record[key])
+		}
+	}
+	if d, ok := record["http.server.request.duration"].(float64); !ok || d <= 0 {
+		t.Errorf("Expected a positive duration, got %v", record["http.server.request.duration"])
+	}
+}
@@ -0,0 +1,33 @@
+// Package otelfuegometrics records the request metrics of otelfuego without tracing, for services
+// adopting metrics first, or stacking metrics and tracing middleware independently. It takes the
+// options of otelfuego, so filters, route attributes and metric attributes are configured once for both.
+//
+// Example:
+//
+//	opts := []otelfuego.Option{
+//	    otelfuego.WithFilter(otelfuego.HealthCheckFilter()),
+//	    otelfuego.WithMeterProvider(mp),
+//	}
+//	server.Use(otelfuegometrics.Middleware(opts...))
+//	server.Use(otelfuego.Middleware("user-service", opts...))
+package otelfuegometrics
+
+import (
+	"net/http"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/internal/signals"
+)
+
+// Middleware returns a middleware recording the duration of requests as the http.server.request.duration
+// histogram, like otelfuego.WithRequestMetrics, and their time to first byte with
+// otelfuego.WithTimeToFirstByteMetric. Requests excluded by filters are not recorded. Options without
+// effect on metrics, such as span naming, are ignored. The tracing middleware should not also be
+// configured WithRequestMetrics, which would record requests twice.
+func Middleware(opts ...otelfuego.Option) func(http.Handler) http.Handler {
+	options := make([]any, len(opts))
+	for i, opt := range opts {
+		options[i] = opt
+	}
+	return signals.MetricsMiddleware(options...)
+}
@@ -0,0 +1,67 @@
+//go:build !otelfuego_minimal
+
+package otelfuegometrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/otelfuegometrics"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	// Setup in-memory span exporter and metric reader for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	handler := otelfuegometrics.Middleware(
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithFilter(otelfuego.HealthCheckFilter()),
+	)(mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("Expected no spans, got %d", len(spans))
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var points []metricdata.HistogramDataPoint[float64]
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if h, ok := m.Data.(metricdata.Histogram[float64]); ok && m.Name == "http.server.request.duration" {
+				points = append(points, h.DataPoints...)
+			}
+		}
+	}
+	if len(points) != 1 || points[0].Count != 1 {
+		t.Fatalf("Expected a single measurement of the unfiltered request, got %+v", points)
+	}
+	route, _ := points[0].Attributes.Value("http.route")
+	status, _ := points[0].Attributes.Value("http.response.status_code")
+	if route.AsString() != "/users/{id}" || status.AsInt64() != http.StatusCreated {
+		t.Errorf("Expected route /users/{id} and status 201, got %s and %d", route.AsString(), status.AsInt64())
+	}
+}
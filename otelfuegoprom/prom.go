@@ -0,0 +1,72 @@
+// Package otelfuegoprom exposes the metrics of otelfuego in the Prometheus exposition format, for teams
+// that scrape their services rather than run an OpenTelemetry collector.
+//
+// Example:
+//
+//	metrics, err := otelfuegoprom.New()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer metrics.Shutdown(context.Background())
+//
+//	server.Use(otelfuego.Middleware("user-service", metrics.Options()...))
+//	server.Mux.Handle("GET /metrics", metrics.Handler())
+package otelfuegoprom
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// Metrics is a meter provider whose metrics are served in the Prometheus exposition format
+type Metrics struct {
+	provider *sdkmetric.MeterProvider
+	handler  http.Handler
+}
+
+// New returns Metrics exporting to a registry of their own, so they do not collide with metrics
+// registered on the default Prometheus registry. Views, e.g. otelfuego.HistogramView, are applied to
+// the meter provider.
+func New(views ...sdkmetric.View) (*Metrics, error) {
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprom.New(otelprom.WithRegisterer(registry))
+	if err != nil {
+		return nil, fmt.Errorf("otelfuegoprom: creating Prometheus exporter: %w", err)
+	}
+	return &Metrics{
+		provider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(exporter), sdkmetric.WithView(views...)),
+		handler:  promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	}, nil
+}
+
+// Options returns the middleware options recording the request metrics of otelfuego, i.e. the rate,
+// errors and duration of requests, with the meter provider of m
+func (m *Metrics) Options() []otelfuego.Option {
+	return []otelfuego.Option{
+		otelfuego.WithMeterProvider(m.provider),
+		otelfuego.WithRequestMetrics(),
+	}
+}
+
+// MeterProvider returns the meter provider of m, for metrics of the application served alongside
+func (m *Metrics) MeterProvider() *sdkmetric.MeterProvider {
+	return m.provider
+}
+
+// Handler returns the handler serving the metrics to Prometheus, usually mounted at /metrics. Scrapers
+// negotiating the OpenMetrics format also get the exemplars linking histogram buckets to traces.
+func (m *Metrics) Handler() http.Handler {
+	return m.handler
+}
+
+// Shutdown stops the meter provider of m
+func (m *Metrics) Shutdown(ctx context.Context) error {
+	return m.provider.Shutdown(ctx)
+}
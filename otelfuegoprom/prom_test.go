@@ -0,0 +1,46 @@
+package otelfuegoprom_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/otelfuegoprom"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	metrics, err := otelfuegoprom.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = metrics.Shutdown(context.Background()) }()
+
+	tp := sdktrace.NewTracerProvider()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handle("GET /metrics", metrics.Handler())
+	opts := append(metrics.Options(), otelfuego.WithTracerProvider(tp))
+	handler := otelfuego.Middleware("test-service", opts...)(mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		`http_server_request_duration_seconds_count{`,
+		`http_route="/users/{id}"`,
+		`otelfuego_spans_started_total`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected the metrics to contain %s, got:\n%s", want, body)
+		}
+	}
+}
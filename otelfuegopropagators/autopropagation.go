@@ -0,0 +1,112 @@
+// Package otelfuegopropagators continues traces propagated in the formats of other tracing systems,
+// AWS X-Ray, Zipkin B3 and Jaeger, with the otelfuego middleware. It is a module of its own, so
+// applications propagating W3C trace context only do not depend on the contrib propagators.
+//
+// Example:
+//
+//	server.Use(otelfuego.Middleware("my-service", otelfuegopropagators.WithAutoPropagators()))
+package otelfuegopropagators
+
+import (
+	"context"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PropagationFormat is a trace context header format understood by AutoPropagator
+type PropagationFormat int
+
+const (
+	// FormatW3C is the W3C trace context format: traceparent and tracestate
+	FormatW3C PropagationFormat = iota
+	// FormatB3Single is the single header Zipkin B3 format: b3
+	FormatB3Single
+	// FormatB3Multi is the multiple header Zipkin B3 format: X-B3-TraceId, X-B3-SpanId and X-B3-Sampled
+	FormatB3Multi
+	// FormatJaeger is the Jaeger format: uber-trace-id
+	FormatJaeger
+)
+
+// autoPropagator extracts the trace context from the first format present and injects it in the
+// preferred formats. W3C baggage is always extracted and injected.
+type autoPropagator struct {
+	extractors []propagation.TextMapPropagator
+	injectors  []propagation.TextMapPropagator
+	baggage    propagation.Baggage
+}
+
+// AutoPropagator returns a propagator extracting the trace context from whichever supported format
+// is present, W3C trace context, B3 single and multiple header, and Jaeger, in that order of
+// precedence, and injecting it in the inject formats, by default W3C trace context. W3C baggage is
+// propagated too. It eases migrating Zipkin and Jaeger-era services to W3C trace context: callers
+// can switch formats independently, while downstream services receive the format they understand.
+//
+// Example:
+//
+//	// Continue traces of any format, propagating both W3C and B3 during the migration
+//	otel.SetTextMapPropagator(otelfuegopropagators.AutoPropagator(
+//	    otelfuegopropagators.FormatW3C, otelfuegopropagators.FormatB3Multi))
+func AutoPropagator(inject ...PropagationFormat) propagation.TextMapPropagator {
+	if len(inject) == 0 {
+		inject = []PropagationFormat{FormatW3C}
+	}
+	p := &autoPropagator{
+		extractors: []propagation.TextMapPropagator{propagation.TraceContext{}, b3.New(), jaeger.Jaeger{}},
+	}
+	var b3Encoding b3.Encoding
+	for _, format := range inject {
+		switch format {
+		case FormatW3C:
+			p.injectors = append(p.injectors, propagation.TraceContext{})
+		case FormatB3Single:
+			b3Encoding |= b3.B3SingleHeader
+		case FormatB3Multi:
+			b3Encoding |= b3.B3MultipleHeader
+		case FormatJaeger:
+			p.injectors = append(p.injectors, jaeger.Jaeger{})
+		}
+	}
+	if b3Encoding != 0 {
+		p.injectors = append(p.injectors, b3.New(b3.WithInjectEncoding(b3Encoding)))
+	}
+	return p
+}
+
+// WithAutoPropagators configures the middleware to continue traces propagated in any format supported
+// by AutoPropagator. The inject formats apply to the propagator returned by AutoPropagator, which the
+// application registers for outgoing requests; the middleware only extracts.
+func WithAutoPropagators(inject ...PropagationFormat) otelfuego.Option {
+	return otelfuego.WithPropagators(AutoPropagator(inject...))
+}
+
+// Inject sets the trace context of ctx in carrier in each preferred format, and the baggage of ctx
+func (p *autoPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	for _, injector := range p.injectors {
+		injector.Inject(ctx, carrier)
+	}
+	p.baggage.Inject(ctx, carrier)
+}
+
+// Extract returns ctx with the trace context of the first format present in carrier, and the baggage
+func (p *autoPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	for _, extractor := range p.extractors {
+		if extracted := extractor.Extract(ctx, carrier); trace.SpanContextFromContext(extracted).IsRemote() {
+			ctx = extracted
+			break
+		}
+	}
+	return p.baggage.Extract(ctx, carrier)
+}
+
+// Fields returns the headers of every supported format, so that all of them are recognized as trace
+// context headers, e.g. by otelfuego.WithStripIncomingContext
+func (p *autoPropagator) Fields() []string {
+	fields := propagation.TraceContext{}.Fields()
+	fields = append(fields, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader|b3.B3MultipleHeader)).Fields()...)
+	fields = append(fields, jaeger.Jaeger{}.Fields()...)
+	return append(fields, p.baggage.Fields()...)
+}
@@ -0,0 +1,115 @@
+package otelfuegopropagators_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/otelfuegopropagators"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithAutoPropagators(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var member string
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuegopropagators.WithAutoPropagators(),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		member = baggage.FromContext(r.Context()).Member("tenant").Value()
+	}))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const otherTraceID = "a3ce929d0e0e47364bf92f3577b34da6"
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"w3c", map[string]string{"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01"}, traceID},
+		{"b3 single", map[string]string{"b3": traceID + "-00f067aa0ba902b7-1"}, traceID},
+		{"b3 multi", map[string]string{
+			"X-B3-TraceId": traceID,
+			"X-B3-SpanId":  "00f067aa0ba902b7",
+			"X-B3-Sampled": "1",
+		}, traceID},
+		{"jaeger", map[string]string{"uber-trace-id": traceID + ":00f067aa0ba902b7:0:1"}, traceID},
+		{"w3c takes precedence", map[string]string{
+			"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+			"b3":          otherTraceID + "-00f067aa0ba902b7-1",
+		}, traceID},
+		{"invalid w3c falls back", map[string]string{
+			"traceparent": "00-invalid",
+			"b3":          otherTraceID + "-00f067aa0ba902b7-1",
+		}, otherTraceID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			req := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			req.Header.Set("baggage", "tenant=acme")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			span := exporter.GetSpans()[0]
+			if got := span.SpanContext.TraceID().String(); got != tt.want || !span.Parent.IsRemote() {
+				t.Errorf("Expected trace %s to be continued, got %s", tt.want, got)
+			}
+			if member != "acme" {
+				t.Errorf("Expected the baggage to be extracted, got %q", member)
+			}
+		})
+	}
+}
+
+func TestAutoPropagator_Inject(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "outgoing")
+	defer span.End()
+
+	tests := []struct {
+		name    string
+		formats []otelfuegopropagators.PropagationFormat
+		want    []string
+		notWant []string
+	}{
+		{"default", nil, []string{"traceparent"}, []string{"b3", "X-B3-TraceId", "uber-trace-id"}},
+		{"migration", []otelfuegopropagators.PropagationFormat{otelfuegopropagators.FormatW3C, otelfuegopropagators.FormatB3Multi},
+			[]string{"traceparent", "X-B3-TraceId"}, []string{"b3", "uber-trace-id"}},
+		{"b3 single", []otelfuegopropagators.PropagationFormat{otelfuegopropagators.FormatB3Single},
+			[]string{"b3"}, []string{"traceparent", "X-B3-TraceId"}},
+		{"jaeger", []otelfuegopropagators.PropagationFormat{otelfuegopropagators.FormatJaeger},
+			[]string{"uber-trace-id"}, []string{"traceparent", "b3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			otelfuegopropagators.AutoPropagator(tt.formats...).Inject(ctx, propagation.HeaderCarrier(header))
+			for _, h := range tt.want {
+				if header.Get(h) == "" {
+					t.Errorf("Expected %s to be injected, got %v", h, header)
+				}
+			}
+			for _, h := range tt.notWant {
+				if header.Get(h) != "" {
+					t.Errorf("Expected %s not to be injected, got %v", h, header)
+				}
+			}
+		})
+	}
+}
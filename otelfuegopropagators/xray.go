@@ -0,0 +1,24 @@
+package otelfuegopropagators
+
+import (
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// WithXRayPropagation configures the middleware to continue traces propagated in AWS X-Ray
+// X-Amzn-Trace-Id headers, such as those of services instrumented with the X-Ray SDK behind an ALB,
+// in addition to W3C trace context and baggage. A traceparent header takes precedence when both are
+// present. X-Ray trace IDs are continued unchanged, e.g. Root=1-5759e988-bd862e3fe1be46a994272793
+// becomes trace 5759e988bd862e3fe1be46a994272793.
+//
+// Headers carrying only a Root, as added by load balancers to requests without a trace, are not
+// continued. Traces started by the service are only accepted by X-Ray with time-based trace IDs: use
+// xray.NewIDGenerator with the tracer provider, and the X-Ray propagator for outgoing requests.
+func WithXRayPropagation() otelfuego.Option {
+	return otelfuego.WithPropagators(propagation.NewCompositeTextMapPropagator(
+		xray.Propagator{},
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
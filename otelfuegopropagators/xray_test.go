@@ -0,0 +1,95 @@
+package otelfuegopropagators_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/otelfuegopropagators"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// sumCounter returns the sum of the data points of the int64 counter name
+func sumCounter(t *testing.T, reader sdkmetric.Reader, name string) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == name {
+				for _, dp := range sum.DataPoints {
+					total += dp.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestMiddleware_WithXRayPropagation(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	// The handler propagates the context downstream in the X-Ray format
+	var outgoing http.Header
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuegopropagators.WithXRayPropagation(),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outgoing = http.Header{}
+		xray.Propagator{}.Inject(r.Context(), propagation.HeaderCarrier(outgoing))
+	}))
+
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("X-Amzn-Trace-Id", "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if got := span.SpanContext.TraceID().String(); got != "5759e988bd862e3fe1be46a994272793" {
+		t.Errorf("Expected the X-Ray trace to be continued, got trace %s", got)
+	}
+	if got := span.Parent.SpanID().String(); got != "53995c3f42cd8ad8" {
+		t.Errorf("Expected the X-Ray parent to be the parent span, got %s", got)
+	}
+	want := "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=" + span.SpanContext.SpanID().String() + ";Sampled=1"
+	if got := outgoing.Get("X-Amzn-Trace-Id"); got != want {
+		t.Errorf("Expected the X-Ray header to round-trip as %q, got %q", want, got)
+	}
+
+	// Load balancers add a root to requests without a trace: a new trace is started, without failure
+	exporter.Reset()
+	req = httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("X-Amzn-Trace-Id", "Root=1-67891233-abcdef012345678912345678")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if spans := exporter.GetSpans(); len(spans) != 1 || spans[0].Parent.IsValid() {
+		t.Errorf("Expected a new root span, got %+v", spans)
+	}
+	if failures := sumCounter(t, reader, "otelfuego.propagation.failures"); failures != 0 {
+		t.Errorf("Expected no propagation failure, got %d", failures)
+	}
+}
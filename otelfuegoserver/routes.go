@@ -0,0 +1,36 @@
+package otelfuegoserver
+
+import (
+	"github.com/go-fuego/fuego"
+	"github.com/pdrvsky/otelfuego"
+)
+
+// Get registers controller for GET requests on path like fuego.Get, wrapped with otelfuego.Controller
+// so the request body and response types and the returned error are recorded on the server span.
+//
+// Example:
+//
+//	otelfuegoserver.Get(server, "/users/{id}", getUser)
+func Get[T, B any](s *fuego.Server, path string, controller func(fuego.ContextWithBody[B]) (T, error), options ...func(*fuego.BaseRoute)) *fuego.Route[T, B] {
+	return fuego.Get(s, path, otelfuego.Controller(controller), options...)
+}
+
+// Post registers controller for POST requests on path like fuego.Post, wrapped with otelfuego.Controller.
+func Post[T, B any](s *fuego.Server, path string, controller func(fuego.ContextWithBody[B]) (T, error), options ...func(*fuego.BaseRoute)) *fuego.Route[T, B] {
+	return fuego.Post(s, path, otelfuego.Controller(controller), options...)
+}
+
+// Put registers controller for PUT requests on path like fuego.Put, wrapped with otelfuego.Controller.
+func Put[T, B any](s *fuego.Server, path string, controller func(fuego.ContextWithBody[B]) (T, error), options ...func(*fuego.BaseRoute)) *fuego.Route[T, B] {
+	return fuego.Put(s, path, otelfuego.Controller(controller), options...)
+}
+
+// Patch registers controller for PATCH requests on path like fuego.Patch, wrapped with otelfuego.Controller.
+func Patch[T, B any](s *fuego.Server, path string, controller func(fuego.ContextWithBody[B]) (T, error), options ...func(*fuego.BaseRoute)) *fuego.Route[T, B] {
+	return fuego.Patch(s, path, otelfuego.Controller(controller), options...)
+}
+
+// Delete registers controller for DELETE requests on path like fuego.Delete, wrapped with otelfuego.Controller.
+func Delete[T, B any](s *fuego.Server, path string, controller func(fuego.ContextWithBody[B]) (T, error), options ...func(*fuego.BaseRoute)) *fuego.Route[T, B] {
+	return fuego.Delete(s, path, otelfuego.Controller(controller), options...)
+}
@@ -0,0 +1,82 @@
+package otelfuegoserver_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-fuego/fuego"
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/otelfuegoserver"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+func TestPost(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := fuego.NewServer(
+		fuego.WithListener(listener),
+		fuego.WithoutStartupMessages(),
+		fuego.WithEngineOptions(fuego.WithOpenAPIConfig(fuego.OpenAPIConfig{Disabled: true, DisableLocalSave: true})),
+		otelfuegoserver.WithFuegoServer("test-service", otelfuego.WithTracerProvider(tp)),
+	)
+	otelfuegoserver.Post(server, "/users", func(c fuego.ContextWithBody[user]) (user, error) {
+		return c.Body()
+	})
+
+	go func() { _ = server.Run() }()
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	resp, err := http.Post("http://"+listener.Addr().String()+"/users", "application/json", strings.NewReader(`{"name":"ada"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected a successful status, got %d", resp.StatusCode)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "POST /users" {
+		t.Errorf("Expected span name 'POST /users', got '%s'", spans[0].Name)
+	}
+
+	expected := map[string]string{
+		"fuego.request.body.type": "otelfuegoserver_test.user",
+		"fuego.response.type":     "otelfuegoserver_test.user",
+	}
+	for key, want := range expected {
+		found := false
+		for _, attr := range spans[0].Attributes {
+			if string(attr.Key) == key {
+				found = true
+				if attr.Value.AsString() != want {
+					t.Errorf("Expected %s '%s', got '%s'", key, want, attr.Value.AsString())
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected attribute %s to be present", key)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Package otelfuegoserver instruments fuego servers with otelfuego as a server option, so tracing is
+// set up when the server is constructed rather than by remembering to register the middleware.
+// Get, Post, Put, Patch and Delete register typed controllers wrapped with otelfuego.Controller.
+//
+// Example:
+//
+//	server := fuego.NewServer(
+//	    otelfuegoserver.WithFuegoServer("user-service", otelfuego.WithFilter(otelfuego.HealthCheckFilter())),
+//	)
+package otelfuegoserver
+
+import (
+	"github.com/go-fuego/fuego"
+	"github.com/pdrvsky/otelfuego"
+)
+
+// WithFuegoServer returns a fuego server option instrumenting every request of the server with
+// otelfuego.Middleware for service, configured with opts. The middleware is registered as a global
+// middleware, wrapping the router, so requests matching no route are traced too and spans are named
+// after the route once it is matched. Global middlewares registered by later options wrap it, so pass
+// it last to include their time in the spans.
+func WithFuegoServer(service string, opts ...otelfuego.Option) func(*fuego.Server) {
+	return fuego.WithGlobalMiddlewares(otelfuego.Middleware(service, opts...))
+}
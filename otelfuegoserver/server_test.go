@@ -0,0 +1,64 @@
+package otelfuegoserver_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-fuego/fuego"
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/otelfuegoserver"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithFuegoServer(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := fuego.NewServer(
+		fuego.WithListener(listener),
+		fuego.WithoutStartupMessages(),
+		fuego.WithEngineOptions(fuego.WithOpenAPIConfig(fuego.OpenAPIConfig{Disabled: true, DisableLocalSave: true})),
+		otelfuegoserver.WithFuegoServer("test-service", otelfuego.WithTracerProvider(tp)),
+	)
+	fuego.Get(server, "/users/{id}", func(c fuego.ContextNoBody) (string, error) {
+		return "user " + c.PathParam("id"), nil
+	})
+
+	go func() { _ = server.Run() }()
+	defer func() { _ = server.Shutdown(context.Background()) }()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/users/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "GET /users/{id}" {
+		t.Errorf("Expected span name 'GET /users/{id}', got '%s'", spans[0].Name)
+	}
+
+	// The fuego version built into the binary is recorded on the instrumentation scope
+	if v, _ := spans[0].InstrumentationScope.Attributes.Value("fuego.version"); !strings.HasPrefix(v.AsString(), "v0.") {
+		t.Errorf("Expected the fuego version as fuego.version, got '%s'", v.AsString())
+	}
+}
@@ -0,0 +1,164 @@
+// Package otelfuegosetup installs an OTLP/HTTP tracing pipeline for otelfuego in one call. It is a module
+// of its own, so the core module does not depend on the OTLP exporter and its gRPC and protobuf
+// dependencies.
+//
+// Example:
+//
+//	shutdown, err := otelfuegosetup.Setup(ctx, otelfuegosetup.Options{ServiceName: "user-service"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer shutdown(context.Background())
+//
+//	server.Use(otelfuego.Middleware("user-service"))
+package otelfuegosetup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+)
+
+// Options configures the tracing pipeline installed by Setup. Only ServiceName is required.
+type Options struct {
+	// ServiceName is recorded as the service.name resource attribute
+	ServiceName string
+	// ServiceVersion is recorded as the service.version resource attribute
+	ServiceVersion string
+	// Environment is recorded as the deployment.environment.name resource attribute, e.g. "production"
+	Environment string
+
+	// Endpoint of the OTLP/HTTP collector, as host:port or as a URL such as
+	// "https://collector.example.com:4318/v1/traces". The OTEL_EXPORTER_OTLP_ENDPOINT and
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT environment variables are used when empty, and
+	// localhost:4318 otherwise.
+	Endpoint string
+	// Insecure disables TLS for host:port endpoints
+	Insecure bool
+	// Headers are sent with every export request, e.g. to authenticate with a vendor backend
+	Headers map[string]string
+
+	// SampleRatio is the fraction of traces started by this service that are sampled. Traces
+	// continued from a caller follow the caller's sampling decision. The default is 1.
+	SampleRatio float64
+	// Sampler replaces the sampler built from SampleRatio when set
+	Sampler sdktrace.Sampler
+}
+
+// Setup installs a tracing pipeline exporting spans over OTLP/HTTP: a batching tracer provider with a
+// parent-based sampler and a resource describing the service, registered as the global tracer
+// provider, and W3C trace context and baggage as the global propagators. Resource attributes set in
+// OTEL_RESOURCE_ATTRIBUTES are merged in.
+//
+// The returned shutdown function flushes buffered spans and stops the pipeline; call it before the
+// process exits.
+//
+// Example:
+//
+//	shutdown, err := otelfuegosetup.Setup(ctx, otelfuegosetup.Options{ServiceName: "user-service"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer shutdown(context.Background())
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	if opts.ServiceName == "" {
+		return nil, errors.New("otelfuegosetup: ServiceName is required")
+	}
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, fmt.Errorf("otelfuegosetup: SampleRatio %v must be between 0 and 1", opts.SampleRatio)
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts.exporterOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("otelfuegosetup: creating OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(opts.resourceAttributes()...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("otelfuegosetup: creating resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(opts.sampler()),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return tp.Shutdown, nil
+}
+
+// ResourceOption returns the tracer provider option describing service, merged with the default
+// resource, which includes the attributes set in OTEL_RESOURCE_ATTRIBUTES. The middleware no longer
+// records service.name on spans (see otelfuego.WithServiceNameAttribute), so tracer providers not created by
+// Setup should be given a resource naming the service, which backends read it from.
+//
+// Example:
+//
+//	tp := sdktrace.NewTracerProvider(
+//	    sdktrace.WithBatcher(exporter),
+//	    otelfuegosetup.ResourceOption("user-service"),
+//	)
+func ResourceOption(service string) sdktrace.TracerProviderOption {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(service)))
+	if err != nil {
+		otel.Handle(err)
+		res = resource.NewSchemaless(semconv.ServiceName(service))
+	}
+	return sdktrace.WithResource(res)
+}
+
+func (o Options) exporterOptions() []otlptracehttp.Option {
+	var opts []otlptracehttp.Option
+	if strings.Contains(o.Endpoint, "://") {
+		opts = append(opts, otlptracehttp.WithEndpointURL(o.Endpoint))
+	} else if o.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(o.Endpoint))
+	}
+	if o.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(o.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(o.Headers))
+	}
+	return opts
+}
+
+func (o Options) resourceAttributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.ServiceName(o.ServiceName)}
+	if o.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(o.ServiceVersion))
+	}
+	if o.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentName(o.Environment))
+	}
+	return attrs
+}
+
+func (o Options) sampler() sdktrace.Sampler {
+	if o.Sampler != nil {
+		return o.Sampler
+	}
+	ratio := o.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
@@ -0,0 +1,63 @@
+package otelfuegosetup_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/otelfuegosetup"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup(t *testing.T) {
+	// Collector accepting OTLP/HTTP export requests
+	var exports atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			exports.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	// Restore the global pipeline replaced by Setup
+	tp, propagators := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(propagators)
+	}()
+
+	shutdown, err := otelfuegosetup.Setup(context.Background(), otelfuegosetup.Options{
+		ServiceName: "test-service",
+		Endpoint:    collector.URL + "/v1/traces",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := otelfuego.Middleware("test-service")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if exports.Load() != 1 {
+		t.Errorf("Expected spans to be flushed to the collector on shutdown, got %d export requests", exports.Load())
+	}
+}
+
+func TestSetup_Invalid(t *testing.T) {
+	if _, err := otelfuegosetup.Setup(context.Background(), otelfuegosetup.Options{}); err == nil {
+		t.Error("Expected an error without a service name")
+	}
+	if _, err := otelfuegosetup.Setup(context.Background(), otelfuegosetup.Options{ServiceName: "test-service", SampleRatio: 2}); err == nil {
+		t.Error("Expected an error for a sample ratio above 1")
+	}
+}
@@ -0,0 +1,51 @@
+// Package otelfuegozap correlates zap logs with the traces of otelfuego, adding the trace_id and span_id
+// fields of the request span, as otelfuego.WithContextLogger does for slog. It is a module of its own,
+// so applications not using zap do not depend on it.
+//
+// Example:
+//
+//	fuego.Get(server, "/orders/{id}", func(c fuego.ContextNoBody) (Order, error) {
+//	    log := otelfuegozap.Logger(c.Context(), logger)
+//	    log.Info("loading order", zap.String("order.id", c.PathParam("id")))
+//	    ...
+//	})
+package otelfuegozap
+
+import (
+	"context"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Fields returns the correlation fields of the request in ctx: the trace_id and span_id of its span when
+// it is sampled, and its http.request_id when the middleware assigns request IDs (see
+// otelfuego.WithRequestID). It returns no fields outside of a traced request.
+//
+// Example:
+//
+//	logger.Info("charging card", append(otelfuegozap.Fields(ctx), zap.Int("amount", total))...)
+func Fields(ctx context.Context) []zap.Field {
+	fields := make([]zap.Field, 0, 3)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsSampled() {
+		fields = append(fields,
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+	if id := otelfuego.RequestIDFromContext(ctx); id != "" {
+		fields = append(fields, zap.String("http.request_id", id))
+	}
+	return fields
+}
+
+// Logger returns base with the correlation fields of the request in ctx (see Fields), or base itself
+// outside of a traced request
+func Logger(ctx context.Context, base *zap.Logger) *zap.Logger {
+	fields := Fields(ctx)
+	if len(fields) == 0 {
+		return base
+	}
+	return base.With(fields...)
+}
@@ -0,0 +1,83 @@
+package otelfuegozap_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"github.com/pdrvsky/otelfuego/otelfuegozap"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	core, logs := observer.New(zap.InfoLevel)
+	base := zap.New(core)
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRequestID(""),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otelfuegozap.Logger(r.Context(), base).Info("loading user", zap.String("user.id", "42"))
+	}))
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("X-Request-ID", "req-8c1f2a")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if logs.Len() != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", logs.Len())
+	}
+
+	fields := logs.All()[0].ContextMap()
+	expected := map[string]string{
+		"trace_id":        spans[0].SpanContext.TraceID().String(),
+		"span_id":         spans[0].SpanContext.SpanID().String(),
+		"http.request_id": "req-8c1f2a",
+		"user.id":         "42",
+	}
+	for key, want := range expected {
+		if fields[key] != want {
+			t.Errorf("Expected %s %q, got %v", key, want, fields[key])
+		}
+	}
+}
+
+func TestFields_OutsideRequest(t *testing.T) {
+	if fields := otelfuegozap.Fields(context.Background()); len(fields) != 0 {
+		t.Errorf("Expected no fields outside of a request, got %v", fields)
+	}
+
+	base := zap.NewNop()
+	if logger := otelfuegozap.Logger(context.Background(), base); logger != base {
+		t.Error("Expected the base logger outside of a request")
+	}
+}
+
+func TestFields_Unsampled(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9},
+		SpanID:  trace.SpanID{0x00, 0xf0},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	if fields := otelfuegozap.Fields(ctx); len(fields) != 0 {
+		t.Errorf("Expected no fields for an unsampled span, got %v", fields)
+	}
+}
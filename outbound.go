@@ -0,0 +1,55 @@
+package otelfuego
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// detachedOriginKey records on detached spans the span ID of the request span they continue
+const detachedOriginKey = attribute.Key("otelfuego.detached.origin_span_id")
+
+// Inject sets the trace context and baggage of ctx in header, for outgoing requests the application
+// makes itself, such as webhook deliveries or jobs enqueued with HTTP metadata. It uses the
+// propagators configured with WithPropagators for the request in ctx, or the global propagators.
+//
+// Example:
+//
+//	req, _ := http.NewRequestWithContext(ctx, "POST", hook.URL, body)
+//	otelfuego.Inject(ctx, req.Header)
+func Inject(ctx context.Context, header http.Header) {
+	propagators := otel.GetTextMapPropagator()
+	if state := requestStateFromContext(ctx); state != nil && state.cfg.Propagators != nil {
+		propagators = state.cfg.Propagators
+	}
+	propagators.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// StartDetachedSpan starts a span for work continuing after the response to the request in ctx was
+// written, such as webhook deliveries or goroutine fan-out. The span starts a trace of its own linked
+// to the request span, so the request trace keeps its duration while the follow-up stays connected to
+// it. The returned context keeps the values of ctx, but is not canceled when the request ends. The
+// caller must end the span.
+//
+// Example:
+//
+//	ctx, span := otelfuego.StartDetachedSpan(c.Context(), "webhook.deliver")
+//	go func() {
+//	    defer span.End()
+//	    deliver(ctx, event)
+//	}()
+func StartDetachedSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent := trace.SpanContextFromContext(ctx)
+	opts = append(opts, trace.WithNewRoot())
+	if parent.IsValid() {
+		opts = append(opts,
+			trace.WithLinks(trace.Link{SpanContext: parent}),
+			trace.WithAttributes(detachedOriginKey.String(parent.SpanID().String())),
+		)
+	}
+	return tracerFromContext(ctx).Start(context.WithoutCancel(ctx), name, opts...)
+}
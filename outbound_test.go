@@ -0,0 +1,94 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInject(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// The propagators of the middleware are used for outgoing requests too
+	outgoing := http.Header{}
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPropagators(propagation.TraceContext{}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otelfuego.Inject(r.Context(), outgoing)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders", nil))
+
+	span := exporter.GetSpans()[0]
+	want := "00-" + span.SpanContext.TraceID().String() + "-" + span.SpanContext.SpanID().String() + "-01"
+	if got := outgoing.Get("traceparent"); got != want {
+		t.Errorf("Expected traceparent header %q, got %q", want, got)
+	}
+}
+
+func TestStartDetachedSpan(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	release, done := make(chan struct{}), make(chan error)
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := otelfuego.StartDetachedSpan(r.Context(), "webhook.deliver",
+			trace.WithSpanKind(trace.SpanKindProducer))
+		go func() {
+			<-release
+			span.End()
+			done <- ctx.Err()
+		}()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders", nil).WithContext(ctx))
+	cancel()
+
+	// The follow-up outlives the request and its cancellation
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Expected the detached context not to be canceled, got %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	request, detached := spans[0], spans[1]
+	if detached.Name != "webhook.deliver" || detached.SpanKind != trace.SpanKindProducer {
+		t.Errorf("Unexpected detached span %s of kind %s", detached.Name, detached.SpanKind)
+	}
+	if detached.Parent.IsValid() || detached.SpanContext.TraceID() == request.SpanContext.TraceID() {
+		t.Error("Expected the detached span to start a trace of its own")
+	}
+	if len(detached.Links) != 1 || detached.Links[0].SpanContext.SpanID() != request.SpanContext.SpanID() {
+		t.Errorf("Expected the detached span to link to the request span, got %+v", detached.Links)
+	}
+	attrs := attribute.NewSet(detached.Attributes...)
+	if v, _ := attrs.Value("otelfuego.detached.origin_span_id"); v.AsString() != request.SpanContext.SpanID().String() {
+		t.Errorf("Expected the origin span ID to be recorded, got %q", v.AsString())
+	}
+}
@@ -0,0 +1,45 @@
+package otelfuego
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// pathParamKeyPrefix prefixes the attributes recording the path parameters of the matched route
+	pathParamKeyPrefix = "http.route.params."
+
+	// redactedValue replaces the values of path parameters that must not be recorded
+	redactedValue = "REDACTED"
+)
+
+// appendPathParamAttributes appends an http.route.params.<name> attribute for each wildcard of the
+// route matched by r, e.g. http.route.params.id for /users/{id}. The values of the redacted parameters
+// are replaced, and other values truncated to limit.
+func appendPathParamAttributes(attrs []attribute.KeyValue, r *http.Request, redacted []string, limit int) []attribute.KeyValue {
+	pattern := patternPath(r.Pattern)
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			return attrs
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return attrs
+		}
+		name := strings.TrimSuffix(pattern[start+1:start+end], "...")
+		pattern = pattern[start+end+1:]
+		if name == "" || name == "$" {
+			continue
+		}
+
+		value := redactedValue
+		if !slices.Contains(redacted, name) {
+			value = truncateValue(r.PathValue(name), limit)
+		}
+		attrs = append(attrs, attribute.String(pathParamKeyPrefix+name, value))
+	}
+}
@@ -0,0 +1,98 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithPathParamAttributes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	mux.HandleFunc("GET /users/{id}/tokens/{token}", noop)
+	mux.HandleFunc("GET /files/{path...}", noop)
+	mux.HandleFunc("GET /{$}", noop)
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPathParamAttributes("token"),
+	)(mux)
+
+	tests := []struct {
+		path string
+		want map[string]string
+	}{
+		{"/users/123/tokens/secret", map[string]string{"id": "123", "token": "REDACTED"}},
+		{"/files/docs/readme.md", map[string]string{"path": "docs/readme.md"}},
+		{"/", map[string]string{}},
+	}
+	for _, tt := range tests {
+		exporter.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+
+		got := map[string]string{}
+		for _, kv := range exporter.GetSpans()[0].Attributes {
+			if name, ok := strings.CutPrefix(string(kv.Key), "http.route.params."); ok {
+				got[name] = kv.Value.AsString()
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Expected params %v for %s, got %v", tt.want, tt.path, got)
+		}
+		for name, want := range tt.want {
+			if got[name] != want {
+				t.Errorf("Expected http.route.params.%s %q for %s, got %q", name, want, tt.path, got[name])
+			}
+		}
+	}
+
+	// Parameters are only recorded when enabled
+	exporter.Reset()
+	otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))(mux).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/123/tokens/secret", nil))
+	attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+	if _, ok := attrs.Value("http.route.params.id"); ok {
+		t.Error("Expected no path parameters without WithPathParamAttributes")
+	}
+}
+
+func TestMiddleware_WithPathParamAttributes_CopiesRedacted(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tokens/{token}", func(w http.ResponseWriter, r *http.Request) {})
+	redacted := []string{"token"}
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPathParamAttributes(redacted...),
+	)(mux)
+
+	// Reusing the slice passed to the option must not stop redaction
+	redacted[0] = "id"
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/tokens/secret", nil))
+
+	attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+	if v, _ := attrs.Value("http.route.params.token"); v.AsString() != "REDACTED" {
+		t.Errorf("Expected the token to be redacted, got %q", v.AsString())
+	}
+}
@@ -0,0 +1,72 @@
+package otelfuego
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const deserializeOutcomeKey = attribute.Key("fuego.deserialize.outcome")
+
+// BodyContext is the subset of fuego.ContextWithBody[B] used by Body
+type BodyContext[B any] interface {
+	ControllerContext
+	Body() (B, error)
+}
+
+// Body returns the decoded and validated request body of c, like c.Body(). When the middleware
+// is configured WithPhaseSpans, the call is recorded as a "fuego.deserialize" child span whose
+// fuego.deserialize.outcome attribute is "ok", "decode_error" or "validation_error".
+//
+// Example:
+//
+//	func createUser(c fuego.ContextWithBody[User]) (User, error) {
+//	    user, err := otelfuego.Body[User](c)
+//	    ...
+//	}
+func Body[B any](c BodyContext[B]) (B, error) {
+	ctx := c.Context()
+	state := requestStateFromContext(ctx)
+	if minimalBuild || state == nil || !state.cfg.PhaseSpans {
+		return c.Body()
+	}
+
+	_, span := state.tracer.Start(ctx, "fuego.deserialize", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	body, err := c.Body()
+	span.SetAttributes(deserializeOutcomeKey.String(deserializeOutcome(err)))
+	if err != nil {
+		recordError(span, err, state.cfg.MaxAttributeValueLength)
+	}
+	return body, err
+}
+
+// deserializeOutcome classifies the error returned by fuego's Body: fuego reports both decoding
+// and validation failures as bad requests, but only validation failures list the invalid fields
+func deserializeOutcome(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	var httpErr errorWithStatus
+	if errors.As(err, &httpErr) && len(problemFromError(httpErr).Errors) > 0 {
+		return "validation_error"
+	}
+	return "decode_error"
+}
+
+// recordSerializeSpan records the time between the Controller returning and the handler
+// completing, during which fuego serializes and writes the response, as a "fuego.serialize" span
+func recordSerializeSpan(ctx context.Context, state *requestState) {
+	if !state.cfg.PhaseSpans || state.controllerEnd.IsZero() {
+		return
+	}
+	_, span := state.tracer.Start(ctx, "fuego.serialize",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithTimestamp(state.controllerEnd),
+	)
+	span.End(trace.WithTimestamp(time.Now()))
+}
@@ -0,0 +1,102 @@
+//go:build !otelfuego_minimal
+
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithPhaseSpans(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPhaseSpans(),
+	)
+
+	bodyErr := badRequestError{
+		Title:  "Validation Error",
+		Status: 400,
+		Errors: []validationError{{Name: "name", Reason: "required"}},
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		outcome string
+	}{
+		{name: "valid body", outcome: "ok"},
+		{name: "invalid body", err: bodyErr, outcome: "validation_error"},
+		{name: "malformed body", err: badRequestError{Title: "Bad Request", Status: 400}, outcome: "decode_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			controller := otelfuego.Controller(func(c testContext[createUserRequest]) (userResponse, error) {
+				if _, err := otelfuego.Body[createUserRequest](c); err != nil {
+					return userResponse{}, err
+				}
+				return userResponse{ID: "1"}, nil
+			})
+
+			handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp, err := controller(testContext[createUserRequest]{ctx: r.Context(), err: tt.err})
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				_, _ = w.Write([]byte(resp.ID))
+			}))
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+
+			spans := exporter.GetSpans()
+			if len(spans) != 3 {
+				t.Fatalf("Expected 3 spans, got %d", len(spans))
+			}
+
+			deserialize, serialize, server := spans[0], spans[1], spans[2]
+			if deserialize.Name != "fuego.deserialize" || serialize.Name != "fuego.serialize" {
+				t.Fatalf("Expected fuego.deserialize and fuego.serialize spans, got '%s' and '%s'", deserialize.Name, serialize.Name)
+			}
+			for _, child := range []tracetest.SpanStub{deserialize, serialize} {
+				if child.Parent.SpanID() != server.SpanContext.SpanID() {
+					t.Errorf("Expected %s to be a child of the server span", child.Name)
+				}
+			}
+
+			attrs := attribute.NewSet(deserialize.Attributes...)
+			if v, _ := attrs.Value("fuego.deserialize.outcome"); v.AsString() != tt.outcome {
+				t.Errorf("Expected outcome '%s', got '%s'", tt.outcome, v.AsString())
+			}
+		})
+	}
+}
+
+func TestBody_WithoutPhaseSpans(t *testing.T) {
+	c := testContext[createUserRequest]{ctx: context.Background(), body: createUserRequest{Name: "Ada"}}
+
+	body, err := otelfuego.Body[createUserRequest](c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if body.Name != "Ada" {
+		t.Errorf("Expected body name 'Ada', got '%s'", body.Name)
+	}
+}
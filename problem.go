@@ -0,0 +1,43 @@
+package otelfuego
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// problemTraceIDField is the member of 5xx problem details holding the ID of the request's trace
+const problemTraceIDField = "trace_id"
+
+// ErrorSerializer writes err as an RFC 9457 application/problem+json response like fuego's default
+// error serializer, adding the ID of the request's trace as a trace_id member to 5xx responses of sampled
+// requests, so support teams can ask users for the ID printed in the error and find the trace. Errors without a
+// status code, such as errors not converted by fuego's error handler, are written as 500 responses.
+//
+// Example:
+//
+//	server := fuego.NewServer(fuego.WithErrorSerializer(otelfuego.ErrorSerializer))
+func ErrorSerializer(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	problem := map[string]any{}
+	var httpErr errorWithStatus
+	if errors.As(err, &httpErr) {
+		status = httpErr.StatusCode()
+		if data, jsonErr := json.Marshal(httpErr); jsonErr == nil {
+			_ = json.Unmarshal(data, &problem)
+		}
+	}
+	if len(problem) == 0 {
+		problem["title"] = http.StatusText(status)
+		problem["status"] = status
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); status >= 500 && sc.IsSampled() {
+		problem[problemTraceIDField] = sc.TraceID().String()
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
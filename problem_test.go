@@ -0,0 +1,95 @@
+package otelfuego_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestErrorSerializer(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantTitle  string
+		wantTrace  bool
+	}{
+		{"server error", badRequestError{Title: "Unavailable", Status: 503}, 503, "Unavailable", true},
+		{"client error", badRequestError{Title: "Bad Request", Status: 400}, 400, "Bad Request", false},
+		{"plain error", errors.New("boom"), 500, "Internal Server Error", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			handler := otelfuego.Middleware("test-service",
+				otelfuego.WithTracerProvider(tp),
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				otelfuego.ErrorSerializer(w, r, tt.err)
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/users/1", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Expected problem+json content type, got %q", ct)
+			}
+			var problem map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("Expected a JSON body, got %q: %v", rec.Body.String(), err)
+			}
+			if problem["title"] != tt.wantTitle {
+				t.Errorf("Expected title %q, got %v", tt.wantTitle, problem["title"])
+			}
+
+			traceID, ok := problem["trace_id"]
+			if !tt.wantTrace {
+				if ok {
+					t.Errorf("Expected no trace_id in %s responses, got %v", tt.name, traceID)
+				}
+				return
+			}
+			if want := exporter.GetSpans()[0].SpanContext.TraceID().String(); traceID != want {
+				t.Errorf("Expected trace_id %q, got %v", want, traceID)
+			}
+		})
+	}
+}
+
+func TestErrorSerializer_Unsampled(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otelfuego.ErrorSerializer(w, r, errors.New("boom"))
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/users/1", nil))
+
+	var problem map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Expected a JSON body, got %q: %v", rec.Body.String(), err)
+	}
+	if traceID, ok := problem["trace_id"]; ok {
+		t.Errorf("Expected no trace_id for an unsampled request, got %v", traceID)
+	}
+}
@@ -0,0 +1,117 @@
+package otelfuego
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// strippedHeaderPrefix prefixes the attributes preserving stripped propagation headers
+	strippedHeaderPrefix = "otelfuego.stripped."
+
+	// maxReportedHeaderValue bounds the header values included in errors reported to the error handler
+	maxReportedHeaderValue = 128
+)
+
+// stripIncomingContext removes the headers read by propagators from an untrusted request, so the
+// incoming trace context is neither continued nor re-propagated by the application. It returns a
+// shallow copy of r with its own header map, along with the removed values as attributes truncated to
+// limit bytes when preserve is set.
+func stripIncomingContext(r *http.Request, propagators propagation.TextMapPropagator, preserve bool, limit int) (*http.Request, []attribute.KeyValue) {
+	var attrs []attribute.KeyValue
+	var header http.Header
+
+	for _, field := range propagators.Fields() {
+		values := r.Header.Values(field)
+		if len(values) == 0 {
+			continue
+		}
+		if header == nil {
+			header = r.Header.Clone()
+		}
+		header.Del(field)
+		if preserve {
+			key := strippedHeaderPrefix + strings.ToLower(field)
+			attrs = append(attrs, attribute.String(key, truncateValue(strings.Join(values, ","), limit)))
+		}
+	}
+
+	if header == nil {
+		return r, nil
+	}
+	r = r.WithContext(r.Context())
+	r.Header = header
+	return r, attrs
+}
+
+// stripIncomingState removes the tracestate header from an untrusted request, and the baggage members
+// whose key is not in allowed. It returns a shallow copy of r with its own header map when anything
+// was removed, keeping the trace context itself.
+func stripIncomingState(r *http.Request, allowed []string) *http.Request {
+	values := r.Header.Values("Baggage")
+	if len(values) == 0 && len(r.Header.Values("Tracestate")) == 0 {
+		return r
+	}
+	header := r.Header.Clone()
+	header.Del("Tracestate")
+	header.Del("Baggage")
+	if bag := allowedBaggage(strings.Join(values, ","), allowed); bag != "" {
+		header.Set("Baggage", bag)
+	}
+	r = r.WithContext(r.Context())
+	r.Header = header
+	return r
+}
+
+// allowedBaggage returns the members of the baggage header value whose key is in allowed, or an empty
+// string if there are none
+func allowedBaggage(value string, allowed []string) string {
+	if value == "" || len(allowed) == 0 {
+		return ""
+	}
+	// Invalid members make the whole baggage invalid, as for the baggage propagator
+	bag, err := baggage.Parse(value)
+	if err != nil {
+		return ""
+	}
+	for _, m := range bag.Members() {
+		if !slices.Contains(allowed, m.Key()) {
+			bag = bag.DeleteMember(m.Key())
+		}
+	}
+	return bag.String()
+}
+
+// untrustedStateCarrier hides the trace state and the baggage members whose key is not in allowed from
+// the propagators, wherever the carrier reads them from, e.g. query parameters
+type untrustedStateCarrier struct {
+	propagation.TextMapCarrier
+	allowed []string
+}
+
+func (c untrustedStateCarrier) Get(key string) string {
+	switch strings.ToLower(key) {
+	case "tracestate":
+		return ""
+	case "baggage":
+		return allowedBaggage(c.TextMapCarrier.Get(key), c.allowed)
+	}
+	return c.TextMapCarrier.Get(key)
+}
+
+func (c untrustedStateCarrier) Keys() []string {
+	return slices.DeleteFunc(slices.Clone(c.TextMapCarrier.Keys()), func(key string) bool {
+		switch strings.ToLower(key) {
+		case "tracestate":
+			return true
+		case "baggage":
+			return c.Get(key) == ""
+		}
+		return false
+	})
+}
@@ -0,0 +1,180 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithStripIncomingContext(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	propagator := propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPropagators(propagator),
+		otelfuego.WithStripIncomingContext(func(req *http.Request) bool {
+			return req.Header.Get("X-Internal") == ""
+		}),
+		otelfuego.WithStrippedContextAttributes(),
+	)
+
+	var seen http.Header
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	parentCtx, parentSpan := tp.Tracer("test").Start(context.Background(), "upstream")
+	parentSpan.End()
+
+	for _, internal := range []bool{false, true} {
+		exporter.Reset()
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		propagator.Inject(parentCtx, propagation.HeaderCarrier(req.Header))
+		req.Header.Set("Baggage", "user=42")
+		if internal {
+			req.Header.Set("X-Internal", "1")
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("Expected 1 span, got %d", len(spans))
+		}
+		continued := spans[0].SpanContext.TraceID() == parentSpan.SpanContext().TraceID()
+		attrs := attribute.NewSet(spans[0].Attributes...)
+
+		if internal {
+			if !continued {
+				t.Error("Expected trusted request to continue the incoming trace")
+			}
+			if seen.Get("Traceparent") == "" {
+				t.Error("Expected trusted request to keep its traceparent header")
+			}
+			continue
+		}
+
+		if continued {
+			t.Error("Expected untrusted request to start a new trace")
+		}
+		if seen.Get("Traceparent") != "" || seen.Get("Baggage") != "" {
+			t.Errorf("Expected propagation headers to be stripped, got %v", seen)
+		}
+		if req.Header.Get("Traceparent") == "" {
+			t.Error("Expected the original request headers to be left untouched")
+		}
+		if v, _ := attrs.Value("otelfuego.stripped.traceparent"); v.AsString() != req.Header.Get("Traceparent") {
+			t.Errorf("Expected stripped traceparent to be preserved, got '%s'", v.AsString())
+		}
+		if v, _ := attrs.Value("otelfuego.stripped.baggage"); v.AsString() != "user=42" {
+			t.Errorf("Expected stripped baggage 'user=42', got '%s'", v.AsString())
+		}
+	}
+}
+
+func TestMiddleware_WithStrippedContextAttributes_Truncated(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPropagators(propagation.Baggage{}),
+		otelfuego.WithStripIncomingContext(func(req *http.Request) bool { return true }),
+		otelfuego.WithStrippedContextAttributes(),
+		otelfuego.WithMaxAttributeValueLength(16),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Untrusted clients control the size of the headers recorded for forensics
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Baggage", "user="+strings.Repeat("x", 4096))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+	if v, _ := attrs.Value("otelfuego.stripped.baggage"); v.AsString() != "user=xxxxxxxxxxx" {
+		t.Errorf("Expected stripped baggage truncated to 16 bytes, got '%s'", v.AsString())
+	}
+}
+
+func TestMiddleware_WithStripIncomingState(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	propagator := propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+	var seen http.Header
+	var bag baggage.Baggage
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPropagators(propagator),
+		otelfuego.WithStripIncomingState(func(r *http.Request) bool {
+			return strings.HasPrefix(r.URL.Path, "/public/")
+		}, "session.id"),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, bag = r.Header, baggage.FromContext(r.Context())
+	}))
+
+	newRequest := func(path string) *http.Request {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		req.Header.Set("tracestate", "vendor=injected")
+		req.Header.Set("baggage", "session.id=abc,user.role=admin")
+		return req
+	}
+
+	// Untrusted requests continue the trace without their state and unlisted baggage
+	req := newRequest("/public/signup")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	span := exporter.GetSpans()[0]
+	if span.Parent.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the trace to be continued, got parent %v", span.Parent)
+	}
+	if span.Parent.TraceState().Len() != 0 || seen.Get("tracestate") != "" {
+		t.Errorf("Expected the trace state to be removed, got %q", span.Parent.TraceState())
+	}
+	if bag.Len() != 1 || bag.Member("session.id").Value() != "abc" || seen.Get("baggage") != "session.id=abc" {
+		t.Errorf("Expected only the allowed baggage to be kept, got %q", bag)
+	}
+	if req.Header.Get("tracestate") == "" {
+		t.Error("Expected the headers of the original request not to be modified")
+	}
+
+	// Trusted requests keep everything
+	exporter.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("/internal/sync"))
+	if span := exporter.GetSpans()[0]; span.Parent.TraceState().Len() != 1 || bag.Len() != 2 {
+		t.Errorf("Expected trusted requests to keep their state and baggage, got %q and %q", span.Parent.TraceState(), bag)
+	}
+}
@@ -0,0 +1,62 @@
+package otelfuego
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const queueDurationKey = attribute.Key("http.request.queue_duration")
+
+// defaultQueueTimeHeaders are the headers reverse proxies set to the time they received a request
+var defaultQueueTimeHeaders = []string{"X-Request-Start", "X-Queue-Start"}
+
+// queueDuration returns the time r spent queued in front of the process until now, according to the
+// first of headers holding a request start timestamp. It returns false when no header holds a
+// timestamp, or when the timestamp is in the future because of clock skew between hosts.
+func queueDuration(r *http.Request, headers []string, now time.Time) (time.Duration, bool) {
+	for _, name := range headers {
+		if value := r.Header.Get(name); value != "" {
+			start, ok := parseRequestStart(value)
+			if !ok {
+				continue
+			}
+			if d := now.Sub(start); d >= 0 {
+				return d, true
+			}
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// parseRequestStart parses a request start timestamp as set by proxies: Unix time optionally prefixed
+// with "t=", in seconds with a fraction (nginx's $msec), or in milliseconds, microseconds (Heroku,
+// Apache's %t) or nanoseconds. The unit of integers is told by their magnitude.
+func parseRequestStart(value string) (time.Time, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "t=")
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil || seconds <= 0 {
+			return time.Time{}, false
+		}
+		return time.Unix(0, int64(seconds*float64(time.Second))), true
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+	switch {
+	case n >= 1e17:
+		return time.Unix(0, n), true
+	case n >= 1e14:
+		return time.UnixMicro(n), true
+	case n >= 1e11:
+		return time.UnixMilli(n), true
+	default:
+		return time.Unix(n, 0), true
+	}
+}
@@ -0,0 +1,65 @@
+package otelfuego_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithQueueTime(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithQueueTime(),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	queued := time.Now().Add(-250 * time.Millisecond)
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   bool
+	}{
+		{"nginx seconds", "X-Request-Start", fmt.Sprintf("t=%.3f", float64(queued.UnixMilli())/1000), true},
+		{"milliseconds", "X-Queue-Start", fmt.Sprint(queued.UnixMilli()), true},
+		{"heroku microseconds", "X-Request-Start", fmt.Sprintf("t=%d", queued.UnixMicro()), true},
+		{"nanoseconds", "X-Request-Start", fmt.Sprint(queued.UnixNano()), true},
+		{"future timestamp", "X-Request-Start", fmt.Sprint(time.Now().Add(time.Minute).UnixMilli()), false},
+		{"garbage", "X-Request-Start", "t=soon", false},
+		{"missing", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			req := httptest.NewRequest("GET", "/jobs", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+			v, ok := attrs.Value("http.request.queue_duration")
+			if ok != tt.want {
+				t.Fatalf("Expected queue duration recorded to be %v, got %v", tt.want, v)
+			}
+			if ok && (v.AsFloat64() < 0.249 || v.AsFloat64() > 1) {
+				t.Errorf("Expected a queue duration of about 250ms, got %vs", v.AsFloat64())
+			}
+		})
+	}
+}
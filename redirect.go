@@ -0,0 +1,37 @@
+package otelfuego
+
+import (
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redirectLocationKey records the Location header of redirects, as semconv records captured headers
+const redirectLocationKey = attribute.Key("http.response.header.location")
+
+// isRedirect reports whether status sends the client to the Location header. 304 Not Modified answers
+// a conditional request rather than redirecting it.
+func isRedirect(status int) bool {
+	return status >= 300 && status < 400 && status != http.StatusNotModified
+}
+
+// redirectEventOptions returns the options of the redirect event of a response with the given Location
+// header value. The location is recorded without user info, query and fragment, which may carry
+// credentials or tokens, and truncated to limit.
+func redirectEventOptions(location string, limit int) []trace.EventOption {
+	if location == "" {
+		return nil
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil
+	}
+	u.User = nil
+	u.RawQuery, u.ForceQuery = "", false
+	u.Fragment, u.RawFragment = "", ""
+	return []trace.EventOption{trace.WithAttributes(
+		redirectLocationKey.StringSlice([]string{truncateValue(u.String(), limit)}),
+	)}
+}
@@ -0,0 +1,66 @@
+package otelfuego
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDKey = attribute.Key("http.request_id")
+
+	// defaultRequestIDHeader is the header WithRequestID reads and echoes by default
+	defaultRequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength bounds the incoming request IDs that are kept, longer ones are replaced
+	maxRequestIDLength = 128
+)
+
+type requestIDContextKey struct{}
+
+// validRequestID reports whether id is short enough and made of visible ASCII characters only, so it
+// can be echoed in a response header and logged as is
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if c <= ' ' || c >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// requestID returns the ID of r: the one set by an enclosing middleware, the valid one sent in
+// header unless r is untrusted, or else the trace ID of sc, so that request IDs minted here find their
+// trace. Without a trace ID, as with the no-op tracer provider, a random ID is returned.
+func requestID(ctx context.Context, r *http.Request, header string, untrusted bool, sc trace.SpanContext) string {
+	if id, ok := ctx.Value(requestIDContextKey{}).(string); ok {
+		return id
+	}
+	if id := r.Header.Get(header); !untrusted && validRequestID(id) {
+		return id
+	}
+	if sc.TraceID().IsValid() {
+		return sc.TraceID().String()
+	}
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// RequestIDFromContext returns the request ID the middleware configured WithRequestID assigned to the
+// request in ctx, or an empty string outside of such a middleware
+//
+// Example:
+//
+//	logger.InfoContext(ctx, "charging card", "request_id", otelfuego.RequestIDFromContext(ctx))
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
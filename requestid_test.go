@@ -0,0 +1,110 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithRequestID(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var fromContext string
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRequestID(""),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromContext = otelfuego.RequestIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{"generated", "", false},
+		{"incoming", "req-8c1f2a", true},
+		{"invalid incoming", "has spaces", false},
+		{"too long incoming", strings.Repeat("a", 200), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			span := exporter.GetSpans()[0]
+			want := span.SpanContext.TraceID().String()
+			if tt.kept {
+				want = tt.incoming
+			}
+			if got := w.Header().Get("X-Request-ID"); got != want {
+				t.Errorf("Expected response header %q, got %q", want, got)
+			}
+			if fromContext != want {
+				t.Errorf("Expected request ID %q in context, got %q", want, fromContext)
+			}
+			attrs := attribute.NewSet(span.Attributes...)
+			if v, _ := attrs.Value("http.request_id"); v.AsString() != want {
+				t.Errorf("Expected http.request_id %q, got %q", want, v.AsString())
+			}
+		})
+	}
+}
+
+func TestMiddleware_WithRequestID_Untrusted(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRequestID(""),
+		otelfuego.WithStripIncomingContext(func(req *http.Request) bool {
+			return req.Header.Get("X-Internal-Token") == ""
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "req-8c1f2a")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	// Untrusted clients must not choose the ID their request is logged under
+	want := exporter.GetSpans()[0].SpanContext.TraceID().String()
+	if got := w.Header().Get("X-Request-ID"); got != want {
+		t.Errorf("Expected a new request ID %q, got %q", want, got)
+	}
+
+	exporter.Reset()
+	req = httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "req-8c1f2a")
+	req.Header.Set("X-Internal-Token", "secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("X-Request-ID"); got != "req-8c1f2a" {
+		t.Errorf("Expected the incoming request ID of a trusted request, got %q", got)
+	}
+}
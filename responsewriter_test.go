@@ -0,0 +1,180 @@
+package otelfuego_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// readerFromRecorder is a ResponseRecorder that records whether ReadFrom was used
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom = true
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestResponseWriter_ReadFrom(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+	)
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(io.ReaderFrom); !ok {
+			t.Fatal("Expected wrapped ResponseWriter to implement io.ReaderFrom")
+		}
+		// Hide strings.Reader's WriteTo, like the LimitedReader used by http.ServeContent
+		_, _ = io.Copy(w, struct{ io.Reader }{strings.NewReader("file contents")})
+	}))
+
+	w := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/download", nil))
+
+	if !w.readFrom {
+		t.Error("Expected ReadFrom of the underlying ResponseWriter to be used")
+	}
+	if w.Body.String() != "file contents" {
+		t.Errorf("Expected body 'file contents', got '%s'", w.Body.String())
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("http.response.body.size"); v.AsInt64() != int64(len("file contents")) {
+		t.Errorf("Expected body size %d, got %d", len("file contents"), v.AsInt64())
+	}
+}
+
+// pushRecorder is a ResponseRecorder supporting HTTP/2 server push and write deadlines
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed   []string
+	deadline time.Time
+}
+
+func (r *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	r.pushed = append(r.pushed, target)
+	return nil
+}
+
+func (r *pushRecorder) SetWriteDeadline(deadline time.Time) error {
+	r.deadline = deadline
+	return nil
+}
+
+func TestResponseWriter_PusherAndResponseController(t *testing.T) {
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(sdktrace.NewTracerProvider()),
+	)
+
+	deadline := time.Now().Add(time.Minute)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pusher, ok := w.(http.Pusher)
+		if !ok {
+			t.Fatal("Expected wrapped ResponseWriter to implement http.Pusher")
+		}
+		if err := pusher.Push("/static/app.js", nil); err != nil {
+			t.Errorf("Unexpected push error: %v", err)
+		}
+		if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+			t.Errorf("Unexpected SetWriteDeadline error: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if len(w.pushed) != 1 || w.pushed[0] != "/static/app.js" {
+		t.Errorf("Expected /static/app.js to be pushed, got %v", w.pushed)
+	}
+	if !w.deadline.Equal(deadline) {
+		t.Errorf("Expected write deadline %v, got %v", deadline, w.deadline)
+	}
+
+	// Push is reported as unsupported when the underlying writer cannot push
+	handler = middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := w.(http.Pusher).Push("/static/app.js", nil); err != http.ErrNotSupported {
+			t.Errorf("Expected http.ErrNotSupported, got %v", err)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestResponseWriter_InformationalResponses(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	server := httptest.NewServer(otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusContinue)
+		otelfuego.WriteEarlyHints(w, "</app.js>; rel=preload; as=script")
+		w.WriteHeader(http.StatusCreated)
+	})))
+	defer server.Close()
+
+	var informational []int
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			informational = append(informational, code)
+			return nil
+		},
+	})
+	req, _ := http.NewRequestWithContext(ctx, "POST", server.URL, strings.NewReader("payload"))
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	// The informational responses reach the client and do not latch the final status
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", resp.StatusCode)
+	}
+	if len(informational) != 2 || informational[0] != 100 || informational[1] != 103 {
+		t.Errorf("Expected 100 and 103 informational responses, got %v", informational)
+	}
+
+	span := exporter.GetSpans()[0]
+	attrs := attribute.NewSet(span.Attributes...)
+	if v, _ := attrs.Value("http.response.status_code"); v.AsInt64() != http.StatusCreated {
+		t.Errorf("Expected http.response.status_code 201, got %d", v.AsInt64())
+	}
+	if len(span.Events) != 2 || span.Events[0].Name != "http.continue" || span.Events[1].Name != "http.early_hints" {
+		t.Fatalf("Expected http.continue and http.early_hints events, got %v", span.Events)
+	}
+	hints := attribute.NewSet(span.Events[1].Attributes...)
+	if v, _ := hints.Value("http.response.header.link"); len(v.AsStringSlice()) != 1 || v.AsStringSlice()[0] != "</app.js>; rel=preload; as=script" {
+		t.Errorf("Expected the hinted link to be recorded, got %v", v.AsStringSlice())
+	}
+}
@@ -0,0 +1,39 @@
+package otelfuego
+
+import (
+	"net/http"
+	"strings"
+)
+
+// routePattern returns the path portion of the route pattern matched by http.ServeMux,
+// or an empty string if the request has not been routed yet.
+// Fuego registers its routes on a ServeMux, so the pattern is available both in
+// per-route middleware and, once next.ServeHTTP returned, in global middleware.
+func routePattern(r *http.Request) string {
+	return patternPath(r.Pattern)
+}
+
+// matchedPattern returns the ServeMux pattern matched by r once routed, or the pattern mux would
+// match before routing. It returns an empty string if no route matched.
+func matchedPattern(r *http.Request, mux *http.ServeMux) string {
+	if r.Pattern != "" || mux == nil {
+		return r.Pattern
+	}
+	_, pattern := mux.Handler(r)
+	return pattern
+}
+
+// patternPath strips the optional method and host from a ServeMux pattern,
+// e.g. "GET example.com/users/{id}" becomes "/users/{id}".
+func patternPath(pattern string) string {
+	if pattern == "" {
+		return ""
+	}
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = strings.TrimLeft(pattern[i+1:], " \t")
+	}
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
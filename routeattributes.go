@@ -0,0 +1,48 @@
+package otelfuego
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// routeAttributes are the static attributes of routes set with WithRouteAttributes, keyed by route path,
+// optionally preceded by a method, e.g. "/users/{id}" or "GET /users/{id}"
+type routeAttributes map[string][]attribute.KeyValue
+
+// lookup returns the attributes of the route matched by r, preferring those set for its method. It
+// returns nil before r is routed.
+func (a routeAttributes) lookup(r *http.Request) []attribute.KeyValue {
+	route := routePattern(r)
+	if len(a) == 0 || route == "" {
+		return nil
+	}
+	if attrs, ok := a[r.Method+" "+route]; ok {
+		return attrs
+	}
+	return a[route]
+}
+
+// routeAttributesKey returns route normalized to the keys lookup matches, "/path" or "METHOD /path", with
+// the method upper-cased and a single space before the path. It returns false if route is not such a key,
+// e.g. because it has a host, which lookup never matches.
+func routeAttributesKey(route string) (string, bool) {
+	fields := strings.Fields(route)
+	var method, path string
+	switch len(fields) {
+	case 1:
+		path = fields[0]
+	case 2:
+		method, path = strings.ToUpper(fields[0]), fields[1]
+	default:
+		return "", false
+	}
+	if path[0] != '/' {
+		return "", false
+	}
+	if method == "" {
+		return path, true
+	}
+	return method + " " + path, true
+}
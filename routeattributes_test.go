@@ -0,0 +1,56 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithRouteAttributes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRouteAttributes(map[string][]attribute.KeyValue{
+			"/users/{id}":        {attribute.String("team", "identity"), attribute.String("tier", "standard")},
+			"DELETE /users/{id}": {attribute.String("team", "identity"), attribute.String("tier", "critical")},
+			"put  /users/{id}":   {attribute.String("team", "identity"), attribute.String("tier", "critical")},
+		}),
+	)(mux)
+
+	tests := []struct {
+		method string
+		path   string
+		tier   string
+	}{
+		{"GET", "/users/1", "standard"},
+		{"DELETE", "/users/1", "critical"},
+		{"PUT", "/users/1", "critical"},
+		{"GET", "/health", ""},
+	}
+	for _, tt := range tests {
+		exporter.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+		attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+		if v, _ := attrs.Value("tier"); v.AsString() != tt.tier {
+			t.Errorf("%s %s: expected tier '%s', got '%s'", tt.method, tt.path, tt.tier, v.AsString())
+		}
+	}
+
+}
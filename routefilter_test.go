@@ -0,0 +1,86 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithRouteFilter(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, pattern := range []string{"GET /healthz", "GET /healthzone", "GET /users/{id}"} {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		traced bool
+	}{
+		{name: "excluded route", path: "/healthz", traced: false},
+		{name: "route sharing the prefix", path: "/healthzone", traced: true},
+		{name: "other route", path: "/users/42", traced: true},
+		{name: "unknown route", path: "/unknown", traced: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup in-memory span exporter for testing
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(
+				sdktrace.WithSyncer(exporter),
+				sdktrace.WithSampler(sdktrace.AlwaysSample()),
+			)
+			defer func() { _ = tp.Shutdown(context.Background()) }()
+
+			middleware := otelfuego.Middleware("test-service",
+				otelfuego.WithTracerProvider(tp),
+				otelfuego.WithRouteFilter(mux, otelfuego.ExcludeRoutes("GET /healthz")),
+			)
+
+			w := httptest.NewRecorder()
+			middleware(mux).ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if traced := len(exporter.GetSpans()) == 1; traced != tt.traced {
+				t.Errorf("Expected traced=%v, got %v", tt.traced, traced)
+			}
+		})
+	}
+}
+
+func TestMiddleware_WithRouteFilterPerRoute(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// Per-route middleware sees the pattern matched by the ServeMux, no mux is needed
+	var patterns []string
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRouteFilter(nil, func(r *http.Request, pattern string) bool {
+			patterns = append(patterns, pattern)
+			return true
+		}),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	if len(patterns) != 1 || patterns[0] != "GET /users/{id}" {
+		t.Errorf("Expected the filter to receive 'GET /users/{id}', got %v", patterns)
+	}
+}
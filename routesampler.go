@@ -0,0 +1,101 @@
+package otelfuego
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// routeSamplers selects a sampler by the ServeMux pattern matching the request. The patterns are
+// registered on a private ServeMux, so they match exactly like fuego's routes, before routing.
+type routeSamplers struct {
+	mux      *http.ServeMux
+	samplers map[string]sdktrace.Sampler
+}
+
+// add registers sampler for pattern, returning an error for invalid or duplicate patterns
+func (s *routeSamplers) add(pattern string, sampler sdktrace.Sampler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid pattern %q: %v", pattern, r)
+		}
+	}()
+	s.mux.Handle(pattern, http.NotFoundHandler())
+	s.samplers[pattern] = sampler
+	return nil
+}
+
+// sample returns the decision of the sampler of the route of the request, and false when no route
+// sampler applies. The span context returned for dropped requests carries the decision downstream like
+// the non-recording spans of the SDK; other decisions are left to RouteSampler on the tracer provider.
+func (s *routeSamplers) sample(ctx context.Context, r *http.Request, name string, kind trace.SpanKind, attrs []attribute.KeyValue) (sdktrace.SamplingResult, trace.SpanContext, bool) {
+	_, pattern := s.mux.Handler(r)
+	sampler, ok := s.samplers[pattern]
+	if !ok {
+		return sdktrace.SamplingResult{}, trace.SpanContext{}, false
+	}
+
+	parent := trace.SpanContextFromContext(ctx)
+	traceID := parent.TraceID()
+	if !parent.IsValid() {
+		binary.BigEndian.PutUint64(traceID[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(traceID[8:], rand.Uint64())
+	}
+
+	result := sampler.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: ctx,
+		TraceID:       traceID,
+		Name:          name,
+		Kind:          kind,
+		Attributes:    attrs,
+	})
+	if result.Decision != sdktrace.Drop {
+		return result, trace.SpanContext{}, true
+	}
+
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], rand.Uint64())
+	return result, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceState: result.Tracestate,
+	}), true
+}
+
+// routeSamplingKey is the context key of the decision of a route sampler for the span being started
+type routeSamplingKey struct{}
+
+// RouteSampler returns a sampler applying the decisions of the samplers configured with
+// WithRouteSampler to request spans, and fallback to other spans, including requests matching no
+// route sampler. Install it on the tracer provider so that route samplers are final: without it,
+// requests they keep are still subject to the provider's sampler.
+//
+// Example:
+//
+//	sdktrace.NewTracerProvider(
+//	    sdktrace.WithSampler(otelfuego.RouteSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0.1)))),
+//	)
+func RouteSampler(fallback sdktrace.Sampler) sdktrace.Sampler {
+	return routeSampler{fallback: fallback}
+}
+
+type routeSampler struct {
+	fallback sdktrace.Sampler
+}
+
+func (s routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if result, ok := p.ParentContext.Value(routeSamplingKey{}).(*sdktrace.SamplingResult); ok && result != nil {
+		return *result
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+func (s routeSampler) Description() string {
+	return "RouteSampler{" + s.fallback.Description() + "}"
+}
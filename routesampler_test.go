@@ -0,0 +1,84 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware_WithRouteSampler(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var spanContext trace.SpanContext
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRouteSampler("/events/", sdktrace.NeverSample()),
+		otelfuego.WithRouteSampler("POST /events/critical", sdktrace.AlwaysSample()),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spanContext = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method  string
+		path    string
+		sampled bool
+	}{
+		{method: "POST", path: "/events/click", sampled: false},
+		{method: "POST", path: "/events/critical", sampled: true},
+		{method: "GET", path: "/events/critical", sampled: false},
+		{method: "POST", path: "/checkout", sampled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			exporter.Reset()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if got := len(exporter.GetSpans()) == 1; got != tt.sampled {
+				t.Errorf("Expected sampled %v, got %v", tt.sampled, got)
+			}
+			if !spanContext.IsValid() || spanContext.IsSampled() != tt.sampled {
+				t.Errorf("Expected a valid span context with sampled=%v, got %v", tt.sampled, spanContext)
+			}
+		})
+	}
+}
+
+func TestMiddleware_WithRouteSampler_Final(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(otelfuego.RouteSampler(sdktrace.TraceIDRatioBased(0.01))),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithRouteSampler("/critical", sdktrace.AlwaysSample()),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	const requests = 100
+	for range requests {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/critical", nil))
+	}
+
+	if got := len(exporter.GetSpans()); got != requests {
+		t.Errorf("Expected every request sampled by its route to be recorded, got %d of %d", got, requests)
+	}
+}
@@ -0,0 +1,228 @@
+package otelfuego
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	droppedReasonKey = attribute.Key("otelfuego.dropped.reason")
+	hookKey          = attribute.Key("otelfuego.hook")
+)
+
+// selfMetrics are the metrics the middleware reports about itself, so operators can tell whether
+// missing traces mean no traffic or broken instrumentation
+type selfMetrics struct {
+	startedSpans        metric.Int64Counter
+	droppedSpans        metric.Int64Counter
+	hookPanics          metric.Int64Counter
+	propagationFailures metric.Int64Counter
+	overhead            metric.Float64Histogram
+
+	// cfg reports recovered panics to the error handler
+	cfg *config
+
+	// static holds the attributes set WithAttributes, added to every measurement
+	static []attribute.KeyValue
+
+	// tenants is set when spans dropped by the rate limit record their tenant
+	tenants *cardinalityGuard
+
+	// The options are built once as they would otherwise be allocated per request. addOptions and
+	// recordOptions are nil without static attributes.
+	rateLimited   metric.AddOption
+	filtered      metric.AddOption
+	disabled      metric.AddOption
+	addOptions    []metric.AddOption
+	recordOptions []metric.RecordOption
+}
+
+func newSelfMetrics(cfg *config) *selfMetrics {
+	meter := meterFor(cfg)
+	m := &selfMetrics{cfg: cfg, static: cfg.Attributes, tenants: newMetricTenants(cfg)}
+	m.rateLimited = m.droppedReason("rate_limit")
+	m.filtered = m.droppedReason("filter")
+	m.disabled = m.droppedReason("disabled")
+	if len(m.static) > 0 {
+		set := metric.WithAttributeSet(attribute.NewSet(m.static...))
+		m.addOptions = []metric.AddOption{set}
+		m.recordOptions = []metric.RecordOption{set}
+	}
+	var err error
+	if m.startedSpans, err = meter.Int64Counter("otelfuego.spans.started",
+		metric.WithDescription("Number of request spans started by the middleware, sampled or not"),
+		metric.WithUnit("{span}"),
+	); err != nil {
+		otel.Handle(err)
+	}
+	if m.droppedSpans, err = meter.Int64Counter("otelfuego.spans.dropped",
+		metric.WithDescription("Number of requests not traced by the middleware"),
+		metric.WithUnit("{span}"),
+	); err != nil {
+		otel.Handle(err)
+	}
+	if m.hookPanics, err = meter.Int64Counter("otelfuego.hook.panics",
+		metric.WithDescription("Number of panics recovered from user-provided hooks such as filters"),
+		metric.WithUnit("{panic}"),
+	); err != nil {
+		otel.Handle(err)
+	}
+	if m.propagationFailures, err = meter.Int64Counter("otelfuego.propagation.failures",
+		metric.WithDescription("Number of requests carrying trace context headers no valid context was extracted from"),
+		metric.WithUnit("{request}"),
+	); err != nil {
+		otel.Handle(err)
+	}
+	if m.overhead, err = meter.Float64Histogram("otelfuego.overhead", durationHistogramOptions(cfg,
+		"Time spent in the middleware rather than in the handler, per sampled request",
+	)...); err != nil {
+		otel.Handle(err)
+	}
+	return m
+}
+
+// recordOverhead records the time spent in the middleware for a request ending at now. ctx carries the
+// span of the request, which the SDK records as an exemplar.
+func (m *selfMetrics) recordOverhead(ctx context.Context, timings requestTimings, now time.Time) {
+	if d := timings.overhead(now); d > 0 {
+		m.overhead.Record(ctx, d.Seconds(), m.recordOptions...)
+	}
+}
+
+// droppedReason returns the option adding a span dropped for reason
+func (m *selfMetrics) droppedReason(reason string) metric.AddOption {
+	return metric.WithAttributeSet(attribute.NewSet(m.with(droppedReasonKey.String(reason))...))
+}
+
+// with returns attrs followed by the static attributes
+func (m *selfMetrics) with(attrs ...attribute.KeyValue) []attribute.KeyValue {
+	return append(attrs, m.static...)
+}
+
+// rateLimitedFor returns the option adding a span dropped by the rate limit for tenant
+func (m *selfMetrics) rateLimitedFor(tenant string) metric.AddOption {
+	if tenant == "" || m.tenants == nil {
+		return m.rateLimited
+	}
+	return metric.WithAttributes(m.with(droppedReasonKey.String("rate_limit"), metricTenant(m.tenants, tenant))...)
+}
+
+// recoverHook recovers a panic of the named user hook, reporting it to the error handler and counting
+// it. It must be deferred.
+func (m *selfMetrics) recoverHook(name string) {
+	if p := recover(); p != nil {
+		m.cfg.handleError(fmt.Errorf("otelfuego: %s panicked: %v", name, p))
+		m.hookPanics.Add(context.Background(), 1, metric.WithAttributes(m.with(hookKey.String(name))...))
+	}
+}
+
+// guardHooks returns a copy of cfg whose user-provided hooks recover from panics, so a buggy hook
+// degrades the instrumentation of a request instead of failing it. Hooks that panic behave as if the
+// request is traced, untrusted and has no name, service, user, tenant, fingerprint, correlated trace or
+// lazy attributes of its own, carries its trace context in its headers and keeps its trace state.
+func (m *selfMetrics) guardHooks(cfg *config) *config {
+	guarded := *cfg
+	if f := cfg.Filter; f != nil {
+		guarded.Filter = func(r *http.Request) (traced bool) {
+			traced = true
+			defer m.recoverHook("filter")
+			return f(r)
+		}
+	}
+	if f := cfg.RouteFilter; f != nil {
+		guarded.RouteFilter = func(r *http.Request, pattern string) (traced bool) {
+			traced = true
+			defer m.recoverHook("route_filter")
+			return f(r, pattern)
+		}
+	}
+	if f := cfg.Enabled; f != nil {
+		guarded.Enabled = func() (enabled bool) {
+			enabled = true
+			defer m.recoverHook("enabled")
+			return f()
+		}
+	}
+	if f := cfg.SpanNameFormatter; f != nil && !isDefaultSpanNameFormatter(f) {
+		guarded.SpanNameFormatter = func(operation string, r *http.Request) (name string) {
+			name = operation
+			defer m.recoverHook("span_name_formatter")
+			return f(operation, r)
+		}
+	}
+	if f := cfg.StripIncomingContext; f != nil {
+		guarded.StripIncomingContext = func(r *http.Request) (untrusted bool) {
+			untrusted = true
+			defer m.recoverHook("strip_incoming_context")
+			return f(r)
+		}
+	}
+	if f := cfg.StripIncomingState; f != nil {
+		guarded.StripIncomingState = func(r *http.Request) (untrusted bool) {
+			untrusted = true
+			defer m.recoverHook("strip_incoming_state")
+			return f(r)
+		}
+	}
+	if f := cfg.CarrierFactory; f != nil {
+		guarded.CarrierFactory = func(r *http.Request) propagation.TextMapCarrier {
+			defer m.recoverHook("carrier_factory")
+			return f(r)
+		}
+	}
+	if f := cfg.ErrorFingerprinter; f != nil {
+		guarded.ErrorFingerprinter = func(errorType, route string, status int) string {
+			defer m.recoverHook("error_fingerprint")
+			return f(errorType, route, status)
+		}
+	}
+	if f := cfg.ServiceNameFunc; f != nil {
+		guarded.ServiceNameFunc = func(r *http.Request) string {
+			defer m.recoverHook("service_name")
+			return f(r)
+		}
+	}
+	if f := cfg.EndUserExtractor; f != nil {
+		guarded.EndUserExtractor = func(r *http.Request) (string, string) {
+			defer m.recoverHook("enduser_extractor")
+			return f(r)
+		}
+	}
+	if f := cfg.TenantExtractor; f != nil {
+		guarded.TenantExtractor = func(r *http.Request) string {
+			defer m.recoverHook("tenant_extractor")
+			return f(r)
+		}
+	}
+	if f := cfg.TraceStateMutator; f != nil {
+		guarded.TraceStateMutator = func(r *http.Request, ts trace.TraceState) (mutated trace.TraceState) {
+			mutated = ts
+			defer m.recoverHook("trace_state_mutator")
+			return f(r, ts)
+		}
+	}
+	if f := cfg.CorrelationIDDecoder; f != nil {
+		guarded.CorrelationIDDecoder = func(value string) (trace.SpanContext, bool) {
+			defer m.recoverHook("correlation_id_decoder")
+			return f(value)
+		}
+	}
+	if len(cfg.LazyAttributes) > 0 {
+		guarded.LazyAttributes = make([]func(*http.Request) []attribute.KeyValue, len(cfg.LazyAttributes))
+		for i, f := range cfg.LazyAttributes {
+			guarded.LazyAttributes[i] = func(r *http.Request) []attribute.KeyValue {
+				defer m.recoverHook("lazy_attributes")
+				return f(r)
+			}
+		}
+	}
+	return &guarded
+}
@@ -0,0 +1,221 @@
+//go:build !otelfuego_minimal
+
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// sumCounter returns the value of the counter name collected by reader, summed over the data points
+// carrying attr, or over all data points when attr is empty
+func sumCounter(t *testing.T, reader sdkmetric.Reader, name string, attr attribute.KeyValue) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok || m.Name != name {
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				if v, ok := dp.Attributes.Value(attr.Key); attr.Key == "" || (ok && v == attr.Value) {
+					total += dp.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestMiddleware_SelfMetrics(t *testing.T) {
+	// Setup in-memory span exporter and metric reader for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithPropagators(propagation.TraceContext{}),
+		otelfuego.WithFilter(otelfuego.HealthCheckFilter()),
+		otelfuego.WithTenantExtractor(func(r *http.Request) string {
+			if r.URL.Path == "/panic" {
+				panic("tenant lookup failed")
+			}
+			return ""
+		}),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+
+	// A panicking hook degrades the instrumentation instead of failing the request
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the request to be served despite the panicking hook, got %d", w.Code)
+	}
+
+	malformed := httptest.NewRequest("GET", "/api/users", nil)
+	malformed.Header.Set("traceparent", "00-not-a-trace-context")
+	handler.ServeHTTP(httptest.NewRecorder(), malformed)
+
+	if spans := exporter.GetSpans(); len(spans) != 3 {
+		t.Errorf("Expected 3 spans, got %d", len(spans))
+	}
+
+	for _, tt := range []struct {
+		name string
+		attr attribute.KeyValue
+		want int64
+	}{
+		{"otelfuego.spans.started", attribute.KeyValue{}, 3},
+		{"otelfuego.spans.dropped", attribute.String("otelfuego.dropped.reason", "filter"), 1},
+		{"otelfuego.hook.panics", attribute.String("otelfuego.hook", "tenant_extractor"), 1},
+		{"otelfuego.propagation.failures", attribute.KeyValue{}, 1},
+	} {
+		if got := sumCounter(t, reader, tt.name, tt.attr); got != tt.want {
+			t.Errorf("Expected %s to be %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMiddleware_WithMaxSpansPerSecond_DroppedSpans(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	// Tenants of dropped spans are bounded like those of request metrics
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithMaxSpansPerSecond(1),
+		otelfuego.WithTenantExtractor(otelfuego.TenantFromHeader("X-Tenant-ID")),
+		otelfuego.WithMaxMetricTenants(1),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tenant := range []string{"acme", "acme", "globex", "initech"} {
+		req := httptest.NewRequest("GET", "/api/users", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for _, tt := range []struct {
+		tenant string
+		want   int64
+	}{
+		{"acme", 1},
+		{"_OTHER", 2},
+		{"globex", 0},
+	} {
+		if got := sumCounter(t, reader, "otelfuego.spans.dropped", attribute.String("tenant.id", tt.tenant)); got != tt.want {
+			t.Errorf("Expected %d spans of tenant %s dropped, got %d", tt.want, tt.tenant, got)
+		}
+	}
+}
+
+func TestMiddleware_WithErrorHandler(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var reported []string
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPropagators(propagation.TraceContext{}),
+		otelfuego.WithErrorFingerprint(nil),
+		otelfuego.WithTenantExtractor(func(r *http.Request) string {
+			panic("tenant lookup failed")
+		}),
+		otelfuego.WithErrorHandler(func(err error) {
+			reported = append(reported, err.Error())
+		}),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	malformed := httptest.NewRequest("GET", "/api/users", nil)
+	malformed.Header.Set("traceparent", "00-not-a-trace-context")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, malformed)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the request to be served despite the failures, got %d", w.Code)
+	}
+
+	want := []string{
+		"otelfuego: invalid options: WithErrorFingerprint: nil fingerprinter",
+		`otelfuego: no valid trace context in Traceparent header "00-not-a-trace-context"`,
+		"otelfuego: tenant_extractor panicked: tenant lookup failed",
+	}
+	if !slices.Equal(reported, want) {
+		t.Errorf("Expected the errors %q to be reported, got %q", want, reported)
+	}
+}
+
+func TestMiddleware_WithOverheadMetric(t *testing.T) {
+	// Setup in-memory span exporter and metric reader for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithMeterProvider(mp),
+		otelfuego.WithOverheadMetric(),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+	}
+
+	points := histogramPoints(t, reader, "otelfuego.overhead")
+	if len(points) != 1 || points[0].Count != 2 {
+		t.Fatalf("Expected 2 overhead measurements, got %+v", points)
+	}
+	overhead := points[0]
+	// The handler time must not be counted as overhead
+	if overhead.Sum <= 0 || overhead.Sum >= 0.02 {
+		t.Errorf("Expected the overhead to exclude the handler time, got %vs", overhead.Sum)
+	}
+}
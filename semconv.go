@@ -0,0 +1,148 @@
+package otelfuego
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+)
+
+// SemconvReporter receives the violations found by WithStrictSemconv. *testing.T and *testing.B
+// satisfy it.
+type SemconvReporter interface {
+	Errorf(format string, args ...any)
+}
+
+// omittedAttributes selects default request attributes the middleware does not record
+type omittedAttributes uint8
+
+const (
+	omitUserAgent omittedAttributes = 1 << iota
+	omitQuery
+	omitURLPath
+)
+
+// appendRequestAttributes appends the HTTP server span attributes known before the request is
+// handled. They are passed at span creation, so they include all sampling-relevant attributes.
+// The user agent and query are truncated to limit bytes. http.route is only recorded once a route
+// matched, as the raw path is unbounded.
+func appendRequestAttributes(attrs []attribute.KeyValue, r *http.Request, limit int, omitted omittedAttributes) []attribute.KeyValue {
+	attrs = append(attrs, semconv.HTTPRequestMethodKey.String(r.Method))
+	if route := routePattern(r); route != "" {
+		attrs = append(attrs, semconv.HTTPRouteKey.String(route))
+	}
+	if omitted&omitURLPath == 0 {
+		attrs = append(attrs, semconv.URLPathKey.String(r.URL.Path))
+	}
+	if omitted&omitUserAgent == 0 {
+		attrs = append(attrs, semconv.UserAgentOriginalKey.String(truncateValue(r.UserAgent(), limit)))
+	}
+	if omitted&omitQuery == 0 {
+		attrs = append(attrs, semconv.URLQueryKey.String(truncateValue(r.URL.RawQuery, limit)))
+	}
+	attrs = append(attrs,
+		semconv.URLSchemeKey.String(requestScheme(r)),
+		semconv.NetworkProtocolVersionKey.String(protocolVersion(r)),
+	)
+
+	if host, port := splitHostPort(r.Host); host != "" {
+		attrs = append(attrs, semconv.ServerAddressKey.String(host))
+		if port > 0 {
+			attrs = append(attrs, semconv.ServerPortKey.Int(port))
+		}
+	}
+	if host, port := splitHostPort(r.RemoteAddr); host != "" {
+		attrs = append(attrs,
+			semconv.ClientAddressKey.String(host),
+			semconv.NetworkPeerAddressKey.String(host),
+		)
+		if port > 0 {
+			attrs = append(attrs, semconv.NetworkPeerPortKey.Int(port))
+		}
+	}
+	return attrs
+}
+
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// protocolVersion returns the HTTP version in the semconv format, e.g. "1.1" or "2"
+func protocolVersion(r *http.Request) string {
+	if r.ProtoMajor >= 2 && r.ProtoMinor == 0 {
+		return strconv.Itoa(r.ProtoMajor)
+	}
+	return strconv.Itoa(r.ProtoMajor) + "." + strconv.Itoa(r.ProtoMinor)
+}
+
+// splitHostPort splits an address with an optional port. The port is 0 when absent or invalid.
+func splitHostPort(addr string) (string, int) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return strings.Trim(addr, "[]"), 0
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return host, 0
+	}
+	return host, port
+}
+
+// attributeReader is implemented by SDK spans, which expose the attributes recorded so far
+type attributeReader interface {
+	Attributes() []attribute.KeyValue
+}
+
+// semconvGaps returns the HTTP server span attributes required or recommended by the semantic
+// conventions (v1.27.0) that are missing from attrs, given the request and response status. Omitted
+// attributes are not reported.
+func semconvGaps(attrs []attribute.KeyValue, r *http.Request, statusCode int, omitted omittedAttributes) []string {
+	present := make(map[attribute.Key]bool, len(attrs))
+	for _, kv := range attrs {
+		present[kv.Key] = true
+	}
+
+	// Required, or conditionally required when the condition is met
+	expected := []attribute.Key{
+		semconv.HTTPRequestMethodKey,
+		semconv.URLSchemeKey,
+		semconv.HTTPResponseStatusCodeKey,
+	}
+	if omitted&omitURLPath == 0 {
+		expected = append(expected, semconv.URLPathKey)
+	}
+	if r.URL.RawQuery != "" && omitted&omitQuery == 0 {
+		expected = append(expected, semconv.URLQueryKey)
+	}
+	if r.Pattern != "" {
+		expected = append(expected, semconv.HTTPRouteKey)
+	}
+	if statusCode >= 500 {
+		expected = append(expected, semconv.ErrorTypeKey)
+	}
+
+	// Recommended
+	expected = append(expected,
+		semconv.ServerAddressKey,
+		semconv.ClientAddressKey,
+		semconv.NetworkPeerAddressKey,
+		semconv.NetworkProtocolVersionKey,
+	)
+	if r.UserAgent() != "" && omitted&omitUserAgent == 0 {
+		expected = append(expected, semconv.UserAgentOriginalKey)
+	}
+
+	var gaps []string
+	for _, key := range expected {
+		if !present[key] {
+			gaps = append(gaps, string(key))
+		}
+	}
+	return gaps
+}
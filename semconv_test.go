@@ -0,0 +1,225 @@
+package otelfuego_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// semconvRecorder collects the violations reported by WithStrictSemconv
+type semconvRecorder struct {
+	errors []string
+}
+
+func (r *semconvRecorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMiddleware_SemconvAttributes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithStrictSemconv(t),
+	)(mux)
+
+	req := httptest.NewRequest("GET", "http://api.example.com:8443/users/42?expand=orders", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "test-agent")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	expected := map[attribute.Key]attribute.Value{
+		"url.scheme":               attribute.StringValue("http"),
+		"server.address":           attribute.StringValue("api.example.com"),
+		"server.port":              attribute.IntValue(8443),
+		"client.address":           attribute.StringValue("203.0.113.7"),
+		"network.peer.address":     attribute.StringValue("203.0.113.7"),
+		"network.peer.port":        attribute.IntValue(51234),
+		"network.protocol.version": attribute.StringValue("1.1"),
+		"http.route":               attribute.StringValue("/users/{id}"),
+		"error.type":               attribute.StringValue("500"),
+	}
+	for key, want := range expected {
+		if got, _ := attrs.Value(key); got != want {
+			t.Errorf("Expected %s=%s, got %s", key, want.Emit(), got.Emit())
+		}
+	}
+}
+
+func TestMiddleware_UnmatchedRoute(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// The raw path of unmatched requests is unbounded and must not stand in for the route
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))(mux)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/wp-login.php", nil))
+
+	attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+	if route, ok := attrs.Value("http.route"); ok {
+		t.Errorf("Expected no http.route for unmatched requests, got '%s'", route.AsString())
+	}
+	if path, _ := attrs.Value("url.path"); path.AsString() != "/wp-login.php" {
+		t.Errorf("Expected url.path '/wp-login.php', got '%s'", path.AsString())
+	}
+}
+
+func TestMiddleware_WithStrictSemconv(t *testing.T) {
+	// Attribute limits make the SDK drop attributes, producing non-compliant spans
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithRawSpanLimits(sdktrace.SpanLimits{AttributeCountLimit: 3}),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	reporter := &semconvRecorder{}
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithStrictSemconv(reporter),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+
+	if len(reporter.errors) != 1 {
+		t.Fatalf("Expected 1 violation report, got %v", reporter.errors)
+	}
+	if !strings.Contains(reporter.errors[0], "url.scheme") || !strings.Contains(reporter.errors[0], "http.response.status_code") {
+		t.Errorf("Expected report to list missing attributes, got '%s'", reporter.errors[0])
+	}
+}
+
+func TestMiddleware_WithoutDefaultAttributes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	// Omitted attributes are not reported as semconv violations
+	reporter := &semconvRecorder{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{email}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithStrictSemconv(reporter),
+		otelfuego.WithoutUserAgent(),
+		otelfuego.WithoutQuery(),
+		otelfuego.WithoutURLPath(),
+	)(mux)
+
+	for _, path := range []string{"/users/jane@example.com?token=secret", "/unknown"} {
+		exporter.Reset()
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("User-Agent", "test-agent")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+		for _, key := range []attribute.Key{"user_agent.original", "url.query", "url.path"} {
+			if attrs.HasValue(key) {
+				t.Errorf("%s: expected no %s attribute", path, key)
+			}
+		}
+		route, hasRoute := attrs.Value("http.route")
+		if path == "/unknown" && hasRoute {
+			t.Errorf("Expected no http.route for unmatched requests, got '%s'", route.AsString())
+		}
+		if path != "/unknown" && route.AsString() != "/users/{email}" {
+			t.Errorf("Expected http.route '/users/{email}', got '%s'", route.AsString())
+		}
+	}
+	if len(reporter.errors) != 0 {
+		t.Errorf("Expected no violation reports, got %v", reporter.errors)
+	}
+}
+
+func TestMiddleware_WithSemconvMode(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tests := []struct {
+		name    string
+		opts    []otelfuego.Option
+		env     string
+		wantOld bool
+		wantNew bool
+	}{
+		{name: "default", wantNew: true},
+		{name: "env dup", env: "http/dup", wantOld: true, wantNew: true},
+		{name: "old", opts: []otelfuego.Option{otelfuego.WithSemconvMode(otelfuego.SemconvOld)}, env: "http/dup", wantOld: true},
+		{name: "dup", opts: []otelfuego.Option{otelfuego.WithSemconvMode(otelfuego.SemconvDup)}, wantOld: true, wantNew: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OTEL_SEMCONV_STABILITY_OPT_IN", tt.env)
+			exporter.Reset()
+
+			handler := otelfuego.Middleware("test-service",
+				append([]otelfuego.Option{otelfuego.WithTracerProvider(tp)}, tt.opts...)...,
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users?notify=true", nil))
+
+			attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+			for _, key := range []attribute.Key{"http.request.method", "http.response.status_code", "url.path"} {
+				if _, ok := attrs.Value(key); ok != tt.wantNew {
+					t.Errorf("Expected %s present=%v", key, tt.wantNew)
+				}
+			}
+			for _, key := range []attribute.Key{"http.method", "http.status_code", "net.host.name"} {
+				if _, ok := attrs.Value(key); ok != tt.wantOld {
+					t.Errorf("Expected %s present=%v", key, tt.wantOld)
+				}
+			}
+			if tt.wantOld {
+				if v, _ := attrs.Value("http.target"); v.AsString() != "/users?notify=true" {
+					t.Errorf("Expected http.target '/users?notify=true', got '%s'", v.AsString())
+				}
+				if v, _ := attrs.Value("http.status_code"); v.AsInt64() != http.StatusCreated {
+					t.Errorf("Expected http.status_code 201, got %d", v.AsInt64())
+				}
+			}
+		})
+	}
+}
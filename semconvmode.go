@@ -0,0 +1,89 @@
+package otelfuego
+
+import (
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+)
+
+// SemconvMode selects the generation of HTTP semantic convention attributes emitted by the middleware
+type SemconvMode int
+
+const (
+	// SemconvStable emits the stable HTTP attributes, e.g. http.request.method
+	SemconvStable SemconvMode = iota + 1
+	// SemconvOld emits the attributes of the conventions preceding v1.20.0, e.g. http.method
+	SemconvOld
+	// SemconvDup emits both the stable and the old attributes, for migrations
+	SemconvDup
+)
+
+// envSemconvStabilityOptIn is the environment variable of the OpenTelemetry specification selecting
+// the semantic conventions generation
+const envSemconvStabilityOptIn = "OTEL_SEMCONV_STABILITY_OPT_IN"
+
+// semconvModeFromEnv returns the mode selected by OTEL_SEMCONV_STABILITY_OPT_IN: SemconvDup for
+// "http/dup", and SemconvStable otherwise, as this package always emitted the stable attributes
+func semconvModeFromEnv() SemconvMode {
+	for _, v := range strings.Split(os.Getenv(envSemconvStabilityOptIn), ",") {
+		if strings.TrimSpace(v) == "http/dup" {
+			return SemconvDup
+		}
+	}
+	return SemconvStable
+}
+
+// oldAttributeKeys maps the stable HTTP attributes to their pre-v1.20.0 equivalents
+var oldAttributeKeys = map[attribute.Key]attribute.Key{
+	semconv.HTTPRequestMethodKey:      "http.method",
+	semconv.URLPathKey:                "http.target",
+	semconv.URLSchemeKey:              "http.scheme",
+	semconv.UserAgentOriginalKey:      "http.user_agent",
+	semconv.NetworkProtocolVersionKey: "http.flavor",
+	semconv.ServerAddressKey:          "net.host.name",
+	semconv.ServerPortKey:             "net.host.port",
+	semconv.ClientAddressKey:          "http.client_ip",
+	semconv.NetworkPeerAddressKey:     "net.sock.peer.addr",
+	semconv.NetworkPeerPortKey:        "net.sock.peer.port",
+	semconv.HTTPResponseStatusCodeKey: "http.status_code",
+	semconv.HTTPRequestBodySizeKey:    "http.request_content_length",
+	semconv.HTTPResponseBodySizeKey:   "http.response_content_length",
+}
+
+// apply converts stable attributes to the old ones in SemconvOld mode, and appends the old attributes
+// to the stable ones in SemconvDup mode. The old http.target includes the query string.
+func (m SemconvMode) apply(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if m != SemconvOld && m != SemconvDup {
+		return attrs
+	}
+
+	n := len(attrs)
+	for i := 0; i < n; i++ {
+		old, ok := oldAttributeKeys[attrs[i].Key]
+		if !ok {
+			continue
+		}
+		kv := attribute.KeyValue{Key: old, Value: attrs[i].Value}
+		if attrs[i].Key == semconv.URLPathKey {
+			kv.Value = attribute.StringValue(targetWithQuery(attrs[i].Value.AsString(), attrs[:n]))
+		}
+		if m == SemconvDup {
+			attrs = append(attrs, kv)
+		} else {
+			attrs[i] = kv
+		}
+	}
+	return attrs
+}
+
+// targetWithQuery appends the url.query attribute found in attrs to path
+func targetWithQuery(path string, attrs []attribute.KeyValue) string {
+	for _, kv := range attrs {
+		if kv.Key == semconv.URLQueryKey && kv.Value.AsString() != "" {
+			return path + "?" + kv.Value.AsString()
+		}
+	}
+	return path
+}
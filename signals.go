@@ -0,0 +1,66 @@
+package otelfuego
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/pdrvsky/otelfuego/internal/signals"
+)
+
+func init() {
+	signals.MetricsMiddleware = func(opts ...any) func(http.Handler) http.Handler {
+		return newMetricsMiddleware(signalConfig(opts))
+	}
+	signals.AccessLogMiddleware = func(logger *slog.Logger, opts ...any) func(http.Handler) http.Handler {
+		return newAccessLogMiddleware(logger, signalConfig(opts))
+	}
+}
+
+// signalConfig returns the config of the options passed by a signal package, reporting invalid options
+// like Middleware does
+func signalConfig(opts []any) *config {
+	options := make([]Option, len(opts))
+	for i, opt := range opts {
+		options[i] = opt.(Option)
+	}
+	cfg := newConfig(options...)
+	if err := cfg.validate(); err != nil {
+		cfg.handleError(err)
+	}
+	return cfg
+}
+
+// included reports whether r passes the enabled function, filter and route filter of cfg
+func (cfg *config) included(r *http.Request) bool {
+	return (cfg.Enabled == nil || cfg.Enabled()) &&
+		(cfg.Filter == nil || cfg.Filter(r)) &&
+		(cfg.RouteFilter == nil || cfg.RouteFilter(r, matchedPattern(r, cfg.RouteFilterMux)))
+}
+
+// newMetricsMiddleware returns a middleware recording the request metrics of cfg without tracing. The
+// filters, route filters and enabled function of cfg apply as they do to spans.
+func newMetricsMiddleware(cfg *config) func(http.Handler) http.Handler {
+	cfg.RequestMetrics = true
+	self := newSelfMetrics(cfg)
+	cfg = self.guardHooks(cfg)
+	metrics := newRequestMetrics(cfg)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.included(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			wrapped := acquireResponseWriter(w)
+			defer releaseResponseWriter(wrapped)
+			next.ServeHTTP(wrapped, r)
+			if !wrapped.firstByte.IsZero() {
+				metrics.recordTimeToFirstByte(r.Context(), r, wrapped.statusCode, wrapped.firstByte.Sub(start))
+			}
+			metrics.recordDuration(r.Context(), r, wrapped.statusCode, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package otelfuego
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	singleflightKeyKey       = attribute.Key("singleflight.key")
+	singleflightCoalescedKey = attribute.Key("singleflight.coalesced")
+	singleflightFollowersKey = attribute.Key("singleflight.followers")
+)
+
+// errSingleflightPanicked is returned to followers when the leader's function panicked
+var errSingleflightPanicked = errors.New("otelfuego: singleflight function panicked")
+
+// Singleflight deduplicates concurrent calls doing identical work and makes the coalescing
+// visible in traces. The zero value is ready to use.
+//
+// The first caller for a key (the leader) runs the function in a "singleflight.do" child span.
+// Concurrent callers with the same key (followers) wait for the leader's result instead, and their
+// spans get a link to the leader's span. Every caller's span records singleflight.coalesced.
+//
+// Example:
+//
+//	var users otelfuego.Singleflight[User]
+//
+//	func getUser(c fuego.ContextNoBody) (User, error) {
+//	    id := c.PathParam("id")
+//	    return users.Do(c.Context(), id, func(ctx context.Context) (User, error) {
+//	        return store.User(ctx, id)
+//	    })
+//	}
+type Singleflight[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*singleflightCall[T]
+}
+
+// singleflightCall is an in-flight or completed Singleflight.Do call
+type singleflightCall[T any] struct {
+	done      chan struct{}
+	leader    trace.SpanContext
+	followers int
+
+	val T
+	err error
+}
+
+// Do runs fn once for all concurrent callers with the same key and returns its results to each
+// of them. fn receives the leader's context, so followers share the leader's cancellation.
+func (g *Singleflight[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	span := trace.SpanFromContext(ctx)
+
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*singleflightCall[T])
+	}
+	if c, ok := g.calls[key]; ok {
+		c.followers++
+		g.mu.Unlock()
+
+		span.SetAttributes(singleflightKeyKey.String(key), singleflightCoalescedKey.Bool(true))
+		if c.leader.IsValid() {
+			span.AddLink(trace.Link{SpanContext: c.leader})
+		}
+
+		select {
+		case <-c.done:
+			return c.val, c.err
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+
+	ctx, leaderSpan := tracerFromContext(ctx).Start(ctx, "singleflight.do",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(singleflightKeyKey.String(key)),
+	)
+	defer leaderSpan.End()
+
+	c := &singleflightCall[T]{
+		done:   make(chan struct{}),
+		leader: leaderSpan.SpanContext(),
+		err:    errSingleflightPanicked,
+	}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	span.SetAttributes(singleflightKeyKey.String(key), singleflightCoalescedKey.Bool(false))
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		followers := c.followers
+		g.mu.Unlock()
+		close(c.done)
+
+		leaderSpan.SetAttributes(singleflightFollowersKey.Int(followers))
+	}()
+
+	c.val, c.err = fn(ctx)
+	if c.err != nil {
+		limit := attributeValueLimit(ctx)
+		recordError(leaderSpan, c.err, limit)
+		leaderSpan.SetStatus(codes.Error, truncateValue(c.err.Error(), limit))
+	}
+	return c.val, c.err
+}
@@ -0,0 +1,107 @@
+package otelfuego_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// waitingContext signals once Done has been called, i.e. once a follower started waiting
+type waitingContext struct {
+	context.Context
+	once    sync.Once
+	waiting chan struct{}
+}
+
+func (c *waitingContext) Done() <-chan struct{} {
+	c.once.Do(func() { close(c.waiting) })
+	return c.Context.Done()
+}
+
+func TestSingleflight(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var group otelfuego.Singleflight[string]
+	tracer := tp.Tracer("test")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	calls := 0
+
+	var wg sync.WaitGroup
+	results := make([]string, 2)
+
+	leaderCtx, leaderRequest := tracer.Start(context.Background(), "leader-request")
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _ = group.Do(leaderCtx, "user:42", func(ctx context.Context) (string, error) {
+			calls++
+			close(started)
+			<-release
+			return "Ada", nil
+		})
+		leaderRequest.End()
+	}()
+	<-started
+
+	followerCtx, followerRequest := tracer.Start(context.Background(), "follower-request")
+	waiting := &waitingContext{Context: followerCtx, waiting: make(chan struct{})}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1], _ = group.Do(waiting, "user:42", func(ctx context.Context) (string, error) {
+			calls++
+			return "unexpected", nil
+		})
+		followerRequest.End()
+	}()
+	<-waiting.waiting
+
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected function to run once, ran %d times", calls)
+	}
+	if results[0] != "Ada" || results[1] != "Ada" {
+		t.Errorf("Expected both callers to get 'Ada', got %v", results)
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	do := spans["singleflight.do"]
+	if do.Parent.SpanID() != leaderRequest.SpanContext().SpanID() {
+		t.Error("Expected singleflight.do to be a child of the leader request")
+	}
+	doAttrs := attribute.NewSet(do.Attributes...)
+	if v, _ := doAttrs.Value("singleflight.followers"); v.AsInt64() != 1 {
+		t.Errorf("Expected 1 follower, got %d", v.AsInt64())
+	}
+
+	for name, coalesced := range map[string]bool{"leader-request": false, "follower-request": true} {
+		attrs := attribute.NewSet(spans[name].Attributes...)
+		if v, _ := attrs.Value("singleflight.coalesced"); v.AsBool() != coalesced {
+			t.Errorf("Expected %s coalesced=%v, got %v", name, coalesced, v.AsBool())
+		}
+	}
+
+	links := spans["follower-request"].Links
+	if len(links) != 1 || links[0].SpanContext.SpanID() != do.SpanContext.SpanID() {
+		t.Errorf("Expected follower to link to the leader's singleflight.do span, got %v", links)
+	}
+}
@@ -0,0 +1,93 @@
+package otelfuego
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	bodyMismatchKey = attribute.Key("request.body.mismatch")
+
+	// sniffLength is the number of leading body bytes inspected by the sniffer
+	sniffLength = 64
+)
+
+// Body mismatches recorded in request.body.mismatch
+const (
+	mismatchJSONMarkup       = "json_declared_markup_sent"
+	mismatchJSONInvalid      = "json_declared_not_json"
+	mismatchFormJSON         = "form_declared_json_sent"
+	mismatchGzipUncompressed = "gzip_declared_not_compressed"
+	mismatchUndeclaredGzip   = "gzip_undeclared"
+)
+
+// sniffBody compares the leading bytes of the request body with its declared Content-Type and
+// Content-Encoding, returning the mismatches found. The body is restored for the next handler.
+func sniffBody(r *http.Request) []string {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	prefix := make([]byte, sniffLength)
+	n, err := io.ReadFull(r.Body, prefix)
+	prefix = prefix[:n]
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+	if n == 0 && err != nil {
+		return nil
+	}
+
+	var mismatches []string
+	gzipped := len(prefix) >= 2 && prefix[0] == 0x1f && prefix[1] == 0x8b
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	switch {
+	case encoding == "gzip" && !gzipped:
+		mismatches = append(mismatches, mismatchGzipUncompressed)
+	case encoding == "" && gzipped:
+		mismatches = append(mismatches, mismatchUndeclaredGzip)
+	}
+	if encoding != "" || gzipped {
+		// The content type describes the decoded body
+		return mismatches
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	first := firstNonSpace(prefix)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if first == '<' {
+			mismatches = append(mismatches, mismatchJSONMarkup)
+		} else if first != 0 && strings.IndexByte(`{["-0123456789tfn`, first) < 0 {
+			mismatches = append(mismatches, mismatchJSONInvalid)
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if first == '{' || first == '[' {
+			mismatches = append(mismatches, mismatchFormJSON)
+		}
+	}
+	return mismatches
+}
+
+// firstNonSpace returns the first byte of data that is not JSON whitespace, or 0
+func firstNonSpace(data []byte) byte {
+	for _, c := range data {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return c
+		}
+	}
+	return 0
+}
+
+// rejectBodyMismatch answers a request whose body does not match its declared content
+func rejectBodyMismatch(w http.ResponseWriter) {
+	http.Error(w, "request body does not match its Content-Type or Content-Encoding", http.StatusUnsupportedMediaType)
+}
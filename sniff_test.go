@@ -0,0 +1,87 @@
+package otelfuego_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithBodySniffing(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	_, _ = zw.Write([]byte(`{"name": "Ada"}`))
+	_ = zw.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		encoding    string
+		body        string
+		reject      bool
+		want        string
+		wantStatus  int
+	}{
+		{name: "valid json", contentType: "application/json", body: ` {"name": "Ada"}`, want: "", wantStatus: 200},
+		{name: "html as json", contentType: "application/json; charset=utf-8", body: "<html></html>", want: "json_declared_markup_sent", wantStatus: 200},
+		{name: "text as json", contentType: "application/problem+json", body: "id=42", want: "json_declared_not_json", wantStatus: 200},
+		{name: "json as form", contentType: "application/x-www-form-urlencoded", body: `{"name": "Ada"}`, want: "form_declared_json_sent", wantStatus: 200},
+		{name: "uncompressed gzip", contentType: "application/json", encoding: "gzip", body: `{"name": "Ada"}`, want: "gzip_declared_not_compressed", wantStatus: 200},
+		{name: "compressed gzip", contentType: "application/json", encoding: "gzip", body: gzipped.String(), want: "", wantStatus: 200},
+		{name: "undeclared gzip", contentType: "application/json", body: gzipped.String(), want: "gzip_undeclared", wantStatus: 200},
+		{name: "rejected", contentType: "application/json", body: "<html></html>", reject: true, want: "json_declared_markup_sent", wantStatus: 415},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			var body []byte
+			handler := otelfuego.Middleware("test-service",
+				otelfuego.WithTracerProvider(tp),
+				otelfuego.WithBodySniffing(tt.reject),
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("POST", "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusOK && string(body) != tt.body {
+				t.Errorf("Expected handler to read the complete body %q, got %q", tt.body, body)
+			}
+
+			attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+			v, _ := attrs.Value("request.body.mismatch")
+			if got := strings.Join(v.AsStringSlice(), ","); got != tt.want {
+				t.Errorf("Expected mismatch '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
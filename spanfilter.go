@@ -0,0 +1,93 @@
+package otelfuego
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanFilterRule describes request spans dropped by the processor returned by NewFilterSpanProcessor.
+// A server span matches a rule when all of its set fields match; a rule without any field set matches
+// every server span.
+type SpanFilterRule struct {
+	// Route matches spans whose http.route is Route
+	Route string
+	// StatusCodes matches spans whose response status code is one of StatusCodes
+	StatusCodes []int
+	// MaxDuration matches spans shorter than MaxDuration
+	MaxDuration time.Duration
+}
+
+// matches reports whether span matches the rule
+func (rule SpanFilterRule) matches(span sdktrace.ReadOnlySpan) bool {
+	if rule.MaxDuration > 0 && span.EndTime().Sub(span.StartTime()) >= rule.MaxDuration {
+		return false
+	}
+	if rule.Route == "" && len(rule.StatusCodes) == 0 {
+		return true
+	}
+
+	var route string
+	status := -1
+	for _, kv := range span.Attributes() {
+		switch kv.Key {
+		case semconv.HTTPRouteKey:
+			route = kv.Value.AsString()
+		case semconv.HTTPResponseStatusCodeKey, oldAttributeKeys[semconv.HTTPResponseStatusCodeKey]:
+			status = int(kv.Value.AsInt64())
+		}
+	}
+	if rule.Route != "" && route != rule.Route {
+		return false
+	}
+	return len(rule.StatusCodes) == 0 || slices.Contains(rule.StatusCodes, status)
+}
+
+// filterSpanProcessor drops the ended server spans matching any of its rules instead of passing them
+// on to the wrapped processor
+type filterSpanProcessor struct {
+	next  sdktrace.SpanProcessor
+	rules []SpanFilterRule
+}
+
+// NewFilterSpanProcessor returns a span processor passing spans on to next, except for the server spans
+// matching any of rules, which are dropped when they end. Unlike filters and samplers, which decide
+// before the request is served, it can drop spans by their outcome, such as fast successful health
+// checks or 404 responses to scanners. Spans started under dropped spans are still exported, so rules
+// are best suited to requests without child spans.
+//
+// Example:
+//
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(otelfuego.NewFilterSpanProcessor(
+//	    sdktrace.NewBatchSpanProcessor(exporter),
+//	    otelfuego.SpanFilterRule{Route: "/health", StatusCodes: []int{200}, MaxDuration: 50 * time.Millisecond},
+//	    otelfuego.SpanFilterRule{StatusCodes: []int{404}},
+//	)))
+func NewFilterSpanProcessor(next sdktrace.SpanProcessor, rules ...SpanFilterRule) sdktrace.SpanProcessor {
+	return &filterSpanProcessor{next: next, rules: rules}
+}
+
+func (p *filterSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *filterSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanKind() == trace.SpanKindServer && slices.ContainsFunc(p.rules, func(rule SpanFilterRule) bool {
+		return rule.matches(s)
+	}) {
+		return
+	}
+	p.next.OnEnd(s)
+}
+
+func (p *filterSpanProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+func (p *filterSpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
@@ -0,0 +1,65 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewFilterSpanProcessor(t *testing.T) {
+	// Setup in-memory span exporter behind the filtering processor
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(otelfuego.NewFilterSpanProcessor(
+			sdktrace.NewSimpleSpanProcessor(exporter),
+			otelfuego.SpanFilterRule{Route: "/health", StatusCodes: []int{http.StatusOK}, MaxDuration: time.Second},
+			otelfuego.SpanFilterRule{StatusCodes: []int{http.StatusNotFound}},
+		)),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("fail") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tp.Tracer("test").Start(r.Context(), "db.query")
+		span.End()
+	})
+	handler := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))(mux)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/health", nil},
+		{"/health?fail", []string{"GET /health"}},
+		{"/missing", nil},
+		{"/users/42", []string{"db.query", "GET /users/{id}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			exporter.Reset()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+
+			spans := exporter.GetSpans()
+			if len(spans) != len(tt.want) {
+				t.Fatalf("Expected %d spans, got %d", len(tt.want), len(spans))
+			}
+			for i, name := range tt.want {
+				if spans[i].Name != name {
+					t.Errorf("Expected span %d to be '%s', got '%s'", i, name, spans[i].Name)
+				}
+			}
+		})
+	}
+}
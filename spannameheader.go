@@ -0,0 +1,34 @@
+package otelfuego
+
+import "net/http"
+
+// maxHeaderSpanNameLength bounds the length of span names accepted by the default header validator
+const maxHeaderSpanNameLength = 128
+
+// spanNameHeader names spans after a request header set by a trusted gateway
+type spanNameHeader struct {
+	header    string
+	validator func(string) bool
+}
+
+// spanName returns the validated span name carried by r, if any
+func (h *spanNameHeader) spanName(r *http.Request) (string, bool) {
+	name := r.Header.Get(h.header)
+	if name == "" || !h.validator(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// validHeaderSpanName accepts printable ASCII names of up to maxHeaderSpanNameLength bytes
+func validHeaderSpanName(name string) bool {
+	if len(name) > maxHeaderSpanNameLength {
+		return false
+	}
+	for _, c := range []byte(name) {
+		if c < ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
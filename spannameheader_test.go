@@ -0,0 +1,102 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithSpanNameFromHeader(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	ops, err := otelfuego.ParseOpenAPI([]byte(`{"paths": {"/users/{id}": {"get": {"operationId": "getUser"}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name      string
+		validator func(string) bool
+		header    string
+		want      string
+	}{
+		{name: "default validator", header: "users.get", want: "users.get"},
+		{name: "default validator rejects", header: strings.Repeat("x", 200), want: "getUser"},
+		{name: "custom validator", validator: func(name string) bool { return name == "users.get" }, header: "users.get", want: "users.get"},
+		{name: "custom validator rejects", validator: func(name string) bool { return false }, header: "users.get", want: "getUser"},
+		{name: "missing header", want: "getUser"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+
+			handler := otelfuego.Middleware("test-service",
+				otelfuego.WithTracerProvider(tp),
+				otelfuego.WithOpenAPIOperations(ops),
+				otelfuego.WithSpanNameFromHeader("X-Operation-Name", tt.validator),
+			)(mux)
+
+			req := httptest.NewRequest("GET", "/users/42", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Operation-Name", tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if name := exporter.GetSpans()[0].Name; name != tt.want {
+				t.Errorf("Expected span name '%s', got '%s'", tt.want, name)
+			}
+		})
+	}
+}
+
+func TestMiddleware_WithSpanNameFromHeader_MaxSpanNames(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithSpanNameFromHeader("X-Operation-Name", nil),
+		otelfuego.WithMaxSpanNames(1),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Names sent by the gateway count towards the limit like any other span name
+	for _, name := range []string{"users.get", "users.list"} {
+		req := httptest.NewRequest("GET", "/users", nil)
+		req.Header.Set("X-Operation-Name", name)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name != "users.get" {
+		t.Errorf("Expected span name 'users.get', got '%s'", spans[0].Name)
+	}
+	if spans[1].Name == "users.list" {
+		t.Error("Expected the span name past the limit to be collapsed")
+	}
+}
@@ -0,0 +1,61 @@
+package otelfuego
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// stateKey is the context key under which the middleware stores the per-request state
+type stateKey struct{}
+
+// instrumentedKey is the context key marking requests an otelfuego middleware decided to trace, whether
+// their span is sampled or not, so nested middlewares don't start a second server span
+type instrumentedKey struct{}
+
+// requestState carries per-request instrumentation state from the middleware to the
+// handler-facing helpers
+type requestState struct {
+	tracer trace.Tracer
+	cfg    *config
+
+	// controllerEnd is set by Controller once the wrapped controller returned
+	controllerEnd time.Time
+
+	// logEvents counts the log events recorded through LogBridge
+	logEvents atomic.Int64
+
+	// tenant is the tenant found by the configured TenantExtractor
+	tenant string
+
+	// errorRecorded is set once RecordError set error.type on the span
+	errorRecorded bool
+
+	// errorType is the Go type of the last error recorded with RecordError
+	errorType string
+}
+
+// withRequestState returns a copy of ctx carrying state
+func withRequestState(ctx context.Context, state *requestState) context.Context {
+	return context.WithValue(ctx, stateKey{}, state)
+}
+
+// requestStateFromContext returns the state stored by the middleware, or nil outside of it
+func requestStateFromContext(ctx context.Context) *requestState {
+	state, _ := ctx.Value(stateKey{}).(*requestState)
+	return state
+}
+
+// tracerFromContext returns the middleware's tracer for the request in ctx, falling back to a
+// tracer from the provider of the span in ctx when called outside of the middleware
+func tracerFromContext(ctx context.Context) trace.Tracer {
+	if state := requestStateFromContext(ctx); state != nil {
+		return state.tracer
+	}
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(
+		instrumentationName,
+		trace.WithInstrumentationVersion(instrumentationVersion),
+	)
+}
@@ -0,0 +1,110 @@
+package otelfuego
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	tenantIDKey = attribute.Key("tenant.id")
+
+	// maxTenantIDLength bounds the length of recorded tenant identifiers
+	maxTenantIDLength = 64
+
+	// defaultMaxMetricTenants bounds the number of distinct tenants recorded on metrics
+	defaultMaxMetricTenants = 100
+
+	// otherTenant replaces the tenants recorded on metrics past the limit of WithMaxMetricTenants
+	otherTenant = "_OTHER"
+)
+
+// TenantExtractor returns the tenant a request belongs to, or an empty string if unknown
+type TenantExtractor func(r *http.Request) string
+
+// TenantFromHeader returns a TenantExtractor reading the tenant from the named request header
+func TenantFromHeader(name string) TenantExtractor {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// TenantFromSubdomain returns a TenantExtractor reading the tenant from the leftmost label of the
+// request host, e.g. "acme" for acme.example.com. Hosts with fewer than three labels and IP
+// addresses have no tenant.
+func TenantFromSubdomain() TenantExtractor {
+	return func(r *http.Request) string {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) != nil || strings.Count(host, ".") < 2 {
+			return ""
+		}
+		label, _, _ := strings.Cut(host, ".")
+		return strings.ToLower(label)
+	}
+}
+
+// TenantFromContext returns a TenantExtractor reading the tenant from the request context value
+// stored under key, typically by an authentication middleware running earlier in the chain. The
+// value must be a string or a fmt.Stringer.
+func TenantFromContext(key any) TenantExtractor {
+	return func(r *http.Request) string {
+		switch v := r.Context().Value(key).(type) {
+		case string:
+			return v
+		case fmt.Stringer:
+			return v.String()
+		}
+		return ""
+	}
+}
+
+// tenantID returns the tenant of the request found by extract, truncated to maxTenantIDLength
+func tenantID(extract TenantExtractor, r *http.Request) string {
+	return truncateValue(extract(r), maxTenantIDLength)
+}
+
+// tenantOf returns the tenant of r recorded by the middleware in ctx, or found by extract, which may be
+// nil, when the request is not traced
+func tenantOf(ctx context.Context, extract TenantExtractor, r *http.Request) string {
+	if state := requestStateFromContext(ctx); state != nil && state.tenant != "" {
+		return state.tenant
+	}
+	if extract == nil {
+		return ""
+	}
+	return tenantID(extract, r)
+}
+
+// newMetricTenants returns the guard bounding the tenants recorded on metrics, or nil when metrics do
+// not record tenants
+func newMetricTenants(cfg *config) *cardinalityGuard {
+	if cfg.TenantExtractor == nil || cfg.MaxMetricTenants <= 0 {
+		return nil
+	}
+	return newCardinalityGuard(cfg.MaxMetricTenants)
+}
+
+// metricTenant returns the tenant.id attribute of metrics for tenant, replaced by _OTHER when tenants
+// guards the number of tenants and it has no room left for tenant
+func metricTenant(tenants *cardinalityGuard, tenant string) attribute.KeyValue {
+	if !tenants.allow(tenant) {
+		tenant = otherTenant
+	}
+	return tenantIDKey.String(tenant)
+}
+
+// TenantFromRequest returns the tenant recorded by the middleware for the request in ctx, or an
+// empty string outside of the middleware or when no tenant was found
+func TenantFromRequest(ctx context.Context) string {
+	if state := requestStateFromContext(ctx); state != nil {
+		return state.tenant
+	}
+	return ""
+}
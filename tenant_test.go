@@ -0,0 +1,92 @@
+package otelfuego_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithTenantExtractor(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithTenantExtractor(otelfuego.TenantFromHeader("X-Tenant-ID")),
+	)
+
+	var output bytes.Buffer
+	var tenant string
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = otelfuego.TenantFromRequest(r.Context())
+		logger := slog.New(otelfuego.LogBridge(r.Context(), slog.NewTextHandler(&output, nil)))
+		logger.Info("listing invoices")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/invoices", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if tenant != "acme" {
+		t.Errorf("Expected TenantFromRequest to return 'acme', got '%s'", tenant)
+	}
+	if !strings.Contains(output.String(), "tenant.id=acme") {
+		t.Errorf("Expected log records to carry tenant.id, got %q", output.String())
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("tenant.id"); v.AsString() != "acme" {
+		t.Errorf("Expected tenant.id 'acme', got '%s'", v.AsString())
+	}
+}
+
+func TestTenantExtractors(t *testing.T) {
+	type tenantKey struct{}
+
+	tests := []struct {
+		name     string
+		extract  otelfuego.TenantExtractor
+		host     string
+		ctxValue any
+		expected string
+	}{
+		{"subdomain", otelfuego.TenantFromSubdomain(), "Acme.example.com", nil, "acme"},
+		{"subdomain with port", otelfuego.TenantFromSubdomain(), "acme.example.com:8080", nil, "acme"},
+		{"apex domain", otelfuego.TenantFromSubdomain(), "example.com", nil, ""},
+		{"ip address", otelfuego.TenantFromSubdomain(), "10.0.0.1", nil, ""},
+		{"context string", otelfuego.TenantFromContext(tenantKey{}), "example.com", "globex", "globex"},
+		{"context missing", otelfuego.TenantFromContext(tenantKey{}), "example.com", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Host = tt.host
+			if tt.ctxValue != nil {
+				req = req.WithContext(context.WithValue(req.Context(), tenantKey{}, tt.ctxValue))
+			}
+			if got := tt.extract(req); got != tt.expected {
+				t.Errorf("Expected tenant '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package otelfuego
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	throughputKey        = attribute.Key("http.response.throughput_bytes_per_s")
+	progressBytesSentKey = attribute.Key("otelfuego.response.bytes_sent")
+)
+
+// responseThroughput configures the throughput recorded for large responses
+type responseThroughput struct {
+	minBytes         int
+	progressInterval time.Duration
+}
+
+// recordProgress adds an "http.response.progress" event to the span of the response when the progress
+// interval elapsed since the previous event, or since the header was written
+func (rw *responseWriter) recordProgress() {
+	now := time.Now()
+	if rw.lastProgress.IsZero() {
+		rw.lastProgress = rw.firstByte
+	}
+	if now.Sub(rw.lastProgress) < rw.progressInterval {
+		return
+	}
+	rw.lastProgress = now
+	rw.span.AddEvent("http.response.progress",
+		trace.WithTimestamp(now),
+		trace.WithAttributes(progressBytesSentKey.Int(rw.bytesWritten)),
+	)
+}
+
+// appendThroughputAttributes appends the rate at which the body of a response of at least minBytes
+// bytes was sent, from the time its header was written until now
+func (rw *responseWriter) appendThroughputAttributes(attrs []attribute.KeyValue, minBytes int, now time.Time) []attribute.KeyValue {
+	if rw.bytesWritten < minBytes || rw.firstByte.IsZero() {
+		return attrs
+	}
+	if d := now.Sub(rw.firstByte); d > 0 {
+		attrs = append(attrs, throughputKey.Float64(float64(rw.bytesWritten)/d.Seconds()))
+	}
+	return attrs
+}
@@ -0,0 +1,70 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithResponseThroughput(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	chunk := strings.Repeat("x", 1000)
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithResponseThroughput(2000, 10*time.Millisecond),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunks := 1
+		if r.URL.Path == "/large" {
+			chunks = 3
+		}
+		for i := 0; i < chunks; i++ {
+			if i > 0 {
+				time.Sleep(15 * time.Millisecond)
+			}
+			_, _ = w.Write([]byte(chunk))
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/large", nil))
+	span := exporter.GetSpans()[0]
+	attrs := attribute.NewSet(span.Attributes...)
+	v, _ := attrs.Value("http.response.throughput_bytes_per_s")
+	// 3000 bytes sent over at least 30ms
+	if got := v.AsFloat64(); got <= 0 || got > 100_000 {
+		t.Errorf("Expected a throughput of at most 100000 bytes/s, got %v", got)
+	}
+	var progress []int64
+	for _, e := range span.Events {
+		if e.Name == "http.response.progress" {
+			sent := attribute.NewSet(e.Attributes...)
+			v, _ := sent.Value("otelfuego.response.bytes_sent")
+			progress = append(progress, v.AsInt64())
+		}
+	}
+	if len(progress) != 2 || progress[0] != 2000 || progress[1] != 3000 {
+		t.Errorf("Expected progress events after 2000 and 3000 bytes, got %v", progress)
+	}
+
+	// Responses below the threshold get no throughput
+	exporter.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/small", nil))
+	attrs = attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+	if attrs.HasValue("http.response.throughput_bytes_per_s") {
+		t.Error("Expected no throughput for small responses")
+	}
+}
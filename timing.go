@@ -0,0 +1,40 @@
+package otelfuego
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	timingBeforeKey  = attribute.Key("otelfuego.timing.before_ms")
+	timingHandlerKey = attribute.Key("otelfuego.timing.handler_ms")
+	timingAfterKey   = attribute.Key("otelfuego.timing.after_ms")
+)
+
+// requestTimings are the timestamps delimiting the phases of a request in the middleware
+type requestTimings struct {
+	entry, handlerStart, handlerEnd time.Time
+}
+
+// attributes returns the time spent before, in and after the next handler, the last one ending now
+func (t requestTimings) attributes(now time.Time) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		timingBeforeKey.Float64(milliseconds(t.handlerStart.Sub(t.entry))),
+		timingHandlerKey.Float64(milliseconds(t.handlerEnd.Sub(t.handlerStart))),
+		timingAfterKey.Float64(milliseconds(now.Sub(t.handlerEnd))),
+	}
+}
+
+// overhead returns the time spent in the middleware rather than in the next handler, the request
+// ending now. It is 0 when the next handler did not return.
+func (t requestTimings) overhead(now time.Time) time.Duration {
+	if t.handlerEnd.IsZero() {
+		return 0
+	}
+	return t.handlerStart.Sub(t.entry) + now.Sub(t.handlerEnd)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,51 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithTimingAttributes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithTimingAttributes(),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports", nil))
+
+	span := exporter.GetSpans()[0]
+	attrs := attribute.NewSet(span.Attributes...)
+	before, _ := attrs.Value("otelfuego.timing.before_ms")
+	handled, _ := attrs.Value("otelfuego.timing.handler_ms")
+	after, _ := attrs.Value("otelfuego.timing.after_ms")
+
+	if handled.AsFloat64() < 10 {
+		t.Errorf("Expected handler time of at least 10ms, got %fms", handled.AsFloat64())
+	}
+	if before.Type() != attribute.FLOAT64 || after.Type() != attribute.FLOAT64 {
+		t.Fatalf("Expected before and after timings, got %v and %v", before, after)
+	}
+	total := float64(span.EndTime.Sub(span.StartTime)) / float64(time.Millisecond)
+	if sum := before.AsFloat64() + handled.AsFloat64() + after.AsFloat64(); sum > total+1 {
+		t.Errorf("Expected timings to add up to at most the span duration %fms, got %fms", total, sum)
+	}
+}
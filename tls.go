@@ -0,0 +1,39 @@
+package otelfuego
+
+import (
+	"crypto/tls"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+)
+
+// appendTLSAttributes appends the protocol version and cipher suite of the TLS connection state, and
+// the subject of the client certificate when clientSubject is set, so legacy TLS usage can be tracked
+// per route
+func appendTLSAttributes(attrs []attribute.KeyValue, state *tls.ConnectionState, clientSubject bool) []attribute.KeyValue {
+	if version := tlsProtocolVersion(state.Version); version != "" {
+		attrs = append(attrs, semconv.TLSProtocolNameTLS, semconv.TLSProtocolVersion(version))
+	}
+	if state.CipherSuite != 0 {
+		attrs = append(attrs, semconv.TLSCipher(tls.CipherSuiteName(state.CipherSuite)))
+	}
+	if clientSubject && len(state.PeerCertificates) > 0 {
+		attrs = append(attrs, semconv.TLSClientSubject(state.PeerCertificates[0].Subject.String()))
+	}
+	return attrs
+}
+
+// tlsProtocolVersion returns the TLS version in the semconv format, e.g. "1.3"
+func tlsProtocolVersion(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "1.0"
+	case tls.VersionTLS11:
+		return "1.1"
+	case tls.VersionTLS12:
+		return "1.2"
+	case tls.VersionTLS13:
+		return "1.3"
+	}
+	return ""
+}
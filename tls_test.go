@@ -0,0 +1,72 @@
+package otelfuego_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_TLSAttributes(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("GET", "https://api.example.com/users", nil)
+		req.TLS.Version = tls.VersionTLS11
+		req.TLS.CipherSuite = tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA
+		req.TLS.PeerCertificates = []*x509.Certificate{{Subject: pkix.Name{CommonName: "billing", Organization: []string{"Example"}}}}
+		return req
+	}
+
+	tests := []struct {
+		name        string
+		opts        []otelfuego.Option
+		wantSubject string
+	}{
+		{"default", nil, ""},
+		{"client subject", []otelfuego.Option{otelfuego.WithTLSClientSubject()}, "CN=billing,O=Example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			opts := append([]otelfuego.Option{otelfuego.WithTracerProvider(tp)}, tt.opts...)
+			otelfuego.Middleware("test-service", opts...)(noop).ServeHTTP(httptest.NewRecorder(), newRequest())
+
+			attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+			for key, want := range map[attribute.Key]string{
+				"tls.protocol.name":    "tls",
+				"tls.protocol.version": "1.1",
+				"tls.cipher":           "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+				"tls.client.subject":   tt.wantSubject,
+			} {
+				if v, _ := attrs.Value(key); v.AsString() != want {
+					t.Errorf("Expected %s %q, got %q", key, want, v.AsString())
+				}
+			}
+		})
+	}
+
+	// Plain HTTP requests get no TLS attributes
+	exporter.Reset()
+	otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))(noop).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+	attrs := attribute.NewSet(exporter.GetSpans()[0].Attributes...)
+	if attrs.HasValue("tls.protocol.version") {
+		t.Error("Expected no TLS attributes for plain HTTP requests")
+	}
+}
@@ -0,0 +1,21 @@
+package otelfuego
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// mutateTraceState gives the parent span context in ctx the trace state returned by mutate for r.
+// Samplers pass the trace state of the parent on to the span they start, so it is carried by the
+// request span and propagated with it. Without a parent, the trace state is set on an invalid span
+// context, which the SDK samplers also pass on to the new root span.
+func mutateTraceState(ctx context.Context, r *http.Request, mutate func(*http.Request, trace.TraceState) trace.TraceState) context.Context {
+	parent := trace.SpanContextFromContext(ctx)
+	ts := mutate(r, parent.TraceState())
+	if ts.String() == parent.TraceState().String() {
+		return ctx
+	}
+	return trace.ContextWithSpanContext(ctx, parent.WithTraceState(ts))
+}
@@ -0,0 +1,77 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware_WithTraceStateMutator(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	var propagated string
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithPropagators(propagation.TraceContext{}),
+		otelfuego.WithTraceStateMutator(func(r *http.Request, ts trace.TraceState) trace.TraceState {
+			updated, err := ts.Insert("acme", "tier:"+r.Header.Get("X-Plan"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return updated
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := http.Header{}
+		otelfuego.Inject(r.Context(), header)
+		propagated = header.Get("Tracestate")
+	}))
+
+	tests := []struct {
+		name        string
+		traceparent string
+		tracestate  string
+		want        string
+	}{
+		{"root span", "", "", "acme=tier:pro"},
+		{"remote parent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "other=1", "acme=tier:pro,other=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-Plan", "pro")
+			if tt.traceparent != "" {
+				req.Header.Set("Traceparent", tt.traceparent)
+				req.Header.Set("Tracestate", tt.tracestate)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			if got := spans[0].SpanContext.TraceState().String(); got != tt.want {
+				t.Errorf("Expected span trace state %q, got %q", tt.want, got)
+			}
+			if propagated != tt.want {
+				t.Errorf("Expected propagated trace state %q, got %q", tt.want, propagated)
+			}
+			if tt.traceparent != "" && spans[0].Parent.SpanID().String() != "00f067aa0ba902b7" {
+				t.Errorf("Expected span to keep its remote parent, got %s", spans[0].Parent.SpanID())
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package otelfuego
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transport is an http.RoundTripper creating a client span for each request
+type transport struct {
+	base        http.RoundTripper
+	cfg         *config
+	tracer      trace.Tracer
+	propagators propagation.TextMapPropagator
+}
+
+// NewTransport returns an http.RoundTripper wrapping base, or http.DefaultTransport if nil, that
+// creates a client span for each request as a child of the span in the request context, and
+// propagates the trace context in the request headers. The tracer providers and propagators are
+// configured with the options of the middleware, WithFilter excludes requests from tracing, and
+// WithClientTrace records connection setup; other options only apply to servers.
+//
+// Example:
+//
+//	client := &http.Client{Transport: otelfuego.NewTransport(nil)}
+//	req, _ := http.NewRequestWithContext(c.Context(), "GET", "https://api.example.com/users", nil)
+//	resp, err := client.Do(req)
+func NewTransport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	cfg := newConfig(opts...)
+	if err := cfg.validate(); err != nil {
+		cfg.handleError(err)
+	}
+	return &transport{
+		base:        base,
+		cfg:         newSelfMetrics(cfg).guardHooks(cfg),
+		tracer:      tracerFor(cfg),
+		propagators: propagatorsFor(cfg),
+	}
+}
+
+// WrapClient returns a copy of client, or of http.DefaultClient if nil, whose transport is wrapped
+// with NewTransport, so that code sharing a client traces its outgoing requests without changes. The
+// client passed is not modified.
+//
+// Example:
+//
+//	client := otelfuego.WrapClient(sharedClient)
+func WrapClient(client *http.Client, opts ...Option) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	wrapped := *client
+	wrapped.Transport = NewTransport(client.Transport, opts...)
+	return &wrapped
+}
+
+// RoundTrip traces req, sending a copy of it carrying the trace context
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.cfg.Filter != nil && !t.cfg.Filter(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), spanNameMethod(req.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(clientRequestAttributes(req)...),
+	)
+	defer span.End()
+
+	// A RoundTripper must not modify the request
+	if t.cfg.ClientTrace {
+		ctx = httptrace.WithClientTrace(ctx, newClientTrace(span))
+	}
+	req = req.Clone(ctx)
+	t.propagators.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.SetAttributes(semconv.ErrorTypeKey.String(fmt.Sprintf("%T", err)))
+		recordError(span, err, t.cfg.MaxAttributeValueLength)
+		span.SetStatus(codes.Error, truncateValue(err.Error(), t.cfg.MaxAttributeValueLength))
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCodeKey.Int(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetAttributes(semconv.ErrorTypeKey.String(strconv.Itoa(resp.StatusCode)))
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// clientRequestAttributes returns the semantic convention attributes of an outgoing request. The
+// user info of the URL is redacted.
+func clientRequestAttributes(req *http.Request) []attribute.KeyValue {
+	u := *req.URL
+	u.User = nil
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLFullKey.String(u.String()),
+	}
+	host, port := splitHostPort(req.URL.Host)
+	if host != "" {
+		attrs = append(attrs, semconv.ServerAddressKey.String(host))
+	}
+	if port == 0 {
+		switch req.URL.Scheme {
+		case "https":
+			port = 443
+		case "http":
+			port = 80
+		}
+	}
+	if port > 0 {
+		attrs = append(attrs, semconv.ServerPortKey.Int(port))
+	}
+	return attrs
+}
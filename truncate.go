@@ -0,0 +1,48 @@
+package otelfuego
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.27.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// truncateValue shortens s to at most limit bytes without splitting a UTF-8 sequence. A limit of 0
+// disables truncation.
+func truncateValue(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
+
+// attributeValueLimit returns the attribute value length limit configured for the request in ctx,
+// or 0 outside of the middleware
+func attributeValueLimit(ctx context.Context) int {
+	if state := requestStateFromContext(ctx); state != nil {
+		return state.cfg.MaxAttributeValueLength
+	}
+	return 0
+}
+
+// recordError records err as an exception event on span like span.RecordError, with the exception
+// message truncated to limit bytes
+func recordError(span trace.Span, err error, limit int, attrs ...attribute.KeyValue) {
+	msg := err.Error()
+	if limit <= 0 || len(msg) <= limit {
+		span.RecordError(err, trace.WithAttributes(attrs...))
+		return
+	}
+	exception := make([]attribute.KeyValue, 0, 2+len(attrs))
+	exception = append(exception,
+		semconv.ExceptionTypeKey.String(fmt.Sprintf("%T", err)),
+		semconv.ExceptionMessageKey.String(truncateValue(msg, limit)),
+	)
+	span.AddEvent(semconv.ExceptionEventName, trace.WithAttributes(append(exception, attrs...)...))
+}
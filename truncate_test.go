@@ -0,0 +1,69 @@
+package otelfuego_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware_WithMaxAttributeValueLength(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+		otelfuego.WithCapturedRequestHeaders("X-Request-ID"),
+		otelfuego.WithMaxAttributeValueLength(8),
+	)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otelfuego.RecordError(r.Context(), errors.New(strings.Repeat("x", 1000)))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest("GET", "/search?q="+strings.Repeat("a", 1000), nil)
+	// The 8th and 9th bytes form a single rune, which must not be split
+	req.Header.Set("User-Agent", "Mozillaé/5.0")
+	req.Header.Set("X-Request-ID", "short")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	attrs := attribute.NewSet(spans[0].Attributes...)
+	if v, _ := attrs.Value("user_agent.original"); v.AsString() != "Mozilla" {
+		t.Errorf("Expected user agent 'Mozilla', got '%s'", v.AsString())
+	}
+	if v, _ := attrs.Value("url.query"); v.AsString() != "q=aaaaaa" {
+		t.Errorf("Expected query 'q=aaaaaa', got '%s'", v.AsString())
+	}
+	if v, _ := attrs.Value("http.request.header.x-request-id"); len(v.AsStringSlice()) != 1 || v.AsStringSlice()[0] != "short" {
+		t.Errorf("Expected short header values to be kept, got %v", v.AsStringSlice())
+	}
+
+	events := spans[0].Events
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("Expected 1 exception event, got %v", events)
+	}
+	event := attribute.NewSet(events[0].Attributes...)
+	if v, _ := event.Value("exception.message"); v.AsString() != "xxxxxxxx" {
+		t.Errorf("Expected truncated exception message, got '%s'", v.AsString())
+	}
+	if v, _ := event.Value("exception.type"); v.AsString() != "*errors.errorString" {
+		t.Errorf("Expected exception type '*errors.errorString', got '%s'", v.AsString())
+	}
+}
@@ -0,0 +1,146 @@
+package otelfuego
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewMiddleware returns a middleware like Middleware, but validates the options first and returns an
+// error describing every invalid option: nil hooks, negative limits, invalid patterns or header names,
+// and conflicting settings. Middleware applies the same options permissively and reports the problems
+// to the global OpenTelemetry error handler instead.
+//
+// Example:
+//
+//	middleware, err := otelfuego.NewMiddleware("my-service", opts...)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	server.Use(middleware)
+func NewMiddleware(service string, opts ...Option) (func(http.Handler) http.Handler, error) {
+	cfg := newConfig(opts...)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return newMiddleware(service, cfg).handler, nil
+}
+
+// validate returns an error listing the problems of the configuration, or nil if there are none
+func (c *config) validate() error {
+	errs := append([]error(nil), c.invalid...)
+
+	if c.MaxLogEvents < 0 {
+		errs = append(errs, fmt.Errorf("WithMaxLogEvents: %d must not be negative", c.MaxLogEvents))
+	}
+	if c.MaxMetricTenants < 0 {
+		errs = append(errs, fmt.Errorf("WithMaxMetricTenants: %d must not be negative", c.MaxMetricTenants))
+	}
+	if c.MaxSpanNames < 0 {
+		errs = append(errs, fmt.Errorf("WithMaxSpanNames: %d must not be negative", c.MaxSpanNames))
+	}
+	if c.MaxAttributeValueLength < 0 {
+		errs = append(errs, fmt.Errorf("WithMaxAttributeValueLength: %d must not be negative", c.MaxAttributeValueLength))
+	}
+	if c.ErrorResponseBodyCapture < 0 {
+		errs = append(errs, fmt.Errorf("WithErrorResponseBodyCapture: %d must not be negative", c.ErrorResponseBodyCapture))
+	}
+	if c.FailedRequestBodyCapture != nil && c.FailedRequestBodyCapture.limit <= 0 {
+		errs = append(errs, fmt.Errorf("WithFailedRequestBodyCapture: %d must be positive", c.FailedRequestBodyCapture.limit))
+	}
+	if c.SlowestRequests < 0 {
+		errs = append(errs, fmt.Errorf("WithSlowestRequests: %d must not be negative", c.SlowestRequests))
+	}
+	if c.MaxSpansPerSecond < 0 {
+		errs = append(errs, fmt.Errorf("WithMaxSpansPerSecond: %d must not be negative", c.MaxSpansPerSecond))
+	}
+	for i, b := range c.DurationBoundaries {
+		if math.IsNaN(b) || math.IsInf(b, 0) || (i > 0 && b <= c.DurationBoundaries[i-1]) {
+			errs = append(errs, fmt.Errorf("WithDurationHistogramBoundaries: %v must be finite and increasing", c.DurationBoundaries))
+			break
+		}
+	}
+	if c.LatencyFlags != nil {
+		if c.LatencyFlags.slow <= 0 {
+			errs = append(errs, fmt.Errorf("WithLatencyFlags: threshold %v must be positive", c.LatencyFlags.slow))
+		}
+		for route, threshold := range c.LatencyFlags.routes {
+			if threshold <= 0 {
+				errs = append(errs, fmt.Errorf("WithLatencyFlags: threshold %v of %q must be positive", threshold, route))
+			}
+		}
+	}
+
+	if c.SpanKind < trace.SpanKindUnspecified || c.SpanKind > trace.SpanKindConsumer {
+		errs = append(errs, fmt.Errorf("WithSpanKind: unknown kind %d", c.SpanKind))
+	}
+	if c.Semconv < 0 || c.Semconv > SemconvDup {
+		errs = append(errs, fmt.Errorf("WithSemconvMode: unknown mode %d", c.Semconv))
+	}
+	if c.Nested < NestedSkip || c.Nested > NestedServer {
+		errs = append(errs, fmt.Errorf("WithNestedMode: unknown mode %d", c.Nested))
+	}
+	for _, a := range c.MetricAttributes {
+		if a < MetricMethod || a > MetricStatusClass {
+			errs = append(errs, fmt.Errorf("WithMetricAttributes: unknown attribute %d", a))
+		}
+	}
+	if c.GraphQLPath != "" && !strings.HasPrefix(c.GraphQLPath, "/") {
+		errs = append(errs, fmt.Errorf("WithGraphQL: path %q must start with /", c.GraphQLPath))
+	}
+	if c.OpenAPIMetadata && c.OpenAPIOperations == nil {
+		errs = append(errs, errors.New("WithOpenAPIMetadata: requires WithOpenAPIOperations"))
+	}
+	if c.customCorrelationIDDecoder && len(c.CorrelationHeaders) == 0 {
+		errs = append(errs, errors.New("WithCorrelationIDDecoder: requires WithCorrelationHeaderLinks"))
+	}
+	if c.FailedRequestBodyStatuses != nil && c.FailedRequestBodyCapture == nil {
+		errs = append(errs, errors.New("WithFailedRequestBodyStatuses: requires WithFailedRequestBodyCapture"))
+	}
+	if c.MaxMetricTenants != defaultMaxMetricTenants && c.TenantExtractor == nil {
+		errs = append(errs, errors.New("WithMaxMetricTenants: requires WithTenantExtractor"))
+	}
+	if c.PreserveStrippedContext && c.StripIncomingContext == nil {
+		errs = append(errs, errors.New("WithStrippedContextAttributes: requires WithStripIncomingContext"))
+	}
+
+	for _, h := range c.CapturedRequestHeaders {
+		if !validHeaderName(h.name) {
+			errs = append(errs, fmt.Errorf("WithCapturedRequestHeaders: %q is not a valid header name", h.name))
+		}
+	}
+	for _, name := range c.CorrelationHeaders {
+		if !validHeaderName(name) {
+			errs = append(errs, fmt.Errorf("WithCorrelationHeaderLinks: %q is not a valid header name", name))
+		}
+	}
+	if c.RequestIDHeader != "" && !validHeaderName(c.RequestIDHeader) {
+		errs = append(errs, fmt.Errorf("WithRequestID: %q is not a valid header name", c.RequestIDHeader))
+	}
+	for _, name := range c.QueueTimeHeaders {
+		if !validHeaderName(name) {
+			errs = append(errs, fmt.Errorf("WithQueueTime: %q is not a valid header name", name))
+		}
+	}
+	names := make(map[string]bool, len(c.Experiments))
+	for _, e := range c.Experiments {
+		switch {
+		case e.Name == "":
+			errs = append(errs, errors.New("WithExperiments: name is required"))
+		case names[e.Name]:
+			errs = append(errs, fmt.Errorf("WithExperiments: %q is listed more than once", e.Name))
+		case e.Header == "" && e.Cookie == "":
+			errs = append(errs, fmt.Errorf("WithExperiments: %q needs a header or a cookie", e.Name))
+		}
+		names[e.Name] = true
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("otelfuego: invalid options: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package otelfuego_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestNewMiddleware_Validation(t *testing.T) {
+	middleware, err := otelfuego.NewMiddleware("test-service",
+		otelfuego.WithTracerProvider(sdktrace.NewTracerProvider()),
+		otelfuego.WithFilter(otelfuego.HealthCheckFilter()),
+		otelfuego.WithMaxLogEvents(8),
+	)
+	if err != nil || middleware == nil {
+		t.Fatalf("Expected valid options to be accepted, got %v", err)
+	}
+
+	_, err = otelfuego.NewMiddleware("test-service",
+		otelfuego.WithFilter(nil),
+		otelfuego.WithSpanNameFormatter(nil),
+		otelfuego.WithMaxLogEvents(-1),
+		otelfuego.WithMaxSpansPerSecond(-5),
+		otelfuego.WithLatencyFlags(0, nil),
+		otelfuego.WithRouteSampler("/users/{id", sdktrace.AlwaysSample()),
+		otelfuego.WithNestedMode(otelfuego.NestedMode(7)),
+		otelfuego.WithStrippedContextAttributes(),
+		otelfuego.WithCapturedRequestHeaders("X Bad"),
+		otelfuego.WithDurationHistogramBoundaries([]float64{0.1, 0.05}),
+		otelfuego.WithQueueTime("X Start"),
+		otelfuego.WithStripIncomingState(nil),
+		otelfuego.WithCarrierFactory(nil),
+		otelfuego.WithErrorResponseBodyCapture(-1),
+		otelfuego.WithFailedRequestBodyCapture(0),
+		otelfuego.WithErrorFingerprint(nil),
+		otelfuego.WithErrorHandler(nil),
+		otelfuego.WithOpenAPIMetadata(),
+		otelfuego.WithResponseThroughput(0, -time.Second),
+		otelfuego.WithLongRequestEvents(0),
+		otelfuego.WithInstrumentationScope("", ""),
+		otelfuego.WithLazyAttributes(nil),
+		otelfuego.WithTraceStateMutator(nil),
+		otelfuego.WithCorrelationHeaderLinks("X Correlation"),
+		otelfuego.WithRequestID("X Request"),
+		otelfuego.WithMetricAttributes(otelfuego.MetricAttribute(9)),
+		otelfuego.WithRouteAttributes(map[string][]attribute.KeyValue{"users": nil, "example.com/users": nil}),
+		otelfuego.WithAttributes(attribute.String("", "blue")),
+		otelfuego.WithFailedRequestBodyStatuses(302),
+		otelfuego.WithMaxMetricTenants(-1),
+	)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, want := range []string{
+		"WithFilter: nil filter",
+		"WithSpanNameFormatter: nil formatter",
+		"WithMaxLogEvents: -1",
+		"WithMaxSpansPerSecond: -5",
+		"WithLatencyFlags: threshold 0s",
+		`WithRouteSampler: invalid pattern "/users/{id"`,
+		"WithNestedMode: unknown mode 7",
+		"WithStrippedContextAttributes: requires WithStripIncomingContext",
+		`WithCapturedRequestHeaders: "X Bad"`,
+		"WithDurationHistogramBoundaries: [0.1 0.05]",
+		`WithQueueTime: "X Start"`,
+		"WithStripIncomingState: nil function",
+		"WithCarrierFactory: nil factory",
+		"WithErrorResponseBodyCapture: -1",
+		"WithFailedRequestBodyCapture: 0 must be positive",
+		"WithErrorFingerprint: nil fingerprinter",
+		"WithErrorHandler: nil handler",
+		"WithOpenAPIMetadata: requires WithOpenAPIOperations",
+		"WithResponseThroughput: 0 bytes must be positive",
+		"WithResponseThroughput: interval -1s must not be negative",
+		"WithLongRequestEvents: interval 0s must be positive",
+		"WithInstrumentationScope: empty name",
+		"WithLazyAttributes: nil function",
+		"WithTraceStateMutator: nil function",
+		`WithCorrelationHeaderLinks: "X Correlation"`,
+		`WithRequestID: "X Request"`,
+		"WithMetricAttributes: unknown attribute 9",
+		`WithRouteAttributes: "users" is not a route pattern`,
+		`WithRouteAttributes: "example.com/users" is not a route pattern`,
+		`WithAttributes: invalid attribute ""`,
+		"WithFailedRequestBodyStatuses: 302 is not an error status",
+		"WithMaxMetricTenants: -1 must not be negative",
+		"WithMaxMetricTenants: requires WithTenantExtractor",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestMiddleware_InvalidOptionsArePermissive(t *testing.T) {
+	// Middleware keeps working with invalid options, falling back to the defaults
+	handler := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(sdktrace.NewTracerProvider()),
+		otelfuego.WithSpanNameFormatter(nil),
+		otelfuego.WithLatencyFlags(-time.Second, nil),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+}
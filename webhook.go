@@ -0,0 +1,49 @@
+package otelfuego
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	webhookVerifyOutcomeKey  = attribute.Key("webhook.verify.outcome")
+	webhookVerifyDurationKey = attribute.Key("webhook.verify.duration_ms")
+)
+
+// SignatureVerifier verifies the signature of an incoming webhook, for example an HMAC over
+// the request body or a JWT in a header. It returns a non-nil error when verification fails.
+type SignatureVerifier func(ctx context.Context) error
+
+// VerifySignature runs verifier inside a "webhook.verify" child span of the span in ctx,
+// recording the outcome ("valid" or "invalid") and the time spent verifying.
+// The error returned by verifier is returned unchanged.
+//
+// Example:
+//
+//	err := otelfuego.VerifySignature(r.Context(), func(ctx context.Context) error {
+//	    return verifyHMAC(secret, body, r.Header.Get("X-Signature"))
+//	})
+func VerifySignature(ctx context.Context, verifier SignatureVerifier) error {
+	ctx, span := tracerFromContext(ctx).Start(ctx, "webhook.verify", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	start := time.Now()
+	err := verifier(ctx)
+	elapsed := time.Since(start)
+
+	span.SetAttributes(webhookVerifyDurationKey.Float64(float64(elapsed) / float64(time.Millisecond)))
+	if err != nil {
+		span.SetAttributes(webhookVerifyOutcomeKey.String("invalid"))
+		limit := attributeValueLimit(ctx)
+		recordError(span, err, limit)
+		span.SetStatus(codes.Error, truncateValue(err.Error(), limit))
+		return err
+	}
+
+	span.SetAttributes(webhookVerifyOutcomeKey.String("valid"))
+	return nil
+}
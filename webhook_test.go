@@ -0,0 +1,84 @@
+package otelfuego_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestVerifySignature(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	middleware := otelfuego.Middleware("test-service",
+		otelfuego.WithTracerProvider(tp),
+	)
+
+	errInvalid := errors.New("signature mismatch")
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := otelfuego.VerifySignature(r.Context(), func(ctx context.Context) error {
+			if r.Header.Get("X-Signature") != "valid" {
+				return errInvalid
+			}
+			return nil
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+
+	tests := []struct {
+		signature string
+		outcome   string
+		status    codes.Code
+	}{
+		{signature: "valid", outcome: "valid", status: codes.Unset},
+		{signature: "forged", outcome: "invalid", status: codes.Error},
+	}
+
+	for _, tt := range tests {
+		exporter.Reset()
+
+		req := httptest.NewRequest("POST", "/webhooks/stripe", nil)
+		req.Header.Set("X-Signature", tt.signature)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+		if len(spans) != 2 {
+			t.Fatalf("Expected 2 spans, got %d", len(spans))
+		}
+
+		// Child spans end first
+		verify, server := spans[0], spans[1]
+		if verify.Name != "webhook.verify" {
+			t.Fatalf("Expected span name 'webhook.verify', got '%s'", verify.Name)
+		}
+		if verify.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Error("Expected webhook.verify to be a child of the server span")
+		}
+
+		attrs := attribute.NewSet(verify.Attributes...)
+		if v, _ := attrs.Value("webhook.verify.outcome"); v.AsString() != tt.outcome {
+			t.Errorf("Expected outcome '%s', got '%s'", tt.outcome, v.AsString())
+		}
+		if !attrs.HasValue("webhook.verify.duration_ms") {
+			t.Error("Expected webhook.verify.duration_ms attribute")
+		}
+		if verify.Status.Code != tt.status {
+			t.Errorf("Expected status %v, got %v", tt.status, verify.Status.Code)
+		}
+	}
+}
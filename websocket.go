@@ -0,0 +1,107 @@
+package otelfuego
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	webSocketMessageTypeKey      = attribute.Key("websocket.message.type")
+	webSocketMessageSizeKey      = attribute.Key("websocket.message.size")
+	webSocketMessageIDKey        = attribute.Key("websocket.message.id")
+	webSocketMessagesReceivedKey = attribute.Key("websocket.messages.received")
+	webSocketMessagesSentKey     = attribute.Key("websocket.messages.sent")
+)
+
+// webSocketMessageType names the RFC 6455 opcodes, which gorilla/websocket and most other WebSocket
+// libraries use as message types
+func webSocketMessageType(messageType int) string {
+	switch messageType {
+	case 1:
+		return "text"
+	case 2:
+		return "binary"
+	case 8:
+		return "close"
+	case 9:
+		return "ping"
+	case 10:
+		return "pong"
+	}
+	return strconv.Itoa(messageType)
+}
+
+// WebSocket traces the messages of a WebSocket connection upgraded by an instrumented request. Message
+// spans are children of the span of the upgrade request, which lasts as long as the handler serving the
+// connection. Its methods are safe for concurrent use, as reads and writes usually happen in separate
+// goroutines.
+type WebSocket struct {
+	ctx      context.Context
+	tracer   trace.Tracer
+	received atomic.Int64
+	sent     atomic.Int64
+}
+
+// NewWebSocket returns a WebSocket tracing the messages of the connection upgraded by the request in
+// ctx. Outside of an instrumented request, message spans start traces of their own.
+//
+// Example:
+//
+//	conn, err := upgrader.Upgrade(w, r, nil)
+//	if err != nil {
+//	    return
+//	}
+//	ws := otelfuego.NewWebSocket(r.Context())
+//	defer ws.Close()
+func NewWebSocket(ctx context.Context) *WebSocket {
+	return &WebSocket{ctx: ctx, tracer: tracerFromContext(ctx)}
+}
+
+// StartReceive starts a "websocket receive" span for handling a message of the given type and size
+// read from the connection. messageType is the WebSocket opcode, such as websocket.TextMessage. The
+// caller must end the span.
+//
+// Example:
+//
+//	for {
+//	    messageType, data, err := conn.ReadMessage()
+//	    if err != nil {
+//	        return
+//	    }
+//	    ctx, span := ws.StartReceive(messageType, len(data))
+//	    handle(ctx, data)
+//	    span.End()
+//	}
+func (ws *WebSocket) StartReceive(messageType, size int) (context.Context, trace.Span) {
+	return ws.start("websocket receive", trace.SpanKindConsumer, ws.received.Add(1), messageType, size)
+}
+
+// StartSend starts a "websocket send" span for writing a message of the given type and size to the
+// connection. The caller must end the span.
+func (ws *WebSocket) StartSend(messageType, size int) (context.Context, trace.Span) {
+	return ws.start("websocket send", trace.SpanKindProducer, ws.sent.Add(1), messageType, size)
+}
+
+func (ws *WebSocket) start(name string, kind trace.SpanKind, id int64, messageType, size int) (context.Context, trace.Span) {
+	return ws.tracer.Start(ws.ctx, name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			webSocketMessageTypeKey.String(webSocketMessageType(messageType)),
+			webSocketMessageSizeKey.Int(size),
+			webSocketMessageIDKey.Int64(id),
+		),
+	)
+}
+
+// Close records the number of messages received and sent on the span of the upgrade request. It
+// should be called when the connection closes, before the handler returns.
+func (ws *WebSocket) Close() {
+	trace.SpanFromContext(ws.ctx).SetAttributes(
+		webSocketMessagesReceivedKey.Int64(ws.received.Load()),
+		webSocketMessagesSentKey.Int64(ws.sent.Load()),
+	)
+}
@@ -0,0 +1,82 @@
+package otelfuego_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pdrvsky/otelfuego"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWebSocket(t *testing.T) {
+	// Setup in-memory span exporter for testing
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	handler := otelfuego.Middleware("test-service", otelfuego.WithTracerProvider(tp))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ws := otelfuego.NewWebSocket(r.Context())
+			defer ws.Close()
+			for range 2 {
+				_, span := ws.StartReceive(1, 5)
+				span.End()
+			}
+			_, span := ws.StartSend(2, 12)
+			span.End()
+		}),
+	)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ws", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(spans))
+	}
+	server := spans[3]
+	tests := []struct {
+		name    string
+		kind    trace.SpanKind
+		msgType string
+		size    int64
+		id      int64
+	}{
+		{"websocket receive", trace.SpanKindConsumer, "text", 5, 1},
+		{"websocket receive", trace.SpanKindConsumer, "text", 5, 2},
+		{"websocket send", trace.SpanKindProducer, "binary", 12, 1},
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name != tt.name || span.SpanKind != tt.kind {
+			t.Errorf("Span %d: expected %s %v, got %s %v", i, tt.name, tt.kind, span.Name, span.SpanKind)
+		}
+		if span.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("Span %d: expected parent to be the upgrade request span", i)
+		}
+		attrs := attribute.NewSet(span.Attributes...)
+		if v, _ := attrs.Value("websocket.message.type"); v.AsString() != tt.msgType {
+			t.Errorf("Span %d: expected message type %q, got %q", i, tt.msgType, v.AsString())
+		}
+		if v, _ := attrs.Value("websocket.message.size"); v.AsInt64() != tt.size {
+			t.Errorf("Span %d: expected message size %d, got %d", i, tt.size, v.AsInt64())
+		}
+		if v, _ := attrs.Value("websocket.message.id"); v.AsInt64() != tt.id {
+			t.Errorf("Span %d: expected message id %d, got %d", i, tt.id, v.AsInt64())
+		}
+	}
+
+	attrs := attribute.NewSet(server.Attributes...)
+	if v, _ := attrs.Value("websocket.messages.received"); v.AsInt64() != 2 {
+		t.Errorf("Expected 2 received messages, got %d", v.AsInt64())
+	}
+	if v, _ := attrs.Value("websocket.messages.sent"); v.AsInt64() != 1 {
+		t.Errorf("Expected 1 sent message, got %d", v.AsInt64())
+	}
+}
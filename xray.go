@@ -0,0 +1,12 @@
+package otelfuego
+
+import "strings"
+
+// xrayHeader is the header carrying the AWS X-Ray trace context
+const xrayHeader = "X-Amzn-Trace-Id"
+
+// rootOnlyXRayHeader reports whether the header name with value is an X-Ray header without a parent,
+// which load balancers add to every request and is no trace context to continue
+func rootOnlyXRayHeader(name, value string) bool {
+	return name == xrayHeader && !strings.Contains(value, "Parent=")
+}